@@ -21,10 +21,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v32/github"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/sets"
+
 	"knative.dev/test-infra/pkg/ghutil"
 )
 
@@ -36,6 +44,7 @@ func addActionsCmd(root *cobra.Command) {
 
 	addActionsListCmd(cmd)
 	addActionsRunCmd(cmd)
+	addActionsWatchCmd(cmd)
 
 	root.AddCommand(cmd)
 }
@@ -61,12 +70,10 @@ func addActionsListCmd(root *cobra.Command) {
 			}
 
 			for _, r := range repos {
-				or := strings.Split(r, "/")
-				if len(or) != 2 {
-					return fmt.Errorf("unexpected format %q, expected %q", r, "org/repo")
+				org, repo, err := splitOrgRepo(r)
+				if err != nil {
+					return err
 				}
-				org := or[0]
-				repo := or[1]
 
 				workflows, err := gh.ListWorkflows(org, repo)
 				if err != nil {
@@ -108,20 +115,54 @@ func addActionsRunCmd(root *cobra.Command) {
 		ref        string
 		inputs     string
 		workflowID int64
+		wait       bool
+		interval   time.Duration
+		timeout    time.Duration
+		logsDir    string
+		orgs       []string
+		parallel   int
+		dryRun     bool
 		// TODO: interactive inputs based on workflow file config.
 	)
 
 	var cmd = &cobra.Command{
-		Use:   "run org/repo --query OneResult",
+		Use:   "run [org/repo | --org org] --query OneResult",
 		Short: "Run a GitHub Actions workflow for a given repository.",
-		Args:  cobra.ExactArgs(1),
+		Long: `
+The run command dispatches a GitHub Actions workflow for the given repository,
+on --ref or else on the default branch of the repository.
+
+With --wait, run locates the workflow run created by the dispatch on the ref
+and watches it the same way the watch command does, exiting with code 1 if it
+fails.
+
+With --org instead of a repository, the workflow matching --query is
+dispatched to every repository of each org, --parallel at a time. Archived
+repositories, and repositories without a matching workflow or where it is
+disabled, are skipped. A summary of the dispatched, skipped and failed
+repositories is printed, and the command exits with code 1 if any failed. --id
+and --wait are not supported with --org, and --parallel must be at least 1.
+
+With --dry-run, the workflows are located but not dispatched.
+`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			or := strings.Split(args[0], "/")
-			if len(or) != 2 {
-				return fmt.Errorf("unexpected format %q, expected %q", args[0], "org/repo")
+			if len(orgs) > 0 {
+				switch {
+				case len(args) > 0:
+					return errors.New("org/repo and --org are mutually exclusive")
+				case query == "":
+					return errors.New("--query is required with --org")
+				case workflowID != 0:
+					return errors.New("--id is not supported with --org, workflow IDs differ between repositories")
+				case wait:
+					return errors.New("--wait is not supported with --org")
+				case parallel < 1:
+					return fmt.Errorf("--parallel must be at least 1, got %d", parallel)
+				}
+			} else if len(args) == 0 {
+				return errors.New("requires org/repo or --org")
 			}
-			org := or[0]
-			repo := or[1]
 
 			gh, err := ghutil.NewGithubClient(tokenPath)
 			if err != nil {
@@ -135,47 +176,201 @@ func addActionsRunCmd(root *cobra.Command) {
 				}
 			}
 
-			if workflowID == 0 {
-				workflows, err := gh.ListWorkflows(org, repo)
+			if len(orgs) > 0 {
+				f := &fanOut{gh: gh, query: query, ref: ref, inputs: jsonInputs, concurrency: parallel, dryRun: dryRun}
+				results, err := f.run(orgs)
 				if err != nil {
 					return err
 				}
-				for _, w := range workflows {
-					if !queryByName(w, query) {
-						continue
-					}
-
-					if workflowID == 0 {
-						workflowID = w.GetID()
-					} else {
-						return fmt.Errorf("query %q matched more than one workflow, cancelling", query)
+				writeDispatchResults(cmd.OutOrStdout(), results, dryRun)
+				for _, r := range results {
+					if r.Result == failed {
+						return errors.New("unable to dispatch the workflow to some repositories")
 					}
 				}
+				return nil
+			}
+
+			org, repo, err := splitOrgRepo(args[0])
+			if err != nil {
+				return err
 			}
 
 			if workflowID == 0 {
-				return errors.New("unable to locate the workflow requested")
+				if workflowID, err = findWorkflowID(gh, org, repo, query); err != nil {
+					return err
+				}
+			}
+			if ref == "" {
+				r, err := gh.GetRepository(org, repo)
+				if err != nil {
+					return err
+				}
+				ref = r.GetDefaultBranch()
+			}
+			if dryRun {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "would dispatch workflow %d to %s/%s on %s\n", workflowID, org, repo, ref)
+				return nil
 			}
 
-			opts := github.CreateWorkflowDispatchEventRequest{
-				Ref:    ref,
-				Inputs: jsonInputs,
+			// Runs created by the dispatch have a creation time after this,
+			// allowing for some clock skew.
+			since := time.Now().Add(-dispatchSkew)
+			if err := gh.DispatchWorkflow(org, repo, workflowID, ref, jsonInputs); err != nil {
+				return err
+			}
+			if !wait {
+				return nil
 			}
 
-			_, err = gh.Client.Actions.CreateWorkflowDispatchEvent(context.Background(), org, repo, workflowID, opts)
-			return err
+			ctx := cmd.Context()
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			w := &runWatcher{gh: gh, org: org, repo: repo, interval: interval, logsDir: logsDir, out: cmd.OutOrStdout()}
+			run, err := w.findDispatchedRun(ctx, workflowID, ref, since)
+			if err != nil {
+				return err
+			}
+			return w.watch(ctx, run.GetID())
 		},
 	}
 
 	cmd.Flags().StringVarP(&tokenPath, "token-path", "t", "", "GitHub token file path.")
 	cmd.Flags().StringVarP(&query, "query", "q", "", "Search for a workflow by name.")
-	cmd.Flags().StringVar(&ref, "ref", "master", "Ref to run workflow from.") // This should be the default branch... but for now we use mostly master.
+	cmd.Flags().StringVar(&ref, "ref", "", "Ref to run workflow from. Defaults to the default branch of each repository.")
 	cmd.Flags().Int64Var(&workflowID, "id", 0, "Workflow ID.")
 	cmd.Flags().StringVar(&inputs, "inputs", "", "Workflow inputs.")
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait for the workflow run to complete, and exit with code 1 if it fails.")
+	cmd.Flags().StringArrayVar(&orgs, "org", nil, "Run the workflow for every repository of this GitHub org. Can be repeated.")
+	cmd.Flags().IntVar(&parallel, "parallel", 4, "Number of repositories to dispatch to concurrently with --org.")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Locate the workflows without dispatching them.")
+	addWatchFlags(cmd, &interval, &timeout, &logsDir)
+
+	root.AddCommand(cmd)
+}
+
+func addActionsWatchCmd(root *cobra.Command) {
+	var (
+		tokenPath  string
+		query      string
+		workflowID int64
+		runID      int64
+		interval   time.Duration
+		timeout    time.Duration
+		logsDir    string
+	)
+
+	var cmd = &cobra.Command{
+		Use:   "watch org/repo [--run-id ID | --query OneResult]",
+		Short: "Watch a GitHub Actions workflow run until it completes.",
+		Long: `
+The watch command polls a workflow run of the given repository until it
+completes, printing the conclusion of each job and step as they complete. The
+run is selected with --run-id, otherwise the most recent run of the workflow
+selected with --id or --query is watched.
+
+The command exits with code 0 if the run succeeds, or is neutral or skipped,
+and with code 1 otherwise. With --logs, the logs archive of the run is
+downloaded into the given directory once the run completes.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			org, repo, err := splitOrgRepo(args[0])
+			if err != nil {
+				return err
+			}
+
+			gh, err := ghutil.NewGithubClient(tokenPath)
+			if err != nil {
+				return err
+			}
+
+			if runID == 0 {
+				if workflowID == 0 {
+					if workflowID, err = findWorkflowID(gh, org, repo, query); err != nil {
+						return err
+					}
+				}
+				runs, err := gh.ListWorkflowRuns(org, repo, workflowID, "", "")
+				if err != nil {
+					return err
+				}
+				if len(runs) == 0 {
+					return fmt.Errorf("workflow %d has no runs", workflowID)
+				}
+				runID = runs[0].GetID()
+			}
+
+			ctx := cmd.Context()
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			w := &runWatcher{gh: gh, org: org, repo: repo, interval: interval, logsDir: logsDir, out: cmd.OutOrStdout()}
+			return w.watch(ctx, runID)
+		},
+	}
+
+	cmd.Flags().StringVarP(&tokenPath, "token-path", "t", "", "GitHub token file path.")
+	cmd.Flags().StringVarP(&query, "query", "q", "", "Search for a workflow by name.")
+	cmd.Flags().Int64Var(&workflowID, "id", 0, "Workflow ID.")
+	cmd.Flags().Int64Var(&runID, "run-id", 0, "Workflow run ID.")
+	addWatchFlags(cmd, &interval, &timeout, &logsDir)
 
 	root.AddCommand(cmd)
 }
 
+func addWatchFlags(cmd *cobra.Command, interval, timeout *time.Duration, logsDir *string) {
+	cmd.Flags().DurationVar(interval, "interval", 10*time.Second, "How often to poll the workflow run.")
+	cmd.Flags().DurationVar(timeout, "timeout", time.Hour, "How long to wait for the workflow run to complete, 0 for no timeout.")
+	cmd.Flags().StringVar(logsDir, "logs", "", "Directory to download the logs archive of the workflow run into.")
+}
+
+// splitOrgRepo splits "org/repo" into org and repo.
+func splitOrgRepo(s string) (string, string, error) {
+	or := strings.Split(s, "/")
+	if len(or) != 2 {
+		return "", "", fmt.Errorf("unexpected format %q, expected %q", s, "org/repo")
+	}
+	return or[0], or[1], nil
+}
+
+// findWorkflowID returns the ID of the only workflow of org/repo matching
+// query.
+func findWorkflowID(gh *ghutil.GithubClient, org, repo, query string) (int64, error) {
+	workflows, err := gh.ListWorkflows(org, repo)
+	if err != nil {
+		return 0, err
+	}
+
+	matches := matchWorkflows(workflows, query)
+	switch len(matches) {
+	case 0:
+		return 0, errors.New("unable to locate the workflow requested")
+	case 1:
+		return matches[0].GetID(), nil
+	default:
+		return 0, fmt.Errorf("query %q matched more than one workflow, cancelling", query)
+	}
+}
+
+// matchWorkflows returns the workflows with a name matching query.
+func matchWorkflows(workflows []*github.Workflow, query string) []*github.Workflow {
+	matches := make([]*github.Workflow, 0)
+	for _, w := range workflows {
+		if queryByName(w, query) {
+			matches = append(matches, w)
+		}
+	}
+	return matches
+}
+
 // queryByName returns true if the name of the workflow contains the query.
 // Query is case insensitive.
 func queryByName(workflow *github.Workflow, query string) bool {
@@ -186,3 +381,187 @@ func queryByName(workflow *github.Workflow, query string) bool {
 	name := strings.ToLower(workflow.GetName())
 	return strings.Contains(name, query)
 }
+
+// dispatchSkew is how much earlier than the dispatch a run created by it may
+// appear to be created, to allow for clock skew with GitHub.
+const dispatchSkew = 30 * time.Second
+
+// runWatcherClient is the part of the GitHub client used by runWatcher.
+type runWatcherClient interface {
+	ListWorkflowRuns(org, repo string, workflowID int64, branch, event string) ([]*github.WorkflowRun, error)
+	GetWorkflowRun(org, repo string, runID int64) (*github.WorkflowRun, error)
+	ListWorkflowJobs(org, repo string, runID int64) ([]*github.WorkflowJob, error)
+	GetWorkflowRunLogsURL(org, repo string, runID int64) (*url.URL, error)
+}
+
+// runWatcher polls a workflow run until it completes, writing the status of
+// its jobs and the conclusion of their steps as they change.
+type runWatcher struct {
+	gh       runWatcherClient
+	org      string
+	repo     string
+	interval time.Duration
+	logsDir  string
+	out      io.Writer
+}
+
+// findDispatchedRun polls for the run of a workflow created by a
+// workflow_dispatch event on ref since the given time. If more than one run
+// was created since, the oldest one is returned.
+func (w *runWatcher) findDispatchedRun(ctx context.Context, workflowID int64, ref string, since time.Time) (*github.WorkflowRun, error) {
+	for {
+		runs, err := w.gh.ListWorkflowRuns(w.org, w.repo, workflowID, ref, "workflow_dispatch")
+		if err != nil {
+			return nil, err
+		}
+		var found *github.WorkflowRun
+		// Runs are listed newest first.
+		for _, r := range runs {
+			if r.GetCreatedAt().Before(since) {
+				break
+			}
+			found = r
+		}
+		if found != nil {
+			return found, nil
+		}
+
+		if err := w.sleep(ctx); err != nil {
+			return nil, fmt.Errorf("unable to find the run of workflow %d on %s: %w", workflowID, ref, err)
+		}
+	}
+}
+
+// watch polls the run until it completes, optionally downloads its logs, and
+// returns an error if it did not succeed.
+func (w *runWatcher) watch(ctx context.Context, runID int64) error {
+	var run *github.WorkflowRun
+	headerPrinted := false
+	jobStatus := make(map[int64]string)
+	stepsDone := make(map[int64]sets.Int64)
+
+	for {
+		var err error
+		if run, err = w.gh.GetWorkflowRun(w.org, w.repo, runID); err != nil {
+			return err
+		}
+		if !headerPrinted {
+			_, _ = fmt.Fprintf(w.out, "run #%d (%s) %s\n", run.GetRunNumber(), run.GetStatus(), run.GetHTMLURL())
+			headerPrinted = true
+		}
+
+		jobs, err := w.gh.ListWorkflowJobs(w.org, w.repo, runID)
+		if err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			w.report(job, jobStatus, stepsDone)
+		}
+
+		if run.GetStatus() == "completed" {
+			break
+		}
+		if err := w.sleep(ctx); err != nil {
+			return fmt.Errorf("workflow run %d did not complete: %w", runID, err)
+		}
+	}
+
+	_, _ = fmt.Fprintf(w.out, "%s run #%d (%s)\n", conclusionGlyph(run.GetConclusion()), run.GetRunNumber(), run.GetConclusion())
+
+	if w.logsDir != "" {
+		if err := w.downloadLogs(runID); err != nil {
+			return err
+		}
+	}
+
+	if !successful(run.GetConclusion()) {
+		return fmt.Errorf("workflow run %d concluded with %q: %s", runID, run.GetConclusion(), run.GetHTMLURL())
+	}
+	return nil
+}
+
+// report writes the status of job if it changed, and the conclusion of each
+// of its steps completed since the last report.
+func (w *runWatcher) report(job *github.WorkflowJob, jobStatus map[int64]string, stepsDone map[int64]sets.Int64) {
+	if _, ok := stepsDone[job.GetID()]; !ok {
+		stepsDone[job.GetID()] = sets.NewInt64()
+	}
+	if jobStatus[job.GetID()] != job.GetStatus() && job.GetStatus() != "completed" {
+		jobStatus[job.GetID()] = job.GetStatus()
+		_, _ = fmt.Fprintf(w.out, "%s (%s)\n", job.GetName(), job.GetStatus())
+	}
+
+	for _, step := range job.Steps {
+		if step.GetStatus() != "completed" || stepsDone[job.GetID()].Has(step.GetNumber()) {
+			continue
+		}
+		stepsDone[job.GetID()].Insert(step.GetNumber())
+		_, _ = fmt.Fprintf(w.out, "  %s %s (%s)\n", conclusionGlyph(step.GetConclusion()), step.GetName(), step.GetConclusion())
+	}
+
+	if jobStatus[job.GetID()] != job.GetStatus() {
+		jobStatus[job.GetID()] = job.GetStatus()
+		_, _ = fmt.Fprintf(w.out, "%s %s (%s) %s\n", conclusionGlyph(job.GetConclusion()), job.GetName(), job.GetConclusion(), job.GetHTMLURL())
+	}
+}
+
+// downloadLogs downloads the logs archive of the run into logsDir.
+func (w *runWatcher) downloadLogs(runID int64) error {
+	u, err := w.gh.GetWorkflowRunLogsURL(w.org, w.repo, runID)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Get(u.String())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to download the logs of workflow run %d: %s", runID, resp.Status)
+	}
+
+	if err := os.MkdirAll(w.logsDir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(w.logsDir, fmt.Sprintf("%s-%s-%d.zip", w.org, w.repo, runID))
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(w.out, "logs:", path)
+	return nil
+}
+
+// sleep waits for the poll interval, or returns the error of ctx when it is
+// done first.
+func (w *runWatcher) sleep(ctx context.Context) error {
+	t := time.NewTimer(w.interval)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// successful returns true for the conclusions of a run, job or step that do
+// not fail it.
+func successful(conclusion string) bool {
+	switch conclusion {
+	case "success", "neutral", "skipped":
+		return true
+	}
+	return false
+}
+
+func conclusionGlyph(conclusion string) string {
+	if successful(conclusion) {
+		return "✔ "
+	}
+	return "✘ "
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/google/go-github/v32/github"
+
+	"knative.dev/test-infra/pkg/helpers"
+)
+
+// Results of dispatching a workflow to a repo of an org.
+const (
+	dispatched    = "dispatched"
+	wouldDispatch = "would dispatch"
+	skipped       = "skipped"
+	failed        = "failed"
+)
+
+// fanOutClient is the part of the GitHub client used by fanOut.
+type fanOutClient interface {
+	ListRepositories(org string) ([]*github.Repository, error)
+	ListWorkflows(org, repo string) ([]*github.Workflow, error)
+	DispatchWorkflow(org, repo string, workflowID int64, ref string, inputs map[string]interface{}) error
+}
+
+// fanOut dispatches workflows to many repos.
+type fanOut struct {
+	gh    fanOutClient
+	query string
+	// ref to dispatch the workflows on, the default branch of each repo if
+	// empty.
+	ref         string
+	inputs      map[string]interface{}
+	concurrency int
+	dryRun      bool
+}
+
+// dispatchResult is the result of dispatching a workflow to a single repo.
+type dispatchResult struct {
+	Repo     string
+	Result   string
+	Workflow string
+	Detail   string
+}
+
+// run dispatches the workflow matching the query to every repo of orgs, with
+// at most concurrency dispatches at a time. Archived repos and repos without a
+// matching workflow are skipped. The results keep the order of the repos.
+func (f *fanOut) run(orgs []string) ([]dispatchResult, error) {
+	type orgRepo struct {
+		org  string
+		repo *github.Repository
+	}
+	repos := make([]orgRepo, 0)
+	for _, org := range orgs {
+		rs, err := f.gh.ListRepositories(org)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rs {
+			repos = append(repos, orgRepo{org: org, repo: r})
+		}
+	}
+
+	results := make([]dispatchResult, len(repos))
+	helpers.ForEach(len(repos), f.concurrency, func(i int) {
+		results[i] = f.dispatch(repos[i].org, repos[i].repo)
+	})
+	return results, nil
+}
+
+// dispatch dispatches the workflow matching the query to a single repo of org.
+func (f *fanOut) dispatch(org string, repo *github.Repository) dispatchResult {
+	name := repo.GetName()
+	r := dispatchResult{Repo: org + "/" + name}
+	if repo.GetArchived() {
+		r.Result, r.Detail = skipped, "repository is archived"
+		return r
+	}
+
+	workflows, err := f.gh.ListWorkflows(org, name)
+	if err != nil {
+		r.Result, r.Detail = failed, err.Error()
+		return r
+	}
+	matches := matchWorkflows(workflows, f.query)
+	switch len(matches) {
+	case 0:
+		r.Result, r.Detail = skipped, "no matching workflow"
+		return r
+	case 1:
+	default:
+		r.Result, r.Detail = failed, fmt.Sprintf("query %q matched %d workflows", f.query, len(matches))
+		return r
+	}
+
+	w := matches[0]
+	r.Workflow = w.GetName()
+	if w.GetState() != "active" {
+		r.Result, r.Detail = skipped, fmt.Sprintf("workflow is %s", w.GetState())
+		return r
+	}
+	ref := f.ref
+	if ref == "" {
+		ref = repo.GetDefaultBranch()
+	}
+	if ref == "" {
+		r.Result, r.Detail = failed, "no ref to dispatch on, the repository has no default branch"
+		return r
+	}
+	r.Detail = "on " + ref
+	if f.dryRun {
+		r.Result = wouldDispatch
+		return r
+	}
+	if err := f.gh.DispatchWorkflow(org, name, w.GetID(), ref, f.inputs); err != nil {
+		r.Result, r.Detail = failed, err.Error()
+		return r
+	}
+	r.Result = dispatched
+	return r
+}
+
+// writeDispatchResults writes the results as a table, followed by the count of
+// each result.
+func writeDispatchResults(out io.Writer, results []dispatchResult, dryRun bool) {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "REPO\tRESULT\tWORKFLOW\tDETAIL")
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.Result]++
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Repo, r.Result, r.Workflow, r.Detail)
+	}
+	_ = tw.Flush()
+
+	result := dispatched
+	if dryRun {
+		result = wouldDispatch
+	}
+	_, _ = fmt.Fprintf(out, "\n%d %s, %d %s, %d %s\n", counts[result], result, counts[skipped], skipped, counts[failed], failed)
+}
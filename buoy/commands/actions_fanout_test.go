@@ -0,0 +1,166 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+)
+
+// fakeFanOutClient serves repos and workflows from maps, and records the
+// dispatches as "org/repo@ref".
+type fakeFanOutClient struct {
+	repos     map[string][]*github.Repository
+	workflows map[string][]*github.Workflow
+	failing   map[string]bool
+
+	mu         sync.Mutex
+	dispatches []string
+}
+
+func (c *fakeFanOutClient) ListRepositories(org string) ([]*github.Repository, error) {
+	repos, ok := c.repos[org]
+	if !ok {
+		return nil, fmt.Errorf("unknown org %s", org)
+	}
+	return repos, nil
+}
+
+func (c *fakeFanOutClient) ListWorkflows(org, repo string) ([]*github.Workflow, error) {
+	return c.workflows[org+"/"+repo], nil
+}
+
+func (c *fakeFanOutClient) DispatchWorkflow(org, repo string, _ int64, ref string, _ map[string]interface{}) error {
+	if c.failing[org+"/"+repo] {
+		return errors.New("dispatch failed")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dispatches = append(c.dispatches, org+"/"+repo+"@"+ref)
+	return nil
+}
+
+func fakeRepo(name, defaultBranch string, archived bool) *github.Repository {
+	return &github.Repository{Name: &name, DefaultBranch: &defaultBranch, Archived: &archived}
+}
+
+func fakeWorkflow(name, state string) *github.Workflow {
+	return &github.Workflow{ID: github.Int64(1), Name: &name, State: &state}
+}
+
+func newFakeFanOutClient() *fakeFanOutClient {
+	return &fakeFanOutClient{
+		repos: map[string][]*github.Repository{
+			"knative": {
+				fakeRepo("serving", "main", false),
+				fakeRepo("eventing", "master", false),
+				fakeRepo("build", "master", true),
+				fakeRepo("docs", "main", false),
+				fakeRepo("pkg", "main", false),
+				fakeRepo("client", "main", false),
+			},
+			"knative-sandbox": {
+				fakeRepo("net-istio", "main", false),
+			},
+		},
+		workflows: map[string][]*github.Workflow{
+			"knative/serving":           {fakeWorkflow("Update deps", "active")},
+			"knative/eventing":          {fakeWorkflow("Update deps", "active"), fakeWorkflow("Build", "active")},
+			"knative/build":             {fakeWorkflow("Update deps", "active")},
+			"knative/docs":              {fakeWorkflow("Build", "active")},
+			"knative/pkg":               {fakeWorkflow("Update deps", "disabled_manually")},
+			"knative/client":            {fakeWorkflow("Update deps", "active"), fakeWorkflow("Update deps (nightly)", "active")},
+			"knative-sandbox/net-istio": {fakeWorkflow("Update deps", "active")},
+		},
+		failing: map[string]bool{
+			"knative-sandbox/net-istio": true,
+		},
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	tests := map[string]struct {
+		orgs           []string
+		ref            string
+		dryRun         bool
+		wantResults    []dispatchResult
+		wantDispatches []string
+		wantErr        bool
+	}{
+		"default branches": {
+			orgs: []string{"knative", "knative-sandbox"},
+			wantResults: []dispatchResult{
+				{Repo: "knative/serving", Result: dispatched, Workflow: "Update deps", Detail: "on main"},
+				{Repo: "knative/eventing", Result: dispatched, Workflow: "Update deps", Detail: "on master"},
+				{Repo: "knative/build", Result: skipped, Detail: "repository is archived"},
+				{Repo: "knative/docs", Result: skipped, Detail: "no matching workflow"},
+				{Repo: "knative/pkg", Result: skipped, Workflow: "Update deps", Detail: "workflow is disabled_manually"},
+				{Repo: "knative/client", Result: failed, Detail: `query "update deps" matched 2 workflows`},
+				{Repo: "knative-sandbox/net-istio", Result: failed, Workflow: "Update deps", Detail: "dispatch failed"},
+			},
+			wantDispatches: []string{"knative/eventing@master", "knative/serving@main"},
+		},
+		"explicit ref": {
+			orgs: []string{"knative"},
+			ref:  "release-0.19",
+			wantResults: []dispatchResult{
+				{Repo: "knative/serving", Result: dispatched, Workflow: "Update deps", Detail: "on release-0.19"},
+				{Repo: "knative/eventing", Result: dispatched, Workflow: "Update deps", Detail: "on release-0.19"},
+				{Repo: "knative/build", Result: skipped, Detail: "repository is archived"},
+				{Repo: "knative/docs", Result: skipped, Detail: "no matching workflow"},
+				{Repo: "knative/pkg", Result: skipped, Workflow: "Update deps", Detail: "workflow is disabled_manually"},
+				{Repo: "knative/client", Result: failed, Detail: `query "update deps" matched 2 workflows`},
+			},
+			wantDispatches: []string{"knative/eventing@release-0.19", "knative/serving@release-0.19"},
+		},
+		"dry run": {
+			orgs:   []string{"knative-sandbox"},
+			dryRun: true,
+			wantResults: []dispatchResult{
+				{Repo: "knative-sandbox/net-istio", Result: wouldDispatch, Workflow: "Update deps", Detail: "on main"},
+			},
+			wantDispatches: nil,
+		},
+		"unknown org": {
+			orgs:    []string{"knative", "nope"},
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			gh := newFakeFanOutClient()
+			f := &fanOut{gh: gh, query: "update deps", ref: tt.ref, concurrency: 3, dryRun: tt.dryRun}
+			results, err := f.run(tt.orgs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.wantResults, results); diff != "" {
+				t.Error("run() results (-want, +got):", diff)
+			}
+			sort.Strings(gh.dispatches)
+			if diff := cmp.Diff(tt.wantDispatches, gh.dispatches); diff != "" {
+				t.Error("run() dispatches (-want, +got):", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+)
+
+// fakePoll is the state of the watched run returned by one poll.
+type fakePoll struct {
+	run  *github.WorkflowRun
+	jobs []*github.WorkflowJob
+}
+
+// fakeWatchClient serves the dispatched runs of a workflow by branch, and the
+// successive states of the watched run, the last one repeating.
+type fakeWatchClient struct {
+	dispatched map[string][]*github.WorkflowRun
+	polls      []fakePoll
+
+	calls    int
+	current  fakePoll
+	branches []string
+}
+
+func (c *fakeWatchClient) ListWorkflowRuns(_, _ string, _ int64, branch, _ string) ([]*github.WorkflowRun, error) {
+	c.branches = append(c.branches, branch)
+	if branch != "" {
+		return c.dispatched[branch], nil
+	}
+	var runs []*github.WorkflowRun
+	for _, r := range c.dispatched {
+		runs = append(runs, r...)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].GetCreatedAt().After(runs[j].GetCreatedAt().Time)
+	})
+	return runs, nil
+}
+
+func (c *fakeWatchClient) GetWorkflowRun(_, _ string, _ int64) (*github.WorkflowRun, error) {
+	c.current = c.polls[len(c.polls)-1]
+	if c.calls < len(c.polls) {
+		c.current = c.polls[c.calls]
+	}
+	c.calls++
+	return c.current.run, nil
+}
+
+func (c *fakeWatchClient) ListWorkflowJobs(_, _ string, _ int64) ([]*github.WorkflowJob, error) {
+	return c.current.jobs, nil
+}
+
+func (c *fakeWatchClient) GetWorkflowRunLogsURL(_, _ string, _ int64) (*url.URL, error) {
+	return nil, errors.New("no logs")
+}
+
+func fakeDispatchedRun(id int64, created time.Time) *github.WorkflowRun {
+	return &github.WorkflowRun{ID: &id, CreatedAt: &github.Timestamp{Time: created}}
+}
+
+func fakeRun(status, conclusion string) *github.WorkflowRun {
+	return &github.WorkflowRun{
+		ID:         github.Int64(1),
+		RunNumber:  github.Int(7),
+		Status:     &status,
+		Conclusion: &conclusion,
+		HTMLURL:    github.String("https://github.com/knative/serving/actions/runs/1"),
+	}
+}
+
+func fakeJob(status, conclusion string, steps ...*github.TaskStep) *github.WorkflowJob {
+	return &github.WorkflowJob{
+		ID:         github.Int64(2),
+		Name:       github.String("build"),
+		Status:     &status,
+		Conclusion: &conclusion,
+		HTMLURL:    github.String("https://github.com/knative/serving/runs/2"),
+		Steps:      steps,
+	}
+}
+
+func fakeStep(number int64, name, status, conclusion string) *github.TaskStep {
+	return &github.TaskStep{Number: &number, Name: &name, Status: &status, Conclusion: &conclusion}
+}
+
+func TestFindDispatchedRun(t *testing.T) {
+	now := time.Now()
+	since := now.Add(-20 * time.Second)
+	dispatched := map[string][]*github.WorkflowRun{
+		"main": {
+			fakeDispatchedRun(3, now),
+			fakeDispatchedRun(2, now.Add(-10*time.Second)),
+			fakeDispatchedRun(1, now.Add(-time.Minute)),
+		},
+		"release-0.19": {
+			fakeDispatchedRun(4, now.Add(-15*time.Second)),
+		},
+	}
+	tests := map[string]struct {
+		ref     string
+		want    int64
+		wantErr bool
+	}{
+		"oldest run since the dispatch": {
+			ref:  "main",
+			want: 2,
+		},
+		"run on another branch": {
+			ref:  "release-0.19",
+			want: 4,
+		},
+		"no run": {
+			ref:     "release-0.20",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			gh := &fakeWatchClient{dispatched: dispatched}
+			w := &runWatcher{gh: gh, org: "knative", repo: "serving", interval: time.Millisecond}
+			run, err := w.findDispatchedRun(ctx, 1, tt.ref, since)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findDispatchedRun() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && run.GetID() != tt.want {
+				t.Errorf("findDispatchedRun() = run %d, want %d", run.GetID(), tt.want)
+			}
+			for _, b := range gh.branches {
+				if b != tt.ref {
+					t.Errorf("findDispatchedRun() listed the runs of branch %q, want %q", b, tt.ref)
+				}
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	tests := map[string]struct {
+		polls   []fakePoll
+		timeout time.Duration
+		want    string
+		wantErr bool
+	}{
+		"queued then succeeded": {
+			polls: []fakePoll{
+				{run: fakeRun("queued", "")},
+				{run: fakeRun("queued", "")},
+				{run: fakeRun("in_progress", ""), jobs: []*github.WorkflowJob{
+					fakeJob("in_progress", "",
+						fakeStep(1, "checkout", "completed", "success"),
+						fakeStep(2, "test", "in_progress", "")),
+				}},
+				{run: fakeRun("completed", "success"), jobs: []*github.WorkflowJob{
+					fakeJob("completed", "success",
+						fakeStep(1, "checkout", "completed", "success"),
+						fakeStep(2, "test", "completed", "success")),
+				}},
+			},
+			want: `run #7 (queued) https://github.com/knative/serving/actions/runs/1
+build (in_progress)
+  ✔  checkout (success)
+  ✔  test (success)
+✔  build (success) https://github.com/knative/serving/runs/2
+✔  run #7 (success)
+`,
+		},
+		"failed": {
+			polls: []fakePoll{
+				{run: fakeRun("completed", "failure"), jobs: []*github.WorkflowJob{
+					fakeJob("completed", "failure",
+						fakeStep(1, "checkout", "completed", "success"),
+						fakeStep(2, "test", "completed", "failure")),
+				}},
+			},
+			want: `run #7 (completed) https://github.com/knative/serving/actions/runs/1
+  ✔  checkout (success)
+  ✘  test (failure)
+✘  build (failure) https://github.com/knative/serving/runs/2
+✘  run #7 (failure)
+`,
+			wantErr: true,
+		},
+		"skipped": {
+			polls: []fakePoll{
+				{run: fakeRun("completed", "skipped")},
+			},
+			want: `run #7 (completed) https://github.com/knative/serving/actions/runs/1
+✔  run #7 (skipped)
+`,
+		},
+		"timed out": {
+			polls: []fakePoll{
+				{run: fakeRun("in_progress", ""), jobs: []*github.WorkflowJob{
+					fakeJob("in_progress", ""),
+				}},
+			},
+			timeout: 20 * time.Millisecond,
+			want: `run #7 (in_progress) https://github.com/knative/serving/actions/runs/1
+build (in_progress)
+`,
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			timeout := tt.timeout
+			if timeout == 0 {
+				timeout = 10 * time.Second
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			var out bytes.Buffer
+			gh := &fakeWatchClient{polls: tt.polls}
+			w := &runWatcher{gh: gh, org: "knative", repo: "serving", interval: time.Millisecond, out: &out}
+			err := w.watch(ctx, 1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("watch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+				t.Error("watch() output (-want, +got):", diff)
+			}
+		})
+	}
+}
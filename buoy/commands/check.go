@@ -22,6 +22,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -35,6 +36,9 @@ func addCheckCmd(root *cobra.Command) {
 	var rulesetFlag string
 	var ruleset git.RulesetType
 	var verbose bool
+	var workers int
+	var timeout time.Duration
+	var indirect bool
 
 	var cmd = &cobra.Command{
 		Use:   "check go.mod",
@@ -50,6 +54,16 @@ Rulesets,
   Release          check requires all dependencies to have tagged releases.
   Branch           check requires all dependencies to have a release branch.
   ReleaseOrBranch  check will use rule (Release || Branch).
+  ReleaseOrCandidate
+                   check requires all dependencies to have tagged releases or
+                   release candidates, ex: v0.20.0-rc.1.
+  ReleaseOrCandidateOrBranch
+                   check will use rule (Release || Candidate || Branch).
+
+Release candidates are only selected when no final release exists for the
+release. Only "-rc" pre-releases (ex: -rc.1 or -rc1) are release candidates,
+other pre-releases (ex: -alpha.1) are never selected. Dependencies only at a
+release candidate are reported in the verbose output.
 
 `,
 		Args: cobra.ExactArgs(1),
@@ -64,12 +78,29 @@ Rulesets,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			gomodFile := args[0]
 
+			opts := gomodOptions(gomod.WithWorkers(workers), gomod.WithTimeout(timeout), gomod.WithIndirect(indirect))
+
+			if output != "" {
+				statuses, err := gomod.CheckStatusContext(cmd.Context(), gomodFile, release, domain, ruleset, opts...)
+				if statuses == nil {
+					return err
+				}
+				if err := printOutput(cmd.OutOrStdout(), statuses); err != nil {
+					return err
+				}
+				if errors.Is(err, gomod.DependencyErr) {
+					_, _ = fmt.Fprintln(cmd.ErrOrStderr(), err.Error())
+					os.Exit(1)
+				}
+				return err
+			}
+
 			var out io.Writer
 			if verbose {
 				out = cmd.OutOrStderr()
 			}
 
-			err := gomod.Check(gomodFile, release, domain, ruleset, out)
+			err := gomod.CheckContext(cmd.Context(), gomodFile, release, domain, ruleset, out, opts...)
 			if errors.Is(err, gomod.DependencyErr) {
 				_, _ = fmt.Fprintln(cmd.OutOrStdout(), err.Error())
 				os.Exit(1)
@@ -85,6 +116,10 @@ Rulesets,
 	_ = cmd.MarkFlagRequired("release")
 	cmd.Flags().StringVar(&rulesetFlag, "ruleset", git.ReleaseOrReleaseBranchRule.String(), fmt.Sprintf("The ruleset to evaluate the dependency refs. Rulesets: [%s]", strings.Join(git.Rulesets(), ", ")))
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Print verbose output.")
+	cmd.Flags().BoolVar(&indirect, "include-indirect", false, "Include indirect dependencies.")
+	cmd.Flags().IntVar(&workers, "workers", gomod.DefaultWorkers, "Number of dependencies to resolve concurrently.")
+	cmd.Flags().DurationVar(&timeout, "timeout", 2*time.Minute, "Timeout for resolving a single dependency, 0 for no timeout.")
 
+	supportsOutput(cmd)
 	root.AddCommand(cmd)
 }
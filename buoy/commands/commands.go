@@ -16,7 +16,28 @@ limitations under the License.
 
 package commands
 
-import "github.com/spf13/cobra"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"knative.dev/test-infra/pkg/ghutil"
+	"knative.dev/test-infra/pkg/git"
+	"knative.dev/test-infra/pkg/golang"
+	"knative.dev/test-infra/pkg/gomod"
+)
+
+// resolverOptions holds the gomod options selected by the global flags.
+var resolverOptions []gomod.Option
+
+// gomodOptions returns the gomod options selected by the global flags,
+// followed by opts.
+func gomodOptions(opts ...gomod.Option) []gomod.Option {
+	return append(append([]gomod.Option{}, resolverOptions...), opts...)
+}
 
 // New creates a new buoy cli command set.
 func New() *cobra.Command {
@@ -25,12 +46,107 @@ func New() *cobra.Command {
 		Short: "Introspect go module dependencies.",
 	}
 
+	addResolverFlags(buoyCmd)
+	addOutputFlag(buoyCmd)
+
 	addFloatCmd(buoyCmd)
+	addUpdateCmd(buoyCmd)
 	addNeedsCmd(buoyCmd)
+	addGraphCmd(buoyCmd)
 	addCheckCmd(buoyCmd)
+	addCutCmd(buoyCmd)
+	addDiffCmd(buoyCmd)
+	addSkewCmd(buoyCmd)
+	addReleaseNotesCmd(buoyCmd)
 	addExistsCmd(buoyCmd)
 	addReposCmd(buoyCmd)
 	addActionsCmd(buoyCmd)
 
 	return buoyCmd
 }
+
+// addResolverFlags adds the flags controlling how remote refs are resolved
+// and cached, and sets up git.DefaultCache and resolverOptions from them
+// before any command runs.
+func addResolverFlags(root *cobra.Command) {
+	var (
+		cacheDir string
+		cacheTTL time.Duration
+		noCache  bool
+		offline  bool
+		goproxy  string
+		resolver string
+	)
+
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		resolverOptions = nil
+		if goproxy != "" {
+			if resolver != "" {
+				return errors.New("--goproxy can not be used with --resolver")
+			}
+			proxy, err := golang.NewProxy(goproxy)
+			if err != nil {
+				return err
+			}
+			resolverOptions = append(resolverOptions, gomod.WithRefResolver(proxy))
+		}
+		if resolver != "" {
+			r, err := refResolver(resolver)
+			if err != nil {
+				return err
+			}
+			resolverOptions = append(resolverOptions, gomod.WithRefResolver(r))
+		}
+
+		if noCache {
+			if offline {
+				return errors.New("--offline can not be used with --no-cache")
+			}
+			git.DefaultCache = nil
+			return nil
+		}
+		git.DefaultCache = &git.Cache{
+			Dir:     cacheDir,
+			TTL:     cacheTTL,
+			Offline: offline,
+		}
+		return nil
+	}
+
+	root.PersistentFlags().StringVar(&cacheDir, "cache-dir", git.DefaultCacheDir(), "Directory to cache remote refs in.")
+	root.PersistentFlags().DurationVar(&cacheTTL, "cache-ttl", 10*time.Minute, "How long cached remote refs are used before fetching them again. Use 0 to always fetch them.")
+	root.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Do not read or write the remote ref cache.")
+	root.PersistentFlags().BoolVar(&offline, "offline", false, "Only use cached remote refs, regardless of their age. Never reach the network.")
+	root.PersistentFlags().StringVar(&goproxy, "goproxy", "", "Resolve refs from this module proxy instead of go-import and git (i.e. https://proxy.golang.org). Accepts the GOPROXY list format.")
+	root.PersistentFlags().StringVar(&resolver, "resolver", "", "How modules are resolved to refs: goimport (the default), local:DIR, github[:TOKEN_PATH] or file:PATH. See the README.")
+}
+
+// refResolver returns the golang.RefResolver selected by the --resolver flag,
+// formatted as "kind[:arg]".
+func refResolver(flag string) (golang.RefResolver, error) {
+	kind, arg := flag, ""
+	if i := strings.Index(flag, ":"); i >= 0 {
+		kind, arg = flag[:i], flag[i+1:]
+	}
+	switch kind {
+	case "goimport":
+		return golang.GoImportResolver, nil
+	case "local":
+		if arg == "" {
+			return nil, errors.New("--resolver local requires a directory, ex: local:$HOME/go/src")
+		}
+		return &golang.LocalResolver{Dir: arg}, nil
+	case "github":
+		gh, err := ghutil.NewGithubClient(arg)
+		if err != nil {
+			return nil, err
+		}
+		return golang.NewGitHubResolver(gh), nil
+	case "file":
+		if arg == "" {
+			return nil, errors.New("--resolver file requires a path, ex: file:refs.yaml")
+		}
+		return golang.LoadStaticResolver(arg)
+	}
+	return nil, fmt.Errorf("unknown resolver %q, want one of goimport, local, github or file", kind)
+}
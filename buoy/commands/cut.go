@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/spf13/cobra"
+
+	"knative.dev/test-infra/pkg/gomod"
+)
+
+func addCutCmd(root *cobra.Command) {
+	var (
+		domain    string
+		release   string
+		dryRun    bool
+		tokenPath string
+		workers   int
+		timeout   time.Duration
+	)
+
+	var cmd = &cobra.Command{
+		Use:   "cut go.mod [go.mod...] --release X.Y",
+		Short: "Create the release branch of each module in dependency order.",
+		Long: `
+The cut command creates the release branch, ex: release-0.19, of each given
+module, in the release order of the dependency graph between them. A module is
+cut from the head of its default branch once each of its dependencies, filtered
+by domain, has a release branch, the same way check does with the Branch
+ruleset. Dependencies cut earlier in the same run count as having one.
+
+Modules whose release branch already exists are skipped, so an interrupted cut
+is resumed by running the command again. The remote is checked again right
+before pushing, and an existing release branch is never moved. Modules blocked
+by their dependencies are reported, the other modules are still cut, and the
+command exits with code 1.
+
+Branches are pushed over https, authenticated with the GitHub token from
+--token-path or the GITHUB_TOKEN environment variable, if any. With --dry-run,
+the branches that would be cut are printed and nothing is pushed.
+`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := gomodOptions(gomod.WithWorkers(workers), gomod.WithTimeout(timeout))
+			if !dryRun {
+				token, err := gitToken(tokenPath)
+				if err != nil {
+					return err
+				}
+				if token != "" {
+					opts = append(opts, gomod.WithGitAuth(&http.BasicAuth{Username: "buoy", Password: token}))
+				}
+			}
+
+			cuts, err := gomod.CutReleaseBranches(cmd.Context(), args, release, domain, dryRun, opts...)
+			if output != "" {
+				if err := printOutput(cmd.OutOrStdout(), cuts); err != nil {
+					return err
+				}
+			} else {
+				gomod.WriteCuts(cmd.OutOrStdout(), cuts)
+			}
+
+			if errors.Is(err, gomod.DependencyErr) {
+				_, _ = fmt.Fprintln(cmd.ErrOrStderr(), err.Error())
+				os.Exit(1)
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&domain, "domain", "d", "knative.dev", "domain filter (i.e. knative.dev) [required]")
+	cmd.Flags().StringVarP(&release, "release", "r", "", "release should be '<major>.<minor>' (i.e.: 1.23 or v1.23) [required]")
+	_ = cmd.MarkFlagRequired("release")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the release branches that would be cut without pushing them.")
+	cmd.Flags().StringVarP(&tokenPath, "token-path", "t", "", "GitHub token file path.")
+	cmd.Flags().IntVar(&workers, "workers", gomod.DefaultWorkers, "Number of dependencies to resolve concurrently.")
+	cmd.Flags().DurationVar(&timeout, "timeout", 2*time.Minute, "Timeout for resolving a single dependency, 0 for no timeout.")
+
+	supportsOutput(cmd)
+	root.AddCommand(cmd)
+}
+
+// gitToken returns the GitHub token in the file at tokenPath, or in the
+// GITHUB_TOKEN environment variable if tokenPath is empty.
+func gitToken(tokenPath string) (string, error) {
+	if tokenPath == "" {
+		return strings.TrimSpace(os.Getenv("GITHUB_TOKEN")), nil
+	}
+	b, err := ioutil.ReadFile(tokenPath)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+
+	"knative.dev/test-infra/pkg/git"
+	"knative.dev/test-infra/pkg/gomod"
+)
+
+func addDiffCmd(root *cobra.Command) {
+	var (
+		domain string
+		repo   string
+		path   string
+	)
+
+	var cmd = &cobra.Command{
+		Use:   "diff old new",
+		Short: "Compare the dependencies of two go.mod files or two git refs of a repo.",
+		Long: `
+The diff command compares the direct dependencies, filtered by domain, of two
+go.mod files. With --repo, old and new are git refs of the local repo instead,
+ex: "release-0.19" and "main", and the go.mod file at --path is read at each of
+them.
+
+Each added, removed, upgraded or downgraded dependency is listed, and each
+version is classified as,
+  tag             a tagged release or release candidate, ex: v0.19.1
+  release branch  a pseudo-version of a commit after a release tag,
+                  ex: v0.19.1-0.20201110123000-0123456789ab
+  pseudo-version  a pseudo-version of a commit not after a release tag,
+                  ex: v0.0.0-20201110123000-0123456789ab
+`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			oldName, newName := args[0], args[1]
+
+			read := ioutil.ReadFile
+			if repo != "" {
+				read = func(rev string) ([]byte, error) {
+					return git.ReadFileAt(repo, rev, path)
+				}
+				oldName, newName = fmt.Sprintf("%s@%s", path, oldName), fmt.Sprintf("%s@%s", path, newName)
+			}
+
+			before, err := read(args[0])
+			if err != nil {
+				return err
+			}
+			after, err := read(args[1])
+			if err != nil {
+				return err
+			}
+
+			changes, err := gomod.Diff(oldName, before, newName, after, domain)
+			if err != nil {
+				return err
+			}
+
+			if output != "" {
+				return printOutput(cmd.OutOrStdout(), changes)
+			}
+			gomod.WriteChanges(cmd.OutOrStdout(), changes)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&domain, "domain", "d", "knative.dev", "domain filter (i.e. knative.dev) [required]")
+	cmd.Flags().StringVar(&repo, "repo", "", "Treat old and new as git refs of the local git repo at this path.")
+	cmd.Flags().StringVar(&path, "path", "go.mod", "Path of the go.mod file relative to the root of --repo.")
+
+	supportsOutput(cmd)
+	root.AddCommand(cmd)
+}
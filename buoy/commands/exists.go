@@ -45,12 +45,16 @@ func addExistsCmd(root *cobra.Command) {
 				out = cmd.OutOrStderr()
 			}
 
-			meta, err := gomod.ReleaseStatus(gomodFile, release, out)
+			meta, err := gomod.ReleaseStatus(gomodFile, release, out, gomodOptions()...)
 			if err != nil {
 				return err
 			}
 
-			if tag {
+			if output != "" {
+				if err := printOutput(cmd.OutOrStdout(), meta); err != nil {
+					return err
+				}
+			} else if tag {
 				_, _ = fmt.Fprintln(cmd.OutOrStdout(), meta.Release)
 			}
 
@@ -67,5 +71,6 @@ func addExistsCmd(root *cobra.Command) {
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Print verbose output (stderr)")
 	cmd.Flags().BoolVarP(&tag, "next", "t", false, "Print the next release tag (stdout)")
 
+	supportsOutput(cmd)
 	root.AddCommand(cmd)
 }
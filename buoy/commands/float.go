@@ -19,6 +19,7 @@ package commands
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -32,6 +33,9 @@ func addFloatCmd(root *cobra.Command) {
 		release     string
 		rulesetFlag string
 		ruleset     git.RulesetType
+		workers     int
+		timeout     time.Duration
+		indirect    bool
 	)
 
 	var cmd = &cobra.Command{
@@ -54,6 +58,13 @@ Rulesets,
   Release          tagged releases
   Branch           release branches
   ReleaseOrBranch  tagged releases, release branch
+  ReleaseOrCandidate
+                   tagged releases, tagged release candidates
+  ReleaseOrCandidateOrBranch
+                   tagged releases, tagged release candidates, release branch
+
+Release candidates, ex: "v0.20.0-rc.1", are only selected when no tagged
+release exists, the highest one wins.
 
 For rulesets that that restrict the selection process, no ref is selected.
 `,
@@ -70,17 +81,26 @@ For rulesets that that restrict the selection process, no ref is selected.
 		RunE: func(cmd *cobra.Command, args []string) error {
 			gomodFile := args[0]
 
-			refs, err := gomod.Float(gomodFile, release, domain, ruleset)
-			if err != nil {
+			opts := gomodOptions(gomod.WithWorkers(workers), gomod.WithTimeout(timeout), gomod.WithIndirect(indirect))
+
+			if output != "" {
+				statuses, err := gomod.FloatStatusContext(cmd.Context(), gomodFile, release, domain, ruleset, opts...)
+				if statuses == nil {
+					return err
+				}
+				if err := printOutput(cmd.OutOrStdout(), statuses); err != nil {
+					return err
+				}
 				return err
 			}
 
+			refs, err := gomod.FloatContext(cmd.Context(), gomodFile, release, domain, ruleset, opts...)
 			for _, r := range refs {
 				if r != "" {
 					_, _ = fmt.Fprintln(cmd.OutOrStdout(), r)
 				}
 			}
-			return nil
+			return err
 		},
 	}
 
@@ -88,6 +108,10 @@ For rulesets that that restrict the selection process, no ref is selected.
 	cmd.Flags().StringVarP(&release, "release", "r", "", "release should be '<major>.<minor>' (i.e.: 1.23 or v1.23) [required]")
 	_ = cmd.MarkFlagRequired("release")
 	cmd.Flags().StringVar(&rulesetFlag, "ruleset", git.AnyRule.String(), fmt.Sprintf("The ruleset to evaluate the dependency refs. Rulesets: [%s]", strings.Join(git.Rulesets(), ", ")))
+	cmd.Flags().BoolVar(&indirect, "include-indirect", false, "Include indirect dependencies.")
+	cmd.Flags().IntVar(&workers, "workers", gomod.DefaultWorkers, "Number of dependencies to resolve concurrently.")
+	cmd.Flags().DurationVar(&timeout, "timeout", 2*time.Minute, "Timeout for resolving a single dependency, 0 for no timeout.")
 
+	supportsOutput(cmd)
 	root.AddCommand(cmd)
 }
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"knative.dev/test-infra/pkg/gomod"
+)
+
+const (
+	graphFormatOrder = "order"
+	graphFormatDOT   = "dot"
+	graphFormatJSON  = "json"
+)
+
+func addGraphCmd(root *cobra.Command) {
+	var (
+		domain string
+		format string
+	)
+
+	var cmd = &cobra.Command{
+		Use:   "graph go.mod [go.mod...]",
+		Short: "Render the dependency graph between modules and their release order.",
+		Long: `
+The graph command reads each given go.mod file and builds the directed
+dependency graph between the modules, filtered by domain. Dependencies that are
+not given as go.mod files are included as leaves.
+
+Formats,
+  order  one module per line, in the order releases should be cut. A module
+         is listed only after all of its dependencies.
+  dot    the graph in the Graphviz DOT language.
+  json   the graph, any cycles, and the release order grouped into stages,
+         the same as --output json.
+
+If the graph contains cycles, the order format fails and reports them.
+`,
+		Args: cobra.MinimumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Validation
+			switch format {
+			case graphFormatOrder, graphFormatDOT, graphFormatJSON:
+				return nil
+			}
+			return fmt.Errorf("invalid format %q, please select one of: [%s, %s, %s]", format, graphFormatOrder, graphFormatDOT, graphFormatJSON)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gomods := args
+
+			graph, err := gomod.ModuleGraph(gomods, domain)
+			if err != nil {
+				return err
+			}
+
+			if output != "" {
+				return printOutput(cmd.OutOrStdout(), graph.Report())
+			}
+
+			switch format {
+			case graphFormatDOT:
+				return graph.WriteDOT(cmd.OutOrStdout())
+			case graphFormatJSON:
+				// Same as --output json.
+				return printJSON(cmd.OutOrStdout(), graph.Report())
+			}
+
+			stages, err := graph.ReleaseOrder()
+			if err != nil {
+				return err
+			}
+			for _, stage := range stages {
+				for _, module := range stage {
+					_, _ = fmt.Fprintln(cmd.OutOrStdout(), module)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&domain, "domain", "d", "knative.dev", "domain filter (i.e. knative.dev) [required]")
+	cmd.Flags().StringVarP(&format, "format", "f", graphFormatOrder, fmt.Sprintf("Output format. Formats: [%s, %s, %s]", graphFormatOrder, graphFormatDOT, graphFormatJSON))
+
+	supportsOutput(cmd)
+	root.AddCommand(cmd)
+}
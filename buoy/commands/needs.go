@@ -26,6 +26,7 @@ import (
 
 func addNeedsCmd(root *cobra.Command) {
 	var domain string
+	var indirect bool
 
 	var cmd = &cobra.Command{
 		Use:   "needs go.mod",
@@ -34,11 +35,21 @@ func addNeedsCmd(root *cobra.Command) {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			gomods := args
 
-			_, packages, err := gomod.Modules(gomods, domain)
+			_, packages, err := gomod.Modules(gomods, domain, gomod.WithIndirect(indirect))
 			if err != nil {
 				return err
 			}
 
+			if output != "" {
+				deps := make([]string, 0, len(packages))
+				for _, p := range packages {
+					if p != "" {
+						deps = append(deps, p)
+					}
+				}
+				return printOutput(cmd.OutOrStdout(), deps)
+			}
+
 			for _, p := range packages {
 				if p != "" {
 					_, _ = fmt.Fprintln(cmd.OutOrStdout(), p)
@@ -50,6 +61,8 @@ func addNeedsCmd(root *cobra.Command) {
 
 	cmd.Flags().StringVarP(&domain, "domain", "d", "", "domain filter (i.e. knative.dev) [required]")
 	_ = cmd.MarkFlagRequired("domain")
+	cmd.Flags().BoolVar(&indirect, "include-indirect", false, "Include indirect dependencies.")
 
+	supportsOutput(cmd)
 	root.AddCommand(cmd)
 }
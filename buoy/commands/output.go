@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	outputJSON = "json"
+	outputYAML = "yaml"
+
+	// outputAnnotation marks the commands that support --output.
+	outputAnnotation = "buoy/output"
+)
+
+// output is the structured output format selected by the global flag, empty
+// for human readable output.
+var output string
+
+// supportsOutput marks cmd as supporting --output.
+func supportsOutput(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[outputAnnotation] = "true"
+}
+
+// validateOutput checks the --output flag is valid for cmd.
+func validateOutput(cmd *cobra.Command) error {
+	switch output {
+	case "":
+		return nil
+	case outputJSON, outputYAML:
+		if cmd.Annotations[outputAnnotation] != "true" {
+			return fmt.Errorf("--output is not supported by %q", cmd.CommandPath())
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid output %q, please select one of: [%s, %s]", output, outputJSON, outputYAML)
+}
+
+// printOutput writes v to out in the selected structured output format.
+func printOutput(out io.Writer, v interface{}) error {
+	switch output {
+	case outputYAML:
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(b)
+		return err
+	default:
+		return printJSON(out, v)
+	}
+}
+
+// printJSON writes v to out as indented JSON.
+func printJSON(out io.Writer, v interface{}) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// addOutputFlag adds the --output flag, and validates it for the command
+// being run before any other persistent pre-run of root.
+func addOutputFlag(root *cobra.Command) {
+	preRun := root.PersistentPreRunE
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := validateOutput(cmd); err != nil {
+			return err
+		}
+		if preRun != nil {
+			return preRun(cmd, args)
+		}
+		return nil
+	}
+
+	root.PersistentFlags().StringVarP(&output, "output", "o", "", fmt.Sprintf("Print structured output instead of human readable output. Formats: [%s, %s]", outputJSON, outputYAML))
+}
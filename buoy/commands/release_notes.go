@@ -0,0 +1,133 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"knative.dev/test-infra/pkg/ghutil"
+	"knative.dev/test-infra/pkg/git"
+	"knative.dev/test-infra/pkg/releasenotes"
+)
+
+func addReleaseNotesCmd(root *cobra.Command) {
+	var (
+		release   string
+		from      string
+		to        string
+		repoDir   string
+		moduleDir string
+		tokenPath string
+	)
+
+	var cmd = &cobra.Command{
+		Use:   "release-notes org/repo --release X.Y",
+		Short: "Generate the release notes of a release from the PRs merged since the previous one.",
+		Long: `
+The release-notes command walks the commits of the local clone of org/repo at
+--repo that are reachable from --to but not from --from, like
+"git log from..to", and maps each of them to the PR that merged it.
+
+By default, --to is the release branch and --from is the tag of the previous
+release, picked from the tags of the clone:
+  X.Y.0   the first release of the latest earlier release line, ex: v0.18.0
+          for --release 0.19, or v0.17.0 if 0.18 was skipped.
+  X.Y.Z   the largest earlier release of the same release line, ex: v0.19.0
+          for --release 0.19.1.
+Pre-releases are ignored. With --module-dir, only the tags of the module in
+that directory of the repo are considered, ex: schema/v0.1.0 for schema. Refs
+not found locally are looked up as branches of the origin remote, so the clone
+has to include the tags and branches of the range.
+
+The content of the release-note code block in the description of each PR
+is grouped by the kind/* label of the PR into API Changes, Features, Bug Fixes,
+Cleanups, Documentation and Other Changes, and rendered as markdown. PRs with a
+release note of NONE and commits without a PR are counted on stderr. If the PR
+of some commits can't be looked up, the notes of the other commits are still
+printed, and the command exits with code 1.
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			org, repo, err := splitOrgRepo(args[0])
+			if err != nil {
+				return err
+			}
+			if release != "" {
+				local, err := git.LocalRepo(args[0], repoDir)
+				if err != nil {
+					return err
+				}
+				local.Dir = moduleDir
+				defaultFrom, defaultTo, err := releasenotes.ReleaseRange(local, release)
+				if err != nil {
+					return err
+				}
+				if from == "" {
+					if defaultFrom == "" {
+						return fmt.Errorf("unable to find the release before %s, set --from", release)
+					}
+					from = defaultFrom
+				}
+				if to == "" {
+					to = defaultTo
+				}
+			}
+			if from == "" || to == "" {
+				return errors.New("--release or both --from and --to are required")
+			}
+
+			commits, err := git.CommitsBetween(repoDir, from, to)
+			if err != nil {
+				return err
+			}
+			gh, err := ghutil.NewGithubClient(tokenPath)
+			if err != nil {
+				return err
+			}
+			// The notes of the other commits are printed even if some
+			// lookups failed.
+			notes, collectErr := releasenotes.Collect(gh, org, repo, commits)
+			notes.From, notes.To = from, to
+
+			if output != "" {
+				if err := printOutput(cmd.OutOrStdout(), notes); err != nil {
+					return err
+				}
+				return collectErr
+			}
+			if err := notes.WriteMarkdown(cmd.OutOrStdout()); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s..%s: %d release notes, %d PRs without a release note, %d commits without a PR, %d failed lookups\n",
+				from, to, len(notes.Notes), len(notes.WithoutNote), len(notes.WithoutPR), len(notes.Failed))
+			return collectErr
+		},
+	}
+
+	cmd.Flags().StringVarP(&release, "release", "r", "", "release should be '<major>.<minor>' or '<major>.<minor>.<patch>' (i.e.: 1.23 or v1.23.1)")
+	cmd.Flags().StringVar(&from, "from", "", "Exclusive start of the range, defaults to the tag of the previous release.")
+	cmd.Flags().StringVar(&to, "to", "", "Inclusive end of the range, defaults to the release branch.")
+	cmd.Flags().StringVar(&repoDir, "repo", ".", "Path of the local git repo of org/repo.")
+	cmd.Flags().StringVar(&moduleDir, "module-dir", "", "Directory of the module within the repo, whose release tags are prefixed with it.")
+	cmd.Flags().StringVarP(&tokenPath, "token-path", "t", "", "GitHub token file path.")
+
+	supportsOutput(cmd)
+	root.AddCommand(cmd)
+}
@@ -18,42 +18,94 @@ package commands
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/spf13/cobra"
+
 	"knative.dev/test-infra/pkg/ghutil"
+	"knative.dev/test-infra/pkg/inventory"
 )
 
 func addReposCmd(root *cobra.Command) {
-
-	var tokenPath string
+	var (
+		tokenPath     string
+		archived      string
+		defaultBranch string
+		topics        []string
+		hasGoMod      string
+		modulePrefix  string
+	)
 
 	var cmd = &cobra.Command{
 		Use:   "repos org1 [org2 org3...]",
 		Short: "List the repos for a list of GitHub organizations.",
-		Args:  cobra.MinimumNArgs(1),
+		Long: `
+The repos command lists the repos of the given GitHub organizations as
+org/repo, selected by the filter flags.
+
+Filtering by --has-gomod or --module reads the go.mod file at the root of the
+default branch of each repo matching the other filters. With --output, the
+go.mod files are always read, and each repo is printed with its attributes:
+whether it is archived, its default branch, its topics, whether it has a
+go.mod file and the module it declares.
+`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			orgs := args
+
+			filter := inventory.Filter{
+				DefaultBranch: defaultBranch,
+				Topics:        topics,
+				ModulePrefix:  modulePrefix,
+			}
+			var err error
+			if filter.Archived, err = parseOptionalBool("archived", archived); err != nil {
+				return err
+			}
+			if filter.GoMod, err = parseOptionalBool("has-gomod", hasGoMod); err != nil {
+				return err
+			}
+
 			gh, err := ghutil.NewGithubClient(tokenPath)
 			if err != nil {
 				return err
 			}
 
-			// for all given orgs, list the repos.
-			for _, org := range orgs {
-				repos, err := gh.ListRepos(org)
-				if err != nil {
-					return err
-				}
-				for _, repo := range repos {
-					_, _ = fmt.Fprintln(cmd.OutOrStdout(), org+"/"+repo)
-				}
+			repos, err := inventory.List(gh, orgs, inventory.Options{Filter: filter, ReadGoMod: output != ""})
+			if err != nil {
+				return err
 			}
 
+			if output != "" {
+				return printOutput(cmd.OutOrStdout(), repos)
+			}
+			for _, repo := range repos {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), repo)
+			}
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVarP(&tokenPath, "token-path", "t", "", "GitHub token file path.")
+	cmd.Flags().StringVar(&archived, "archived", "", "Only list archived repos if true, or repos not archived if false.")
+	cmd.Flags().StringVar(&defaultBranch, "default-branch", "", "Only list repos with this default branch (i.e. main).")
+	cmd.Flags().StringArrayVar(&topics, "topic", nil, "Only list repos with this topic. Can be repeated, repos must have all of them.")
+	cmd.Flags().StringVar(&hasGoMod, "has-gomod", "", "Only list repos with a go.mod file at their root if true, or without if false.")
+	cmd.Flags().StringVar(&modulePrefix, "module", "", "Only list repos declaring a go module with this prefix (i.e. knative.dev/).")
 
+	supportsOutput(cmd)
 	root.AddCommand(cmd)
 }
+
+// parseOptionalBool parses the value of a boolean filter flag, returning nil
+// if it is not set.
+func parseOptionalBool(flag, value string) (*bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s %q, expected true or false", flag, value)
+	}
+	return &b, nil
+}
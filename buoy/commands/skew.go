@@ -0,0 +1,101 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"knative.dev/test-infra/pkg/gomod"
+)
+
+func addSkewCmd(root *cobra.Command) {
+	var (
+		domain   string
+		release  string
+		maxSkew  int
+		workers  int
+		timeout  time.Duration
+		indirect bool
+	)
+
+	var cmd = &cobra.Command{
+		Use:   "skew go.mod [go.mod...]",
+		Short: "Check that the dependencies of modules are on the release line of a given release.",
+		Long: `
+The skew command checks that the required version of each dependency, filtered
+by domain, of the given modules is on the release line of the release, or at
+most --max-skew minor versions behind it. The release line of a version is,
+  release, release candidate   its major and minor version, ex: v0.19.1 is on
+                               0.19.
+  release branch               the major and minor version of the release it
+                               follows, ex: v0.19.1-0.20201110123000-0123...
+                               is on 0.19.
+  default branch               the release line after the latest release of
+                               the dependency, ex: 0.20 if release-0.19 is the
+                               latest release branch.
+
+Dependencies replaced by a fork are checked using the version of the fork, and
+dependencies replaced by a local directory are ignored.
+
+If each dependency is on an allowed release line, the command will exit with
+code 0, otherwise the offending refs are listed, with the release or release
+branch to use instead if there is one, and the command exits with code 1.
+`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if maxSkew < 0 {
+				return errors.New("--max-skew can not be negative")
+			}
+
+			opts := gomodOptions(gomod.WithWorkers(workers), gomod.WithTimeout(timeout), gomod.WithIndirect(indirect))
+			skews, err := gomod.SkewContext(cmd.Context(), args, release, domain, maxSkew, opts...)
+			if skews == nil {
+				return err
+			}
+
+			if output != "" {
+				if err := printOutput(cmd.OutOrStdout(), skews); err != nil {
+					return err
+				}
+			} else {
+				gomod.WriteSkews(cmd.OutOrStdout(), skews)
+			}
+
+			if errors.Is(err, gomod.DependencyErr) {
+				_, _ = fmt.Fprintln(cmd.ErrOrStderr(), err.Error())
+				os.Exit(1)
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&domain, "domain", "d", "knative.dev", "domain filter (i.e. knative.dev) [required]")
+	cmd.Flags().StringVarP(&release, "release", "r", "", "release should be '<major>.<minor>' (i.e.: 1.23 or v1.23) [required]")
+	_ = cmd.MarkFlagRequired("release")
+	cmd.Flags().IntVar(&maxSkew, "max-skew", 0, "Number of minor versions dependencies are allowed to be behind the release.")
+	cmd.Flags().BoolVar(&indirect, "include-indirect", false, "Include indirect dependencies.")
+	cmd.Flags().IntVar(&workers, "workers", gomod.DefaultWorkers, "Number of dependencies to resolve concurrently.")
+	cmd.Flags().DurationVar(&timeout, "timeout", 2*time.Minute, "Timeout for resolving a single dependency, 0 for no timeout.")
+
+	supportsOutput(cmd)
+	root.AddCommand(cmd)
+}
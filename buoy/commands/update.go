@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"knative.dev/test-infra/pkg/git"
+	"knative.dev/test-infra/pkg/gomod"
+	"knative.dev/test-infra/pkg/helpers"
+)
+
+func addUpdateCmd(root *cobra.Command) {
+	var (
+		domain      string
+		release     string
+		rulesetFlag string
+		ruleset     git.RulesetType
+		dryrun      bool
+		workers     int
+		timeout     time.Duration
+		indirect    bool
+	)
+
+	var cmd = &cobra.Command{
+		Use:   "update go.mod",
+		Short: "Rewrite go.mod to require the latest versions of dependencies based on a release.",
+		Long: `
+The update command selects a ref for each dependency the same way float does,
+and rewrites the require block of the go.mod file with them. Release tags are
+required as is. Branches are required as the pseudo-version of the commit at
+the head of the branch, based on the latest tag reachable from it the way the
+go command does, ex: "v0.19.2-0.20200922164940-4bf40ad82aab" after v0.19.1,
+or "v0.0.0-20200922164940-4bf40ad82aab" without a tag.
+
+Dependencies without a ref for the selected ruleset are left unchanged.
+
+Rulesets,
+  Any              tagged releases, release branches, default branch
+  Release          tagged releases
+  Branch           release branches
+  ReleaseOrBranch  tagged releases, release branch
+  ReleaseOrCandidate
+                   tagged releases, tagged release candidates
+  ReleaseOrCandidateOrBranch
+                   tagged releases, tagged release candidates, release branch
+
+Release candidates, ex: "v0.20.0-rc.1", are only selected when no tagged
+release exists, the highest one wins.
+
+With --dry-run, the go.mod file is not written, and a unified diff of the
+changes is printed instead.
+`,
+		Args: cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Validation
+			ruleset = git.Ruleset(rulesetFlag)
+			if ruleset == git.InvalidRule {
+				return fmt.Errorf("invalid ruleset, please select one of: [%s]", strings.Join(git.Rulesets(), ", "))
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gomodFile := args[0]
+
+			before, err := ioutil.ReadFile(gomodFile)
+			if err != nil {
+				return err
+			}
+
+			after, err := gomod.UpdateContext(cmd.Context(), gomodFile, release, domain, ruleset,
+				gomodOptions(gomod.WithWorkers(workers), gomod.WithTimeout(timeout), gomod.WithIndirect(indirect))...)
+			if err != nil {
+				return err
+			}
+
+			if dryrun {
+				_, _ = fmt.Fprint(cmd.OutOrStdout(), helpers.UnifiedDiff(gomodFile, gomodFile, string(before), string(after)))
+				return nil
+			}
+
+			info, err := os.Stat(gomodFile)
+			if err != nil {
+				return err
+			}
+			return ioutil.WriteFile(gomodFile, after, info.Mode())
+		},
+	}
+
+	cmd.Flags().StringVarP(&domain, "domain", "d", "knative.dev", "domain filter (i.e. knative.dev) [required]")
+	cmd.Flags().StringVarP(&release, "release", "r", "", "release should be '<major>.<minor>' (i.e.: 1.23 or v1.23) [required]")
+	_ = cmd.MarkFlagRequired("release")
+	cmd.Flags().StringVar(&rulesetFlag, "ruleset", git.AnyRule.String(), fmt.Sprintf("The ruleset to evaluate the dependency refs. Rulesets: [%s]", strings.Join(git.Rulesets(), ", ")))
+	cmd.Flags().BoolVar(&dryrun, "dry-run", false, "Print a diff of the changes instead of writing go.mod.")
+	cmd.Flags().BoolVar(&indirect, "include-indirect", false, "Include indirect dependencies.")
+	cmd.Flags().IntVar(&workers, "workers", gomod.DefaultWorkers, "Number of dependencies to resolve concurrently.")
+	cmd.Flags().DurationVar(&timeout, "timeout", 2*time.Minute, "Timeout for resolving a single dependency, 0 for no timeout.")
+
+	root.AddCommand(cmd)
+}
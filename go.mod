@@ -17,6 +17,7 @@ require (
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51
 	github.com/kelseyhightower/envconfig v1.4.0
 	github.com/pkg/errors v0.9.1
+	github.com/sergi/go-diff v1.1.0
 	github.com/spf13/cobra v1.0.0
 	go.uber.org/atomic v1.6.0
 	golang.org/x/mod v0.3.0
@@ -34,4 +34,5 @@ go run "${CONFIG_GENERATOR_DIR}" \
     --prow-jobs-config-output="${CONFIG_DIR}/prod/prow/jobs/config.yaml" \
     --testgrid-config-output="${CONFIG_DIR}/prod/prow/testgrid/testgrid.yaml" \
     --k8s-testgrid-config-output="${CONFIG_DIR}/prod/prow/k8s-testgrid/k8s-testgrid.yaml" \
+    --inventory="${CONFIG_DIR}/prod/prow/inventory.yaml" \
     "${CONFIG_DIR}/prod/prow/config_knative.yaml"
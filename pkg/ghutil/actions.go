@@ -19,6 +19,9 @@ limitations under the License.
 package ghutil
 
 import (
+	"fmt"
+	"net/url"
+
 	"github.com/google/go-github/v32/github"
 )
 
@@ -46,3 +49,101 @@ func (gc *GithubClient) ListWorkflows(org, repo string) ([]*github.Workflow, err
 	}
 	return res, err
 }
+
+// ListWorkflowRuns lists the most recent runs of a workflow, newest first.
+// Runs can be filtered by branch and by the event that triggered them, ex:
+// "workflow_dispatch"; empty values do not filter. Only the first page of
+// runs is returned, as the full history of a workflow can be long.
+func (gc *GithubClient) ListWorkflowRuns(org, repo string, workflowID int64, branch, event string) ([]*github.WorkflowRun, error) {
+	var res []*github.WorkflowRun
+	_, err := gc.retry(
+		fmt.Sprintf("listing runs of workflow %d in %s/%s", workflowID, org, repo),
+		maxRetryCount,
+		func() (*github.Response, error) {
+			runs, resp, err := gc.Client.Actions.ListWorkflowRunsByID(ctx, org, repo, workflowID, &github.ListWorkflowRunsOptions{
+				Branch:      branch,
+				Event:       event,
+				ListOptions: github.ListOptions{PerPage: 100},
+			})
+			if err == nil {
+				res = runs.WorkflowRuns
+			}
+			return resp, err
+		},
+	)
+	return res, err
+}
+
+// GetWorkflowRun gets a workflow run by its ID.
+func (gc *GithubClient) GetWorkflowRun(org, repo string, runID int64) (*github.WorkflowRun, error) {
+	var res *github.WorkflowRun
+	_, err := gc.retry(
+		fmt.Sprintf("getting workflow run %d in %s/%s", runID, org, repo),
+		maxRetryCount,
+		func() (*github.Response, error) {
+			var resp *github.Response
+			var err error
+			res, resp, err = gc.Client.Actions.GetWorkflowRunByID(ctx, org, repo, runID)
+			return resp, err
+		},
+	)
+	return res, err
+}
+
+// ListWorkflowJobs lists the jobs, with their steps, of the latest attempt of
+// a workflow run.
+func (gc *GithubClient) ListWorkflowJobs(org, repo string, runID int64) ([]*github.WorkflowJob, error) {
+	options := &github.ListWorkflowJobsOptions{Filter: "latest"}
+	genericList, err := gc.depaginate(
+		fmt.Sprintf("listing jobs of workflow run %d in %s/%s", runID, org, repo),
+		maxRetryCount,
+		&options.ListOptions,
+		func() ([]interface{}, *github.Response, error) {
+			jobs, resp, err := gc.Client.Actions.ListWorkflowJobs(ctx, org, repo, runID, options)
+			var interfaceList []interface{}
+			if nil == err {
+				for _, job := range jobs.Jobs {
+					interfaceList = append(interfaceList, job)
+				}
+			}
+			return interfaceList, resp, err
+		},
+	)
+	res := make([]*github.WorkflowJob, len(genericList))
+	for i, elem := range genericList {
+		res[i] = elem.(*github.WorkflowJob)
+	}
+	return res, err
+}
+
+// GetWorkflowRunLogsURL gets the URL to download the logs archive of a
+// workflow run from. The URL expires after a minute.
+func (gc *GithubClient) GetWorkflowRunLogsURL(org, repo string, runID int64) (*url.URL, error) {
+	var res *url.URL
+	_, err := gc.retry(
+		fmt.Sprintf("getting logs of workflow run %d in %s/%s", runID, org, repo),
+		maxRetryCount,
+		func() (*github.Response, error) {
+			var resp *github.Response
+			var err error
+			res, resp, err = gc.Client.Actions.GetWorkflowRunLogs(ctx, org, repo, runID, true)
+			return resp, err
+		},
+	)
+	return res, err
+}
+
+// DispatchWorkflow creates a workflow_dispatch event to run a workflow on ref.
+func (gc *GithubClient) DispatchWorkflow(org, repo string, workflowID int64, ref string, inputs map[string]interface{}) error {
+	_, err := gc.retry(
+		fmt.Sprintf("dispatching workflow %d in %s/%s", workflowID, org, repo),
+		maxRetryCount,
+		func() (*github.Response, error) {
+			return gc.Client.Actions.CreateWorkflowDispatchEvent(ctx, org, repo, workflowID, github.CreateWorkflowDispatchEventRequest{
+				Ref:    ref,
+				Inputs: inputs,
+			})
+		},
+	)
+	return err
+}
@@ -284,6 +284,21 @@ func (fgc *FakeGithubClient) GetPullRequestByCommitID(org, repo string, commitID
 	return res[0], nil
 }
 
+// ListPullRequestsByCommitID lists the PullRequests containing a commit
+func (fgc *FakeGithubClient) ListPullRequestsByCommitID(org, repo, commitID string) ([]*github.PullRequest, error) {
+	res := make([]*github.PullRequest, 0)
+	for prNum, commits := range fgc.PRCommits {
+		for _, commit := range commits {
+			if commit.GetSHA() == commitID {
+				if pullRequest, err := fgc.GetPullRequest(org, repo, prNum); err == nil {
+					res = append(res, pullRequest)
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
 // EditPullRequest updates PullRequest
 func (fgc *FakeGithubClient) EditPullRequest(org, repo string, ID int, title, body string) (*github.PullRequest, error) {
 	PR, err := fgc.GetPullRequest(org, repo, ID)
@@ -135,9 +135,22 @@ func (gc *GithubClient) GetPullRequest(org, repo string, ID int) (*github.PullRe
 
 // GetPullRequestByCommitID gets PullRequest by commit ID
 func (gc *GithubClient) GetPullRequestByCommitID(org, repo, commitID string) (*github.PullRequest, error) {
+	res, err := gc.ListPullRequestsByCommitID(org, repo, commitID)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) != 1 {
+		return nil, fmt.Errorf("GetPullRequestByCommitID is expected to return 1 PullRequest, got %d", len(res))
+	}
+	return res[0], nil
+}
+
+// ListPullRequestsByCommitID lists the PullRequests containing a commit. A
+// commit without a PullRequest is not an error.
+func (gc *GithubClient) ListPullRequestsByCommitID(org, repo, commitID string) ([]*github.PullRequest, error) {
 	var res []*github.PullRequest
 	if _, err := gc.retry(
-		fmt.Sprintf("Get PullRequest by commit ID '%s'", commitID),
+		fmt.Sprintf("List PullRequests by commit ID '%s'", commitID),
 		maxRetryCount,
 		func() (*github.Response, error) {
 			var resp *github.Response
@@ -154,11 +167,7 @@ func (gc *GithubClient) GetPullRequestByCommitID(org, repo, commitID string) (*g
 	); err != nil {
 		return nil, err
 	}
-
-	if len(res) != 1 {
-		return nil, fmt.Errorf("GetPullRequestByCommitID is expected to return 1 PullRequest, got %d", len(res))
-	}
-	return res[0], nil
+	return res, nil
 }
 
 // EditPullRequest updates PullRequest
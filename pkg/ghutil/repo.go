@@ -19,13 +19,29 @@ limitations under the License.
 package ghutil
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/google/go-github/v32/github"
 )
 
+// ErrNotFound is returned when the requested file or repo does not exist.
+var ErrNotFound = errors.New("not found")
+
 // ListRepos lists repos under org
 func (gc *GithubClient) ListRepos(org string) ([]string, error) {
+	repos, err := gc.ListRepositories(org)
+	res := make([]string, len(repos))
+	for i, repo := range repos {
+		res[i] = repo.GetName()
+	}
+	return res, err
+}
+
+// ListRepositories lists repos under org, with their attributes such as
+// whether they are archived, their default branch and their topics.
+func (gc *GithubClient) ListRepositories(org string) ([]*github.Repository, error) {
 	repoListOptions := &github.RepositoryListOptions{}
 	genericList, err := gc.depaginate(
 		"listing repos",
@@ -42,21 +58,52 @@ func (gc *GithubClient) ListRepos(org string) ([]string, error) {
 			return interfaceList, resp, err
 		},
 	)
-	res := make([]string, len(genericList))
+	res := make([]*github.Repository, len(genericList))
 	for i, elem := range genericList {
-		res[i] = elem.(*github.Repository).GetName()
+		res[i] = elem.(*github.Repository)
 	}
 	return res, err
 }
 
+// GetFileContent gets the content of the file at path in the given repo, at
+// ref. An empty ref is the default branch of the repo. If the file does not
+// exist, ErrNotFound is returned.
+func (gc *GithubClient) GetFileContent(org, repo, ref, path string) ([]byte, error) {
+	var content string
+	_, err := gc.retry(
+		fmt.Sprintf("getting %s of %s/%s at %q", path, org, repo, ref),
+		maxRetryCount,
+		func() (*github.Response, error) {
+			file, _, resp, err := gc.Client.Repositories.GetContents(ctx, org, repo, path, &github.RepositoryContentGetOptions{Ref: ref})
+			if err != nil {
+				return resp, err
+			}
+			if file == nil {
+				return resp, fmt.Errorf("%s of %s/%s is a directory", path, org, repo)
+			}
+			content, err = file.GetContent()
+			return resp, err
+		},
+	)
+	if err != nil {
+		var errResp *github.ErrorResponse
+		if errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return []byte(content), nil
+}
+
 // ListBranches lists branchs for given repo
 func (gc *GithubClient) ListBranches(org, repo string) ([]*github.Branch, error) {
+	branchListOptions := &github.BranchListOptions{}
 	genericList, err := gc.depaginate(
-		fmt.Sprintf("listing Pull request from org %q and base %q", org, repo),
+		fmt.Sprintf("listing branches of %s/%s", org, repo),
 		maxRetryCount,
-		&github.ListOptions{},
+		&branchListOptions.ListOptions,
 		func() ([]interface{}, *github.Response, error) {
-			page, resp, err := gc.Client.Repositories.ListBranches(ctx, org, repo, nil)
+			page, resp, err := gc.Client.Repositories.ListBranches(ctx, org, repo, branchListOptions)
 			var interfaceList []interface{}
 			if nil == err {
 				for _, PR := range page {
@@ -72,3 +119,52 @@ func (gc *GithubClient) ListBranches(org, repo string) ([]*github.Branch, error)
 	}
 	return res, err
 }
+
+// ListTags lists the tags of the given repo.
+func (gc *GithubClient) ListTags(org, repo string) ([]*github.RepositoryTag, error) {
+	listOptions := &github.ListOptions{}
+	genericList, err := gc.depaginate(
+		fmt.Sprintf("listing tags of %s/%s", org, repo),
+		maxRetryCount,
+		listOptions,
+		func() ([]interface{}, *github.Response, error) {
+			page, resp, err := gc.Client.Repositories.ListTags(ctx, org, repo, listOptions)
+			var interfaceList []interface{}
+			if nil == err {
+				for _, tag := range page {
+					interfaceList = append(interfaceList, tag)
+				}
+			}
+			return interfaceList, resp, err
+		},
+	)
+	res := make([]*github.RepositoryTag, len(genericList))
+	for i, elem := range genericList {
+		res[i] = elem.(*github.RepositoryTag)
+	}
+	return res, err
+}
+
+// GetRepository gets the given repo, with its attributes such as its default
+// branch. If the repo does not exist, ErrNotFound is returned.
+func (gc *GithubClient) GetRepository(org, repo string) (*github.Repository, error) {
+	var res *github.Repository
+	_, err := gc.retry(
+		fmt.Sprintf("getting repo %s/%s", org, repo),
+		maxRetryCount,
+		func() (*github.Response, error) {
+			var resp *github.Response
+			var err error
+			res, resp, err = gc.Client.Repositories.Get(ctx, org, repo)
+			return resp, err
+		},
+	)
+	if err != nil {
+		var errResp *github.ErrorResponse
+		if errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// ErrBranchExists is returned by CreateBranch when the branch is already on the
+// remote.
+var ErrBranchExists = errors.New("branch already exists")
+
+// CreateBranch creates branch on the remote at url, pointing to the head
+// commit of the from branch, and returns that commit. Only the head commit of
+// from is fetched. auth may be nil for remotes that do not need it.
+//
+// The remote refs are listed right before pushing, bypassing DefaultCache, and
+// an existing branch is never updated: ErrBranchExists is returned instead.
+func CreateBranch(url, from, branch string, auth transport.AuthMethod) (*Commit, error) {
+	fromRef := plumbing.NewBranchReferenceName(from)
+	r, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
+		URL:           url,
+		Auth:          auth,
+		ReferenceName: fromRef,
+		SingleBranch:  true,
+		Depth:         1,
+		Tags:          git.NoTags,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch %s from %s: %w", from, url, err)
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+	c, err := r.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	branchRef := plumbing.NewBranchReferenceName(branch)
+	remote, err := r.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := remote.List(&git.ListOptions{Auth: auth})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the refs of %s: %w", url, err)
+	}
+	for _, ref := range refs {
+		if ref.Name() == branchRef {
+			return nil, fmt.Errorf("unable to push %s to %s: %w", branch, url, ErrBranchExists)
+		}
+	}
+
+	spec := config.RefSpec(fmt.Sprintf("%s:%s", fromRef, branchRef))
+	if err := r.Push(&git.PushOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []config.RefSpec{spec},
+		Auth:       auth,
+	}); err != nil {
+		return nil, fmt.Errorf("unable to push %s to %s: %w", branch, url, err)
+	}
+
+	return &Commit{
+		Hash: c.Hash.String(),
+		Time: c.Committer.When,
+	}, nil
+}
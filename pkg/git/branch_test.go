@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestCreateBranch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "createbranch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A bare remote, populated from a work repo.
+	remote := filepath.Join(dir, "remote.git")
+	if _, err := git.PlainInit(remote, true); err != nil {
+		t.Fatal(err)
+	}
+	work := filepath.Join(dir, "work")
+	r, err := git.PlainInit(work, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, r, work, "go.mod", "module example.com/old\n")
+	head := commitFile(t, r, work, "go.mod", "module example.com/new\n")
+	if _, err := r.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{remote}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Push(&git.PushOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := CreateBranch(remote, "master", "release-0.19", nil)
+	if err != nil {
+		t.Fatal("CreateBranch() =", err)
+	}
+	if got.Hash != head.String() {
+		t.Errorf("CreateBranch() commit = %s, want %s", got.Hash, head)
+	}
+
+	rr, err := git.PlainOpen(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := rr.Reference(plumbing.NewBranchReferenceName("release-0.19"), false)
+	if err != nil {
+		t.Fatal("release branch not found on the remote:", err)
+	}
+	if ref.Hash() != head {
+		t.Errorf("release branch = %s, want %s", ref.Hash(), head)
+	}
+
+	// An existing branch is never updated, even when it is behind.
+	stale := commitFile(t, r, work, "go.mod", "module example.com/newer\n")
+	if err := r.Push(&git.PushOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateBranch(remote, "master", "release-0.19", nil); !errors.Is(err, ErrBranchExists) {
+		t.Errorf("CreateBranch() again = %v, want %v", err, ErrBranchExists)
+	}
+	if ref, err := rr.Reference(plumbing.NewBranchReferenceName("release-0.19"), false); err != nil {
+		t.Fatal(err)
+	} else if ref.Hash() == stale {
+		t.Error("CreateBranch() again fast-forwarded the existing branch")
+	}
+
+	if _, err := CreateBranch(remote, "main", "release-0.19", nil); err == nil {
+		t.Error("CreateBranch() from an unknown branch succeeded")
+	}
+}
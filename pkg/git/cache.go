@@ -0,0 +1,161 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultCache is used by GetRepo and other remote lookups when set. A nil
+// DefaultCache disables caching.
+var DefaultCache *Cache
+
+// ErrOffline is returned when a lookup is not in the cache and the cache is
+// not allowed to reach the network.
+var ErrOffline = errors.New("not found in cache while offline")
+
+// Cache persists the results of remote lookups on disk, so repeated lookups
+// for the same remote do not go back to the network.
+type Cache struct {
+	// Dir is the directory the cache entries are stored in.
+	Dir string
+	// TTL is how long an entry is used before it is fetched again. Entries are
+	// always written, so a zero TTL still populates the cache for Offline use.
+	TTL time.Duration
+	// Offline only answers from the cache, regardless of the age of the entry.
+	// Lookups that are not cached fail with ErrOffline.
+	Offline bool
+
+	// now is used to override time.Now in tests.
+	now func() time.Time
+}
+
+type cacheEntry struct {
+	Key     string          `json:"key"`
+	Fetched time.Time       `json:"fetched"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// DefaultCacheDir returns the default cache directory, inside the user cache
+// directory.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "knative-buoy")
+}
+
+func (c *Cache) time() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get loads the cached value for key into v. Get returns false if there is no
+// usable entry for key, either because it is missing, expired or unreadable.
+// Expired entries are still used when the cache is Offline.
+func (c *Cache) Get(key string, v interface{}) bool {
+	b, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return false
+	}
+
+	entry := new(cacheEntry)
+	if err := json.Unmarshal(b, entry); err != nil || entry.Key != key {
+		return false
+	}
+	if !c.Offline && c.time().Sub(entry.Fetched) > c.TTL {
+		return false
+	}
+	return json.Unmarshal(entry.Value, v) == nil
+}
+
+// Put stores v as the cached value for key.
+func (c *Cache) Put(key string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(&cacheEntry{
+		Key:     key,
+		Fetched: c.time(),
+		Value:   value,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+	// Write to a temp file and rename, so readers never see a partial entry.
+	tmp, err := ioutil.TempFile(c.Dir, "entry-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// GetRepo returns the cached Repo for url, and otherwise fetches the Repo and
+// stores it in the cache. Failing to store it is logged, not returned.
+func (c *Cache) GetRepo(ref, url string) (*Repo, error) {
+	key := "ls-remote " + url
+
+	repo := new(Repo)
+	if c.Get(key, repo) {
+		repo.Ref = ref
+		repo.URL = url
+		return repo, nil
+	}
+	if c.Offline {
+		return nil, fmt.Errorf("%s: %w", url, ErrOffline)
+	}
+
+	repo, err := getRepo(ref, url)
+	if err != nil {
+		return nil, err
+	}
+	// Caching is best effort, the cache dir may not be writable.
+	if err := c.Put(key, repo); err != nil {
+		log.Printf("unable to cache %s: %v", url, err)
+	}
+	return repo, nil
+}
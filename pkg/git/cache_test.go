@@ -0,0 +1,168 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	fixtures "github.com/go-git/go-git-fixtures/v4"
+	"github.com/google/go-cmp/cmp"
+)
+
+func tempCache(t *testing.T, ttl time.Duration) *Cache {
+	dir, err := ioutil.TempDir("", "git-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return &Cache{Dir: dir, TTL: ttl}
+}
+
+func TestCache_GetPut(t *testing.T) {
+	now := time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	cache := tempCache(t, time.Hour)
+	cache.now = func() time.Time { return now }
+
+	want := &Repo{DefaultBranch: "main", Tags: []string{"v0.1.0"}}
+	if err := cache.Put("key", want); err != nil {
+		t.Fatal("failed to Put: ", err)
+	}
+
+	tests := map[string]struct {
+		key     string
+		age     time.Duration
+		offline bool
+		found   bool
+	}{
+		"fresh": {
+			key:   "key",
+			age:   time.Minute,
+			found: true,
+		},
+		"expired": {
+			key: "key",
+			age: 2 * time.Hour,
+		},
+		"expired, offline": {
+			key:     "key",
+			age:     2 * time.Hour,
+			offline: true,
+			found:   true,
+		},
+		"missing": {
+			key: "other",
+		},
+		"missing, offline": {
+			key:     "other",
+			offline: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := *cache
+			c.Offline = tt.offline
+			c.now = func() time.Time { return now.Add(tt.age) }
+
+			got := new(Repo)
+			if found := c.Get(tt.key, got); found != tt.found {
+				t.Fatalf("Get() = %t, want %t", found, tt.found)
+			}
+			if !tt.found {
+				return
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Error("Get() diff(-want,+got):\n", diff)
+			}
+		})
+	}
+}
+
+func TestCache_GetRepo(t *testing.T) {
+	f := fixtures.Basic().One()
+	repoURL := f.DotGit().Root()
+
+	cache := tempCache(t, time.Hour)
+	want, err := cache.GetRepo("foo", repoURL)
+	if err != nil {
+		t.Fatal("failed to GetRepo: ", err)
+	}
+
+	// Served from the cache, even with a different ref.
+	cache.Offline = true
+	got, err := cache.GetRepo("bar", repoURL)
+	if err != nil {
+		t.Fatal("failed to GetRepo offline: ", err)
+	}
+	if want := "bar"; got.Ref != want {
+		t.Errorf("expected ref to be %q, got %q", want, got.Ref)
+	}
+	got.Ref = want.Ref
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("GetRepo() diff(-want,+got):\n", diff)
+	}
+
+	if _, err := cache.GetRepo("foo", "invalid"); !errors.Is(err, ErrOffline) {
+		t.Error("expected GetRepo to return ErrOffline, got ", err)
+	}
+}
+
+func TestCache_GetRepoNotWritable(t *testing.T) {
+	f := fixtures.Basic().One()
+	repoURL := f.DotGit().Root()
+
+	// The cache dir is a file, so nothing can be written in it.
+	file, err := ioutil.TempFile("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	cache := &Cache{Dir: file.Name(), TTL: time.Hour}
+	r, err := cache.GetRepo("foo", repoURL)
+	if err != nil {
+		t.Fatal("failed to GetRepo: ", err)
+	}
+	if want := "master"; r.DefaultBranch != want {
+		t.Errorf("expected default branch to be %q, got %q", want, r.DefaultBranch)
+	}
+}
+
+func TestGetRepo_DefaultCache(t *testing.T) {
+	f := fixtures.Basic().One()
+	repoURL := f.DotGit().Root()
+
+	DefaultCache = tempCache(t, 0)
+	defer func() { DefaultCache = nil }()
+
+	if _, err := GetRepo("foo", repoURL); err != nil {
+		t.Fatal("failed to GetRepo: ", err)
+	}
+
+	DefaultCache.Offline = true
+	r, err := GetRepo("foo", repoURL)
+	if err != nil {
+		t.Fatal("failed to GetRepo offline: ", err)
+	}
+	if want := "master"; r.DefaultBranch != want {
+		t.Errorf("expected default branch to be %q, got %q", want, r.DefaultBranch)
+	}
+}
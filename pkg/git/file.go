@@ -0,0 +1,52 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// ReadFileAt returns the contents of the file at path, relative to the root
+// of the local git repo containing dir, as of the revision rev, ex: "main",
+// "release-0.19", "v0.19.0" or "origin/main".
+func ReadFileAt(dir, rev, path string) ([]byte, error) {
+	r, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open git repo at %s: %w", dir, err)
+	}
+
+	hash, err := r.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve %s: %w", rev, err)
+	}
+	c, err := r.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	f, err := c.File(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s at %s: %w", path, rev, err)
+	}
+	contents, err := f.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(contents), nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// commitFile writes contents to name in the worktree of r and commits it.
+func commitFile(t *testing.T, r *git.Repository, dir, name, contents string) plumbing.Hash {
+	t.Helper()
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Add(name); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := w.Commit("update "+name, &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestReadFileAt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "readfileat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := commitFile(t, r, dir, "go.mod", "module example.com/old\n")
+	if err := r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("release-0.1"), first)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CreateTag("v0.1.0", first, nil); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, r, dir, "go.mod", "module example.com/new\n")
+
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		dir     string
+		rev     string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		"head": {
+			dir:  dir,
+			rev:  "HEAD",
+			path: "go.mod",
+			want: "module example.com/new\n",
+		},
+		"branch": {
+			dir:  dir,
+			rev:  "release-0.1",
+			path: "go.mod",
+			want: "module example.com/old\n",
+		},
+		"tag": {
+			dir:  dir,
+			rev:  "v0.1.0",
+			path: "go.mod",
+			want: "module example.com/old\n",
+		},
+		"from a sub directory": {
+			dir:  filepath.Join(dir, "sub"),
+			rev:  "HEAD",
+			path: "go.mod",
+			want: "module example.com/new\n",
+		},
+		"unknown rev": {
+			dir:     dir,
+			rev:     "release-9.9",
+			path:    "go.mod",
+			wantErr: true,
+		},
+		"unknown file": {
+			dir:     dir,
+			rev:     "HEAD",
+			path:    "nope.mod",
+			wantErr: true,
+		},
+		"not a repo": {
+			dir:     os.TempDir(),
+			rev:     "HEAD",
+			path:    "go.mod",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ReadFileAt(tt.dir, tt.rev, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadFileAt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ReadFileAt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
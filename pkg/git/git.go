@@ -18,27 +18,45 @@ package git
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/blang/semver/v4"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/storage/memory"
+	"golang.org/x/mod/module"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 // Repo is a simplified git remote, containing only the list of tags, default
 // branch and branches.
 type Repo struct {
-	Ref           string
-	DefaultBranch string
-	Tags          []string
-	Branches      []string
+	Ref           string   `json:"ref,omitempty" yaml:"ref,omitempty"`
+	URL           string   `json:"url,omitempty" yaml:"url,omitempty"`
+	DefaultBranch string   `json:"defaultBranch,omitempty" yaml:"defaultBranch,omitempty"`
+	Tags          []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Branches      []string `json:"branches,omitempty" yaml:"branches,omitempty"`
+	// Dir is the directory of the module within the repo, for repos holding
+	// more than one module. Tags of such a module are prefixed with the
+	// directory, ex: "schema/v0.1.0". Empty for a module at the repo root.
+	Dir string `json:"dir,omitempty" yaml:"dir,omitempty"`
 }
 
-// GetRepo will fetch a git repo and process it into a Repo object.
+// GetRepo will fetch a git repo and process it into a Repo object. If
+// DefaultCache is set, the result is looked up in and stored to the cache.
 func GetRepo(ref, url string) (*Repo, error) {
+	if DefaultCache != nil {
+		return DefaultCache.GetRepo(ref, url)
+	}
+	return getRepo(ref, url)
+}
+
+func getRepo(ref, url string) (*Repo, error) {
 	repo := new(Repo)
 	repo.Ref = ref
+	repo.URL = url
 
 	rem := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
 		Name: "origin",
@@ -63,6 +81,60 @@ func GetRepo(ref, url string) (*Repo, error) {
 	return repo, nil
 }
 
+// LocalRepo reads the tags and branches of the local git repo at dir into a
+// Repo, without reaching the network. The branches of the "origin" remote are
+// included, so a fresh clone lists the same branches as the remote. URL is
+// the URL of the "origin" remote if there is one, and dir otherwise.
+func LocalRepo(ref, dir string) (*Repo, error) {
+	r, err := git.PlainOpen(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open git repo at %s: %w", dir, err)
+	}
+
+	repo := &Repo{Ref: ref, URL: dir}
+	if remote, err := r.Remote("origin"); err == nil && len(remote.Config().URLs) > 0 {
+		repo.URL = remote.Config().URLs[0]
+	}
+
+	const originPrefix = "refs/remotes/origin/"
+	branches := sets.NewString()
+	refs, err := r.References()
+	if err != nil {
+		return nil, err
+	}
+	if err := refs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name()
+		switch {
+		case name.IsTag():
+			repo.Tags = append(repo.Tags, name.Short())
+		case name.IsBranch():
+			branches.Insert(name.Short())
+		case name.String() == originPrefix+"HEAD":
+			if ref.Type() == plumbing.SymbolicReference {
+				repo.DefaultBranch = strings.TrimPrefix(ref.Target().String(), originPrefix)
+			}
+		case strings.HasPrefix(name.String(), originPrefix):
+			branches.Insert(strings.TrimPrefix(name.String(), originPrefix))
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Strings(repo.Tags)
+	repo.Branches = branches.List()
+
+	if repo.DefaultBranch == "" {
+		head, err := r.Reference(plumbing.HEAD, false)
+		if err != nil {
+			return nil, err
+		}
+		if head.Type() == plumbing.SymbolicReference {
+			repo.DefaultBranch = head.Target().Short()
+		}
+	}
+	return repo, nil
+}
+
 type RefType int
 
 const (
@@ -78,13 +150,15 @@ const (
 	NoRef
 	// UndefinedRef is not defined
 	UndefinedRef
+	// ReleaseCandidateRef - tagged release candidate, ex: v0.20.0-rc.1
+	ReleaseCandidateRef
 )
 
-var refTypeString = []string{"Branch", "Default Branch", "Release Branch", "Release", "No Ref"}
+var refTypeString = []string{"Branch", "Default Branch", "Release Branch", "Release", "No Ref", "", "Release Candidate"}
 
 // String returns the string of RefType in human readable form.
 func (rt RefType) String() string {
-	if rt >= DefaultBranchRef && rt <= NoRef {
+	if rt >= DefaultBranchRef && rt <= ReleaseCandidateRef {
 		return refTypeString[rt]
 	}
 	return ""
@@ -94,35 +168,31 @@ func (rt RefType) String() string {
 // a this release.
 func (r *Repo) BestRefFor(this semver.Version, ruleset RulesetType) (string, RefType) {
 	switch ruleset {
-	case AnyRule, ReleaseOrReleaseBranchRule, ReleaseRule:
-		var largest *semver.Version
+	case AnyRule, ReleaseOrReleaseBranchRule, ReleaseRule, ReleaseOrCandidateRule, ReleaseOrCandidateOrReleaseBranchRule:
 		// Look for a release.
-		for _, t := range r.Tags {
-			if sv, ok := normalizeTagVersion(t); ok {
-				v, _ := semver.Make(sv)
-				// Go does not understand how to fetch semver tags with pre or build tags, skip those.
-				if v.Pre != nil || v.Build != nil {
-					continue
-				}
-				if v.Major == this.Major && v.Minor == this.Minor {
-					if largest == nil || largest.LT(v) {
-						largest = &v
-					}
-				}
-			}
-		}
-		if largest != nil {
+		if largest := r.largestTag(this, false); largest != nil {
 			return fmt.Sprintf("%s@%s", r.Ref, ReleaseVersion(*largest)), ReleaseRef
 		}
 	}
 
 	switch ruleset {
-	case AnyRule, ReleaseOrReleaseBranchRule, ReleaseBranchRule:
+	case ReleaseOrCandidateRule, ReleaseOrCandidateOrReleaseBranchRule:
+		// Look for a release candidate.
+		if largest := r.largestTag(this, true); largest != nil {
+			return fmt.Sprintf("%s@v%s", r.Ref, largest.String()), ReleaseCandidateRef
+		}
+	}
+
+	switch ruleset {
+	case AnyRule, ReleaseOrReleaseBranchRule, ReleaseBranchRule, ReleaseOrCandidateOrReleaseBranchRule:
 		var largest *semver.Version
 		// Look for a release branch.
 		for _, b := range r.Branches {
 			if bv, ok := normalizeBranchVersion(b); ok {
-				v, _ := semver.Make(bv)
+				v, err := semver.Make(bv)
+				if err != nil {
+					continue
+				}
 
 				if v.Major == this.Major && v.Minor == this.Minor {
 					if largest == nil || largest.LT(v) {
@@ -146,6 +216,143 @@ func (r *Repo) BestRefFor(this semver.Version, ruleset RulesetType) (string, Ref
 	return r.Ref, NoRef
 }
 
+// largestTag returns the largest version tagged for the major and minor
+// version of this. With pre, only release candidates are considered,
+// otherwise only releases are.
+func (r *Repo) largestTag(this semver.Version, pre bool) *semver.Version {
+	var largest *semver.Version
+	for _, t := range r.Tags {
+		sv, ok := r.moduleTagVersion(t)
+		if !ok {
+			continue
+		}
+		v, err := semver.Make(sv)
+		if err != nil {
+			continue
+		}
+		// Go does not understand how to fetch semver tags with build tags, skip those.
+		if v.Build != nil {
+			continue
+		}
+		if pre && !isReleaseCandidate(v) || !pre && v.Pre != nil {
+			continue
+		}
+		// The major version has to match the major version suffix of the module, if any.
+		if !r.matchesPathMajor(v) {
+			continue
+		}
+		if v.Major == this.Major && v.Minor == this.Minor {
+			if largest == nil || largest.LT(v) {
+				largest = &v
+			}
+		}
+	}
+	return largest
+}
+
+// LatestReleaseLine returns the largest major and minor version released by
+// the repo, tagged as a release or release candidate, or with a release
+// branch. The patch version is always 0. Returns false if the repo has no
+// release.
+func (r *Repo) LatestReleaseLine() (semver.Version, bool) {
+	var latest *semver.Version
+	consider := func(v semver.Version) {
+		line := semver.Version{Major: v.Major, Minor: v.Minor}
+		if latest == nil || latest.LT(line) {
+			latest = &line
+		}
+	}
+
+	for _, t := range r.Tags {
+		sv, ok := r.moduleTagVersion(t)
+		if !ok {
+			continue
+		}
+		v, err := semver.Make(sv)
+		if err != nil || v.Build != nil || IsPseudoVersion(t) || !r.matchesPathMajor(v) {
+			continue
+		}
+		if v.Pre != nil && !isReleaseCandidate(v) {
+			continue
+		}
+		consider(v)
+	}
+	for _, b := range r.Branches {
+		if bv, ok := normalizeBranchVersion(b); ok {
+			if v, err := semver.Make(bv); err == nil {
+				consider(v)
+			}
+		}
+	}
+
+	if latest == nil {
+		return semver.Version{}, false
+	}
+	return *latest, true
+}
+
+// PreviousRelease returns the tag of the release before this, ex: the
+// starting point of the release notes of this. For a patch release, it is the
+// largest earlier release of the same release line, ex: v0.19.0 for 0.19.1.
+// Otherwise, it is the first release of the latest earlier release line, ex:
+// v0.17.0 for 0.19.0 if 0.18 was skipped. Only releases of the module in r.Dir
+// are considered, of any major version. Returns false if there is none.
+func (r *Repo) PreviousRelease(this semver.Version) (string, bool) {
+	var prev *semver.Version
+	var prevTag string
+	for _, t := range r.Tags {
+		sv, ok := r.moduleTagVersion(t)
+		if !ok {
+			continue
+		}
+		v, err := semver.Make(sv)
+		if err != nil || v.Build != nil || v.Pre != nil || !v.LT(this) {
+			continue
+		}
+		sameLine := v.Major == this.Major && v.Minor == this.Minor
+		if this.Patch > 0 && !sameLine || this.Patch == 0 && sameLine {
+			continue
+		}
+		switch {
+		case prev == nil,
+			// The latest release line.
+			v.Major > prev.Major || v.Major == prev.Major && v.Minor > prev.Minor,
+			// Within it, the largest patch of a patch release, or the first
+			// release of a minor release.
+			v.Major == prev.Major && v.Minor == prev.Minor && (this.Patch > 0) == v.GT(*prev):
+			prev, prevTag = &v, t
+		}
+	}
+	return prevTag, prev != nil
+}
+
+// moduleTagVersion returns the version of a tag of the module in r.Dir,
+// without the leading "v". Tags of other modules in the repo are rejected.
+func (r *Repo) moduleTagVersion(tag string) (string, bool) {
+	if r.Dir != "" {
+		prefix := strings.Trim(r.Dir, "/") + "/"
+		if !strings.HasPrefix(tag, prefix) {
+			return tag, false
+		}
+		tag = tag[len(prefix):]
+	}
+	// Tags of nested modules are never a release of this module.
+	if strings.Contains(tag, "/") {
+		return tag, false
+	}
+	return normalizeTagVersion(tag)
+}
+
+// matchesPathMajor returns true if v is allowed by the major version suffix of
+// the module path, ex: "/v2" only allows v2.x.y, no suffix allows v0 and v1.
+func (r *Repo) matchesPathMajor(v semver.Version) bool {
+	_, pathMajor, ok := module.SplitPathVersion(r.Ref)
+	if !ok {
+		return false
+	}
+	return module.MatchPathMajor(ReleaseVersion(v), pathMajor)
+}
+
 func normalizeTagVersion(v string) (string, bool) {
 	if strings.HasPrefix(v, "v") {
 		// No need to account for unicode widths.
@@ -154,6 +361,25 @@ func normalizeTagVersion(v string) (string, bool) {
 	return v, false
 }
 
+// isReleaseCandidate returns true if v is a release candidate, a pre-release
+// starting with "rc", ex: v0.20.0-rc.1 or v0.20.0-rc1. Other pre-releases,
+// ex: v0.20.0-alpha.1, are not.
+func isReleaseCandidate(v semver.Version) bool {
+	if len(v.Pre) == 0 || v.Pre[0].IsNum {
+		return false
+	}
+	id := strings.ToLower(v.Pre[0].VersionStr)
+	if !strings.HasPrefix(id, "rc") {
+		return false
+	}
+	for _, c := range id[len("rc"):] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // ReleaseVersion returns a formatted release tag for a given version.
 func ReleaseVersion(v semver.Version) string {
 	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
@@ -182,7 +408,10 @@ func ParseRef(ref string) (string, string, RefType) {
 	}
 
 	// Try ref as a tag.
-	if _, ok := normalizeTagVersion(parts[1]); ok {
+	if sv, ok := normalizeTagVersion(parts[1]); ok {
+		if v, err := semver.Parse(sv); err == nil && isReleaseCandidate(v) && !IsPseudoVersion(parts[1]) {
+			return parts[0], parts[1], ReleaseCandidateRef
+		}
 		return parts[0], parts[1], ReleaseRef
 	}
 
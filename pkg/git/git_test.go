@@ -17,10 +17,16 @@ limitations under the License.
 package git
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/blang/semver/v4"
 	fixtures "github.com/go-git/go-git-fixtures/v4"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestGetRepo_BasicOne(t *testing.T) {
@@ -52,6 +58,74 @@ func TestGetRepo_Error(t *testing.T) {
 	}
 }
 
+func TestLocalRepo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "localrepo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := commitFile(t, r, dir, "go.mod", "module example.com/foo\n")
+	for _, ref := range []*plumbing.Reference{
+		plumbing.NewHashReference(plumbing.NewBranchReferenceName("release-0.1"), head),
+		plumbing.NewHashReference(plumbing.NewRemoteReferenceName("origin", "main"), head),
+		plumbing.NewHashReference(plumbing.NewRemoteReferenceName("origin", "release-0.1"), head),
+		plumbing.NewHashReference(plumbing.NewRemoteReferenceName("origin", "release-0.2"), head),
+		plumbing.NewHashReference(plumbing.NewRemoteReferenceName("upstream", "release-0.3"), head),
+		plumbing.NewSymbolicReference(plumbing.NewRemoteReferenceName("origin", "HEAD"), plumbing.NewRemoteReferenceName("origin", "main")),
+	} {
+		if err := r.Storer.SetReference(ref); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tag := range []string{"v0.1.0", "v0.0.1"} {
+		if _, err := r.CreateTag(tag, head, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := LocalRepo("example.com/foo", dir)
+	if err != nil {
+		t.Fatal("LocalRepo() =", err)
+	}
+	want := &Repo{
+		Ref:           "example.com/foo",
+		URL:           dir,
+		DefaultBranch: "main",
+		Tags:          []string{"v0.0.1", "v0.1.0"},
+		Branches:      []string{"main", "master", "release-0.1", "release-0.2"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("LocalRepo() diff(-want,+got):\n", diff)
+	}
+
+	// With an origin remote, its URL is used, and without origin/HEAD the
+	// checked out branch is the default branch.
+	if _, err := r.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"https://github.com/example/foo.git"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Storer.RemoveReference(plumbing.NewRemoteReferenceName("origin", "HEAD")); err != nil {
+		t.Fatal(err)
+	}
+	got, err = LocalRepo("example.com/foo", dir)
+	if err != nil {
+		t.Fatal("LocalRepo() =", err)
+	}
+	want.URL = "https://github.com/example/foo.git"
+	want.DefaultBranch = "master"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("LocalRepo() diff(-want,+got):\n", diff)
+	}
+
+	if _, err := LocalRepo("example.com/foo", os.TempDir()); err == nil {
+		t.Error("LocalRepo() expected an error for a directory that is not a git repo")
+	}
+}
+
 func TestRepo_BestRefFor(t *testing.T) {
 	repo := &Repo{
 		Ref:           "ref",
@@ -60,6 +134,13 @@ func TestRepo_BestRefFor(t *testing.T) {
 		Branches:      []string{"release-0.1", "bar", "release-0.2", "baz", "main", "release-0.3"},
 	}
 
+	rcRepo := &Repo{
+		Ref:           "ref",
+		DefaultBranch: "main",
+		Tags:          []string{"v0.19.0-rc.1", "v0.19.0", "v0.19.1", "v0.20.0-rc.1", "v0.20.0-rc.10", "v0.20.0-rc.2", "v0.20.0-rc.11+build", "v0.21.0+build", "v0.21.0-alpha.1", "v0.21.0-beta.2", "v0.21.0-nightly.20201110", "v0.21.0-rcx.1"},
+		Branches:      []string{"release-0.19", "release-0.20", "release-0.21", "main"},
+	}
+
 	tests := map[string]struct {
 		repo    *Repo
 		version semver.Version
@@ -182,6 +263,130 @@ func TestRepo_BestRefFor(t *testing.T) {
 			release: NoRef,
 			rule:    ReleaseBranchRule,
 		},
+		"Any - v0.0, unparsable versions": {
+			repo: &Repo{
+				Ref:           "ref",
+				DefaultBranch: "main",
+				Tags:          []string{"vnext", "v0", "v0.0"},
+				Branches:      []string{"release-next", "release-0"},
+			},
+			version: semver.MustParse("0.0.0"),
+			want:    "ref@main",
+			release: DefaultBranchRef,
+			rule:    AnyRule,
+		},
+		"Release - proxy versions": {
+			repo: &Repo{
+				Ref:           "ref",
+				DefaultBranch: "v0.20.0",
+				Tags:          []string{"v0.19.0", "v0.19.1", "v0.19.10", "v0.19.2", "v0.20.0", "v2.0.0+incompatible"},
+			},
+			version: semver.MustParse("0.19.0"),
+			want:    "ref@v0.19.10",
+			release: ReleaseRef,
+			rule:    ReleaseRule,
+		},
+		"Release - nested module": {
+			repo: &Repo{
+				Ref:  "knative.dev/hack/schema",
+				Dir:  "schema",
+				Tags: []string{"v0.3.2", "schema/v0.3.0", "schema/v0.3.1", "other/v0.3.5"},
+			},
+			version: semver.MustParse("0.3.0"),
+			want:    "knative.dev/hack/schema@v0.3.1",
+			release: ReleaseRef,
+			rule:    ReleaseRule,
+		},
+		"Release - root module ignores nested module tags": {
+			repo: &Repo{
+				Ref:  "knative.dev/hack",
+				Tags: []string{"v0.3.0", "schema/v0.3.1"},
+			},
+			version: semver.MustParse("0.3.0"),
+			want:    "knative.dev/hack@v0.3.0",
+			release: ReleaseRef,
+			rule:    ReleaseRule,
+		},
+		"Release - major version suffix": {
+			repo: &Repo{
+				Ref:  "example.com/foo/v2",
+				Tags: []string{"v1.3.0", "v2.3.0", "v2.3.1"},
+			},
+			version: semver.MustParse("2.3.0"),
+			want:    "example.com/foo/v2@v2.3.1",
+			release: ReleaseRef,
+			rule:    ReleaseRule,
+		},
+		"Release - no major version suffix": {
+			repo: &Repo{
+				Ref:  "example.com/foo",
+				Tags: []string{"v1.3.0", "v2.3.0"},
+			},
+			version: semver.MustParse("2.3.0"),
+			want:    "example.com/foo",
+			release: NoRef,
+			rule:    ReleaseRule,
+		},
+		"Release - nested module with major version suffix": {
+			repo: &Repo{
+				Ref:  "example.com/foo/bar/v3",
+				Dir:  "bar",
+				Tags: []string{"v3.1.0", "bar/v2.1.0", "bar/v3.1.0", "bar/v3.1.1"},
+			},
+			version: semver.MustParse("3.1.0"),
+			want:    "example.com/foo/bar/v3@v3.1.1",
+			release: ReleaseRef,
+			rule:    ReleaseRule,
+		},
+		"ReleaseOrCandidate - release": {
+			repo:    repo,
+			version: semver.MustParse("0.2.0"),
+			want:    "ref@v0.2.1",
+			release: ReleaseRef,
+			rule:    ReleaseOrCandidateRule,
+		},
+		"ReleaseOrCandidate - candidate": {
+			repo:    rcRepo,
+			version: semver.MustParse("0.20.0"),
+			want:    "ref@v0.20.0-rc.10",
+			release: ReleaseCandidateRef,
+			rule:    ReleaseOrCandidateRule,
+		},
+		"ReleaseOrCandidate - branch only": {
+			repo:    rcRepo,
+			version: semver.MustParse("0.21.0"),
+			want:    "ref",
+			release: NoRef,
+			rule:    ReleaseOrCandidateRule,
+		},
+		"ReleaseOrCandidateOrBranch - release": {
+			repo:    rcRepo,
+			version: semver.MustParse("0.19.0"),
+			want:    "ref@v0.19.1",
+			release: ReleaseRef,
+			rule:    ReleaseOrCandidateOrReleaseBranchRule,
+		},
+		"ReleaseOrCandidateOrBranch - candidate": {
+			repo:    rcRepo,
+			version: semver.MustParse("0.20.0"),
+			want:    "ref@v0.20.0-rc.10",
+			release: ReleaseCandidateRef,
+			rule:    ReleaseOrCandidateOrReleaseBranchRule,
+		},
+		"ReleaseOrCandidateOrBranch - branch": {
+			repo:    rcRepo,
+			version: semver.MustParse("0.21.0"),
+			want:    "ref@release-0.21",
+			release: ReleaseBranchRef,
+			rule:    ReleaseOrCandidateOrReleaseBranchRule,
+		},
+		"Release - candidates are ignored": {
+			repo:    rcRepo,
+			version: semver.MustParse("0.20.0"),
+			want:    "ref",
+			release: NoRef,
+			rule:    ReleaseRule,
+		},
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -196,6 +401,128 @@ func TestRepo_BestRefFor(t *testing.T) {
 	}
 }
 
+func TestRepo_LatestReleaseLine(t *testing.T) {
+	tests := map[string]struct {
+		repo   *Repo
+		want   semver.Version
+		wantOK bool
+	}{
+		"no release": {
+			repo: &Repo{Ref: "knative.dev/pkg", Branches: []string{"master"}, Tags: []string{"notarelease"}},
+		},
+		"tags": {
+			repo:   &Repo{Ref: "knative.dev/pkg", Tags: []string{"v0.18.1", "v0.19.0", "v0.9.0"}},
+			want:   semver.MustParse("0.19.0"),
+			wantOK: true,
+		},
+		"release branch": {
+			repo:   &Repo{Ref: "knative.dev/pkg", Tags: []string{"v0.18.1"}, Branches: []string{"master", "release-0.18", "release-0.19"}},
+			want:   semver.MustParse("0.19.0"),
+			wantOK: true,
+		},
+		"release candidate": {
+			repo:   &Repo{Ref: "knative.dev/pkg", Tags: []string{"v0.19.0", "v0.20.0-rc.1"}},
+			want:   semver.MustParse("0.20.0"),
+			wantOK: true,
+		},
+		"other pre-release": {
+			repo:   &Repo{Ref: "knative.dev/pkg", Tags: []string{"v0.19.0", "v0.20.0-alpha.1"}},
+			want:   semver.MustParse("0.19.0"),
+			wantOK: true,
+		},
+		"other major": {
+			repo:   &Repo{Ref: "knative.dev/pkg", Tags: []string{"v0.19.0", "v2.0.0"}},
+			want:   semver.MustParse("0.19.0"),
+			wantOK: true,
+		},
+		"nested module": {
+			repo:   &Repo{Ref: "knative.dev/pkg/schema", Dir: "schema", Tags: []string{"v0.20.0", "schema/v0.1.0"}},
+			want:   semver.MustParse("0.1.0"),
+			wantOK: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := tt.repo.LatestReleaseLine()
+			if ok != tt.wantOK {
+				t.Fatalf("LatestReleaseLine() ok = %t, want %t", ok, tt.wantOK)
+			}
+			if !got.Equals(tt.want) {
+				t.Errorf("LatestReleaseLine() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepo_PreviousRelease(t *testing.T) {
+	tags := []string{"v0.17.0", "v0.17.1", "v0.18.0", "v0.18.1", "v0.18.2", "v0.19.0", "v0.19.2", "v0.20.0-rc.1", "v0.20.0", "v0.20.0+build"}
+	tests := map[string]struct {
+		repo   *Repo
+		this   string
+		want   string
+		wantOK bool
+	}{
+		"minor": {
+			repo:   &Repo{Tags: tags},
+			this:   "0.19.0",
+			want:   "v0.18.0",
+			wantOK: true,
+		},
+		"first patch": {
+			repo:   &Repo{Tags: tags},
+			this:   "0.19.1",
+			want:   "v0.19.0",
+			wantOK: true,
+		},
+		"skipped patch": {
+			repo:   &Repo{Tags: tags},
+			this:   "0.19.3",
+			want:   "v0.19.2",
+			wantOK: true,
+		},
+		"skipped minor": {
+			repo:   &Repo{Tags: []string{"v0.17.0", "v0.17.1", "v0.19.0"}},
+			this:   "0.19.0",
+			want:   "v0.17.0",
+			wantOK: true,
+		},
+		"pre-releases are ignored": {
+			repo:   &Repo{Tags: tags},
+			this:   "0.21.0",
+			want:   "v0.20.0",
+			wantOK: true,
+		},
+		"first major": {
+			repo:   &Repo{Tags: append(tags, "v1.0.0")},
+			this:   "1.0.0",
+			want:   "v0.20.0",
+			wantOK: true,
+		},
+		"first release": {
+			repo: &Repo{Tags: tags},
+			this: "0.17.0",
+		},
+		"first patch without release": {
+			repo: &Repo{Tags: tags},
+			this: "0.21.1",
+		},
+		"nested module": {
+			repo:   &Repo{Dir: "schema", Tags: append(tags, "schema/v0.1.0", "schema/v0.2.0", "other/v0.2.1")},
+			this:   "0.3.0",
+			want:   "schema/v0.2.0",
+			wantOK: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := tt.repo.PreviousRelease(semver.MustParse(tt.this))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("PreviousRelease() = %q, %t, want %q, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
 func TestNormalizeTagVersion(t *testing.T) {
 	tests := map[string]struct {
 		version string
@@ -379,6 +706,26 @@ func TestParseRef(t *testing.T) {
 		wantModule:  "foo",
 		wantRef:     "v0.1.1",
 		wantRefType: ReleaseRef,
+	}, {
+		ref:         "foo@v0.20.0-rc.1",
+		wantModule:  "foo",
+		wantRef:     "v0.20.0-rc.1",
+		wantRefType: ReleaseCandidateRef,
+	}, {
+		ref:         "foo@v0.20.0-rc1",
+		wantModule:  "foo",
+		wantRef:     "v0.20.0-rc1",
+		wantRefType: ReleaseCandidateRef,
+	}, {
+		ref:         "foo@v0.20.0-alpha.1",
+		wantModule:  "foo",
+		wantRef:     "v0.20.0-alpha.1",
+		wantRefType: ReleaseRef,
+	}, {
+		ref:         "foo@v0.0.0-20200922164940-4bf40ad82aab",
+		wantModule:  "foo",
+		wantRef:     "v0.0.0-20200922164940-4bf40ad82aab",
+		wantRefType: ReleaseRef,
 	}, {
 		ref:         "foo@release-v0.1",
 		wantModule:  "foo",
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// CommitsBetween returns the commits reachable from the revision to but not
+// from the revision from, like `git log from..to`, in the local git repo
+// containing dir. The commits are ordered newest first. Revisions that are not
+// found locally are looked up as branches of the "origin" remote, ex:
+// "release-0.19" as "origin/release-0.19".
+func CommitsBetween(dir, from, to string) ([]Commit, error) {
+	r, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open git repo at %s: %w", dir, err)
+	}
+	fromHash, err := resolveRevision(r, from)
+	if err != nil {
+		return nil, err
+	}
+	toHash, err := resolveRevision(r, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Everything reachable from from is excluded.
+	excluded := make(map[plumbing.Hash]bool)
+	iter, err := r.Log(&git.LogOptions{From: fromHash})
+	if err != nil {
+		return nil, err
+	}
+	if err := iter.ForEach(func(c *object.Commit) error {
+		excluded[c.Hash] = true
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	commits := make([]Commit, 0)
+	iter, err = r.Log(&git.LogOptions{From: toHash})
+	if err != nil {
+		return nil, err
+	}
+	if err := iter.ForEach(func(c *object.Commit) error {
+		if excluded[c.Hash] {
+			return nil
+		}
+		commits = append(commits, Commit{
+			Hash:    c.Hash.String(),
+			Time:    c.Committer.When,
+			Message: c.Message,
+		})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+// resolveRevision resolves rev in r, falling back to the branch of the
+// "origin" remote.
+func resolveRevision(r *git.Repository, rev string) (plumbing.Hash, error) {
+	hash, err := r.ResolveRevision(plumbing.Revision(rev))
+	if err == nil {
+		return *hash, nil
+	}
+	if remote, rerr := r.ResolveRevision(plumbing.Revision("refs/remotes/origin/" + rev)); rerr == nil {
+		return *remote, nil
+	}
+	return plumbing.ZeroHash, fmt.Errorf("unable to resolve %s: %w", rev, err)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestCommitsBetween(t *testing.T) {
+	dir, err := ioutil.TempDir("", "commitsbetween")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := commitFile(t, r, dir, "a.txt", "1")
+	if _, err := r.CreateTag("v0.18.0", first, nil); err != nil {
+		t.Fatal(err)
+	}
+	second := commitFile(t, r, dir, "a.txt", "2")
+	third := commitFile(t, r, dir, "a.txt", "3")
+	// Only a remote branch, as in a fresh clone.
+	if err := r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewRemoteReferenceName("origin", "release-0.19"), third)); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, r, dir, "a.txt", "4")
+
+	tests := map[string]struct {
+		from    string
+		to      string
+		want    []plumbing.Hash
+		wantErr bool
+	}{
+		"tag to remote branch": {
+			from: "v0.18.0",
+			to:   "release-0.19",
+			want: []plumbing.Hash{third, second},
+		},
+		"same revision": {
+			from: "v0.18.0",
+			to:   "v0.18.0",
+		},
+		"unknown revision": {
+			from:    "v0.17.0",
+			to:      "HEAD",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := CommitsBetween(dir, tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CommitsBetween() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("CommitsBetween() = %d commits, want %d", len(got), len(tt.want))
+			}
+			for i, c := range got {
+				if c.Hash != tt.want[i].String() {
+					t.Errorf("CommitsBetween()[%d] = %s, want %s", i, c.Hash, tt.want[i])
+				}
+				if c.Message != "update a.txt" {
+					t.Errorf("CommitsBetween()[%d] message = %q", i, c.Message)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"golang.org/x/mod/module"
+)
+
+// pseudoVersionRE matches the pseudo-version forms described by
+// `go help modules`, ex: "v0.0.0-20200922164940-4bf40ad82aab".
+var pseudoVersionRE = regexp.MustCompile(`^v[0-9]+\.(0\.0-|\d+\.\d+-([^+]*\.)?0\.)\d{14}-[A-Za-z0-9]+(\+[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?$`)
+
+// IsPseudoVersion returns true if v is a go module pseudo-version.
+func IsPseudoVersion(v string) bool {
+	return strings.Count(v, "-") >= 2 && pseudoVersionRE.MatchString(v)
+}
+
+// Commit is the hash and commit time of a single git commit.
+type Commit struct {
+	Hash string
+	Time time.Time
+	// Message is the commit message. It is only set when listing commits.
+	Message string
+	// Tags are the tags of the commit itself. Only set by HeadCommit.
+	Tags []string `json:",omitempty"`
+	// ReachableTags are the tags of the commit and of its ancestors, the
+	// candidates for the base version of its pseudo-version. Only set by
+	// HeadCommit.
+	ReachableTags []string `json:",omitempty"`
+}
+
+// HeadCommit fetches the head commit of branch from the remote at url, with
+// the tags of the commit and of its ancestors. The history of the branch is
+// fetched, as the tags reachable from the head commit are needed for its
+// pseudo-version.
+func HeadCommit(url, branch string) (*Commit, error) {
+	r, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
+		URL:           url,
+		ReferenceName: plumbing.NewBranchReferenceName(branch),
+		SingleBranch:  true,
+		Tags:          git.AllTags,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch %s from %s: %w", branch, url, err)
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+	c, err := r.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	commit := &Commit{
+		Hash: c.Hash.String(),
+		Time: c.Committer.When,
+	}
+
+	// Map the tagged commits to their tags, peeling annotated tags.
+	tagged := make(map[plumbing.Hash][]string)
+	tags, err := r.Tags()
+	if err != nil {
+		return nil, err
+	}
+	if err := tags.ForEach(func(ref *plumbing.Reference) error {
+		hash := ref.Hash()
+		if tag, err := r.TagObject(hash); err == nil {
+			tc, err := tag.Commit()
+			if err != nil {
+				return nil // Not a tag of a commit.
+			}
+			hash = tc.Hash
+		}
+		tagged[hash] = append(tagged[hash], ref.Name().Short())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	commit.Tags = tagged[c.Hash]
+
+	if len(tagged) > 0 {
+		log, err := r.Log(&git.LogOptions{From: c.Hash})
+		if err != nil {
+			return nil, err
+		}
+		if err := log.ForEach(func(ac *object.Commit) error {
+			commit.ReachableTags = append(commit.ReachableTags, tagged[ac.Hash]...)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		sort.Strings(commit.ReachableTags)
+	}
+	return commit, nil
+}
+
+// PseudoVersion returns the go module pseudo-version for commit c of the
+// module with the given path, at the root of its repo. See Repo.PseudoVersion.
+func PseudoVersion(modulePath string, c *Commit) string {
+	return (&Repo{Ref: modulePath}).PseudoVersion(c)
+}
+
+// PseudoVersion returns the go module version for commit c of the module r.Ref,
+// the way the go command computes it. If c is tagged with a version of the
+// module, that version is returned. Otherwise, the pseudo-version is based on
+// the largest version of the module tagged on an ancestor of c:
+//   - "vX.Y.(Z+1)-0.<time>-<hash>" after the release vX.Y.Z,
+//   - "vX.Y.Z-pre.0.<time>-<hash>" after the pre-release vX.Y.Z-pre,
+//   - "vN.0.0-<time>-<hash>" without such a tag, with the major version taken
+//     from the module path.
+func (r *Repo) PseudoVersion(c *Commit) string {
+	if tagged := r.largestModuleTag(c.Tags); tagged != nil {
+		return "v" + tagged.String()
+	}
+
+	hash := c.Hash
+	if len(hash) > 12 {
+		hash = hash[:12]
+	}
+	suffix := c.Time.UTC().Format("20060102150405") + "-" + hash
+
+	base := r.largestModuleTag(c.ReachableTags)
+	switch {
+	case base == nil:
+		major := "v0"
+		if _, pathMajor, ok := module.SplitPathVersion(r.Ref); ok && pathMajor != "" {
+			major = strings.TrimLeft(pathMajor, "/.")
+			major = strings.TrimSuffix(major, "-unstable") // gopkg.in
+		}
+		return fmt.Sprintf("%s.0.0-%s", major, suffix)
+	case len(base.Pre) > 0:
+		return fmt.Sprintf("v%s.0.%s", base.String(), suffix)
+	default:
+		return fmt.Sprintf("v%d.%d.%d-0.%s", base.Major, base.Minor, base.Patch+1, suffix)
+	}
+}
+
+// largestModuleTag returns the largest version of the module r.Ref among
+// tags, or nil if none of them is a version of the module.
+func (r *Repo) largestModuleTag(tags []string) *semver.Version {
+	var largest *semver.Version
+	for _, t := range tags {
+		sv, ok := r.moduleTagVersion(t)
+		if !ok {
+			continue
+		}
+		v, err := semver.Make(sv)
+		if err != nil || v.Build != nil || IsPseudoVersion(t) || !r.matchesPathMajor(v) {
+			continue
+		}
+		if largest == nil || largest.LT(v) {
+			largest = &v
+		}
+	}
+	return largest
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	fixtures "github.com/go-git/go-git-fixtures/v4"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHeadCommit(t *testing.T) {
+	f := fixtures.Basic().One()
+	repoURL := f.DotGit().Root()
+
+	c, err := HeadCommit(repoURL, "branch")
+	if err != nil {
+		t.Fatal("failed to HeadCommit: ", err)
+	}
+	if want := "e8d3ffab552895c19b9fcf7aa264d277cde33881"; c.Hash != want {
+		t.Errorf("expected hash to be %q, got %q", want, c.Hash)
+	}
+	if want := time.Date(2015, 3, 31, 12, 0, 8, 0, time.UTC); !c.Time.Equal(want) {
+		t.Errorf("expected time to be %v, got %v", want, c.Time)
+	}
+
+	if _, err := HeadCommit(repoURL, "does-not-exist"); err == nil {
+		t.Error("expected to get an error from HeadCommit but did not")
+	}
+}
+
+func TestPseudoVersion(t *testing.T) {
+	commit := &Commit{
+		Hash: "4bf40ad82aab1d2b6bd4dd3bf0fd0fe76bbbb2d1",
+		Time: time.Date(2020, 9, 22, 9, 49, 40, 0, time.FixedZone("PDT", -7*60*60)),
+	}
+
+	tests := map[string]struct {
+		module        string
+		dir           string
+		tags          []string
+		reachableTags []string
+		want          string
+	}{
+		"v0": {
+			module: "knative.dev/pkg",
+			want:   "v0.0.0-20200922164940-4bf40ad82aab",
+		},
+		"v2": {
+			module: "knative.dev/pkg/v2",
+			want:   "v2.0.0-20200922164940-4bf40ad82aab",
+		},
+		"gopkg.in": {
+			module: "gopkg.in/yaml.v3",
+			want:   "v3.0.0-20200922164940-4bf40ad82aab",
+		},
+		"after a release": {
+			module:        "knative.dev/pkg",
+			reachableTags: []string{"v0.19.0", "v0.20.0", "v0.20.1"},
+			want:          "v0.20.2-0.20200922164940-4bf40ad82aab",
+		},
+		"after a pre-release": {
+			module:        "knative.dev/pkg",
+			reachableTags: []string{"v0.19.0", "v0.20.0-rc.1"},
+			want:          "v0.20.0-rc.1.0.20200922164940-4bf40ad82aab",
+		},
+		"tagged commit": {
+			module:        "knative.dev/pkg",
+			tags:          []string{"v0.20.1"},
+			reachableTags: []string{"v0.20.0", "v0.20.1"},
+			want:          "v0.20.1",
+		},
+		"after a release of another major": {
+			module:        "knative.dev/pkg/v2",
+			reachableTags: []string{"v1.3.0"},
+			want:          "v2.0.0-20200922164940-4bf40ad82aab",
+		},
+		"after a release of a nested module": {
+			module:        "knative.dev/pkg",
+			reachableTags: []string{"v0.19.0", "schema/v0.20.0"},
+			want:          "v0.19.1-0.20200922164940-4bf40ad82aab",
+		},
+		"nested module": {
+			module:        "knative.dev/pkg/schema",
+			dir:           "schema",
+			reachableTags: []string{"v0.19.0", "schema/v0.20.0"},
+			want:          "v0.20.1-0.20200922164940-4bf40ad82aab",
+		},
+		"after other tags": {
+			module:        "knative.dev/pkg",
+			reachableTags: []string{"latest", "v0.19.0+build"},
+			want:          "v0.0.0-20200922164940-4bf40ad82aab",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := *commit
+			c.Tags, c.ReachableTags = tt.tags, tt.reachableTags
+			repo := &Repo{Ref: tt.module, Dir: tt.dir}
+			if got := repo.PseudoVersion(&c); got != tt.want {
+				t.Errorf("PseudoVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got, want := PseudoVersion("knative.dev/pkg", commit), "v0.0.0-20200922164940-4bf40ad82aab"; got != want {
+		t.Errorf("PseudoVersion() = %q, want %q", got, want)
+	}
+}
+
+func TestHeadCommit_Tags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "headcommit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := commitFile(t, r, dir, "go.mod", "module example.com/a\n")
+	if _, err := r.CreateTag("v0.20.0", first, nil); err != nil {
+		t.Fatal(err)
+	}
+	second := commitFile(t, r, dir, "a.go", "package a\n")
+	if _, err := r.CreateTag("v0.20.1", second, &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		Message: "annotated",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("release-0.20"), second)); err != nil {
+		t.Fatal(err)
+	}
+	head := commitFile(t, r, dir, "b.go", "package a\n")
+	// A tag on another branch is not reachable from master.
+	if err := r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("release-0.21"), first)); err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("release-0.21")}); err != nil {
+		t.Fatal(err)
+	}
+	other := commitFile(t, r, dir, "c.go", "package a\n")
+	if _, err := r.CreateTag("v0.21.0", other, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		branch            string
+		wantHash          string
+		wantTags          []string
+		wantReachableTags []string
+	}{
+		"after a tag": {
+			branch:            "master",
+			wantHash:          head.String(),
+			wantReachableTags: []string{"v0.20.0", "v0.20.1"},
+		},
+		"tagged head": {
+			branch:            "release-0.20",
+			wantHash:          second.String(),
+			wantTags:          []string{"v0.20.1"},
+			wantReachableTags: []string{"v0.20.0", "v0.20.1"},
+		},
+		"other branch": {
+			branch:            "release-0.21",
+			wantHash:          other.String(),
+			wantTags:          []string{"v0.21.0"},
+			wantReachableTags: []string{"v0.20.0", "v0.21.0"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, err := HeadCommit(dir, tt.branch)
+			if err != nil {
+				t.Fatal("failed to HeadCommit: ", err)
+			}
+			if c.Hash != tt.wantHash {
+				t.Errorf("expected hash to be %q, got %q", tt.wantHash, c.Hash)
+			}
+			if diff := cmp.Diff(tt.wantTags, c.Tags); diff != "" {
+				t.Error("Tags diff(-want,+got):\n", diff)
+			}
+			if diff := cmp.Diff(tt.wantReachableTags, c.ReachableTags); diff != "" {
+				t.Error("ReachableTags diff(-want,+got):\n", diff)
+			}
+		})
+	}
+}
@@ -30,11 +30,17 @@ const (
 	ReleaseRule
 	// ReleaseBranchRule - only release branch
 	ReleaseBranchRule
+	// ReleaseOrCandidateRule - only release tag, or release candidate tag if
+	// there is no release
+	ReleaseOrCandidateRule
+	// ReleaseOrCandidateOrReleaseBranchRule - release tag, release candidate
+	// tag if there is no release, or release branch
+	ReleaseOrCandidateOrReleaseBranchRule
 	// InvalidRule - unable to parse
 	InvalidRule
 )
 
-var rulesetTypeString = []string{"Any", "ReleaseOrBranch", "Release", "Branch", "Invalid"}
+var rulesetTypeString = []string{"Any", "ReleaseOrBranch", "Release", "Branch", "ReleaseOrCandidate", "ReleaseOrCandidateOrBranch", "Invalid"}
 var rulesetLookup map[string]RulesetType
 
 // init will produce a ruleset lookup map to help with ruleset string conversion.
@@ -69,6 +75,8 @@ func Rulesets() []string {
 		ReleaseOrReleaseBranchRule.String(),
 		ReleaseRule.String(),
 		ReleaseBranchRule.String(),
+		ReleaseOrCandidateRule.String(),
+		ReleaseOrCandidateOrReleaseBranchRule.String(),
 		// Invalid is omitted.
 	}
 }
@@ -42,6 +42,14 @@ func TestRuleset(t *testing.T) {
 			rule: "Branch",
 			want: ReleaseBranchRule,
 		},
+		"ReleaseOrCandidate": {
+			rule: "ReleaseOrCandidate",
+			want: ReleaseOrCandidateRule,
+		},
+		"ReleaseOrCandidateOrBranch": {
+			rule: "ReleaseOrCandidateOrBranch",
+			want: ReleaseOrCandidateOrReleaseBranchRule,
+		},
 		"Invalid": {
 			rule: "Invalid",
 			want: InvalidRule,
@@ -106,6 +114,14 @@ func TestRulesetType_String(t *testing.T) {
 			rt:   ReleaseBranchRule,
 			want: "Branch",
 		},
+		"ReleaseOrCandidate": {
+			rt:   ReleaseOrCandidateRule,
+			want: "ReleaseOrCandidate",
+		},
+		"ReleaseOrCandidateOrBranch": {
+			rt:   ReleaseOrCandidateOrReleaseBranchRule,
+			want: "ReleaseOrCandidateOrBranch",
+		},
 		"Invalid": {
 			rt:   InvalidRule,
 			want: "Invalid",
@@ -129,7 +145,7 @@ func TestRulesets(t *testing.T) {
 		want []string
 	}{
 		"Default": {
-			want: []string{"Any", "ReleaseOrBranch", "Release", "Branch"},
+			want: []string{"Any", "ReleaseOrBranch", "Release", "Branch", "ReleaseOrCandidate", "ReleaseOrCandidateOrBranch"},
 		},
 	}
 	for name, tt := range tests {
@@ -19,11 +19,14 @@ package golang
 import (
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"path"
 	"strings"
 
 	"knative.dev/test-infra/pkg/git"
 
+	"golang.org/x/mod/module"
 	"golang.org/x/net/html"
 )
 
@@ -33,81 +36,267 @@ type MetaImport struct {
 	Prefix, VCS, RepoRoot string
 }
 
-func (m *MetaImport) OrgRepo() (string, string) {
+// OrgRepo returns the last two path elements of the repo root, which are the
+// org and repo of repos hosted on GitHub or GitLab. Both URLs and scp-like
+// repo roots, ex: "git@github.com:knative/pkg.git", are supported.
+func (m *MetaImport) OrgRepo() (string, string, error) {
 	repoRoot := strings.TrimSuffix(m.RepoRoot, ".git")
 	urlParts := strings.Split(repoRoot, "://")
-	parts := strings.Split(urlParts[len(urlParts)-1], "/")
-	if len(parts) >= 2 {
-		return parts[len(parts)-2], parts[len(parts)-1]
+	hostPath := urlParts[len(urlParts)-1]
+	if len(urlParts) == 1 {
+		hostPath = strings.Replace(hostPath, ":", "/", 1)
 	}
-	panic("unknown repo root: " + m.RepoRoot)
+	parts := strings.Split(hostPath, "/")
+	if len(parts) >= 3 && parts[len(parts)-2] != "" && parts[len(parts)-1] != "" {
+		return parts[len(parts)-2], parts[len(parts)-1], nil
+	}
+	return "", "", fmt.Errorf("unknown repo root: %q", m.RepoRoot)
 }
 
-func metaContent(doc *html.Node, name string) (string, error) {
-	var meta *html.Node
+// MetaSource represents the parsed <meta name="go-source"
+// content="prefix home directory file" /> tags from HTML files, which point
+// to the browsable source of a repo.
+type MetaSource struct {
+	Prefix, Home, Directory, File string
+}
+
+// Metas holds the go-import and go-source meta tags of a page, in document
+// order.
+type Metas struct {
+	Imports []MetaImport
+	Sources []MetaSource
+}
+
+// goGetURL returns the URL go-get discovery fetches for an import path. It is
+// overridden in tests.
+var goGetURL = func(importPath string) string {
+	return fmt.Sprintf("https://%s?go-get=1", importPath)
+}
+
+// metaContents returns the content of each meta tag named name in doc.
+func metaContents(doc *html.Node, name string) []string {
+	var contents []string
 	var crawler func(*html.Node)
 	crawler = func(node *html.Node) {
 		if node.Type == html.ElementNode && node.Data == "meta" {
+			var isName bool
+			var content string
 			for _, attr := range node.Attr {
-				if attr.Key == "name" && attr.Val == name {
-					meta = node
-					return
+				switch attr.Key {
+				case "name":
+					isName = attr.Val == name
+				case "content":
+					content = attr.Val
 				}
 			}
-
+			if isName {
+				contents = append(contents, content)
+			}
 		}
 		for child := node.FirstChild; child != nil; child = child.NextSibling {
 			crawler(child)
 		}
 	}
 	crawler(doc)
-	if meta != nil {
-		for _, attr := range meta.Attr {
-			if attr.Key == "content" {
-				return attr.Val, nil
-			}
-		}
+	return contents
+}
+
+func metaContent(doc *html.Node, name string) (string, error) {
+	if contents := metaContents(doc, name); len(contents) > 0 {
+		return contents[0], nil
 	}
 	return "", fmt.Errorf("missing <meta name=%s> in the node tree", name)
 }
 
-// GetMetaImport fetches and parses header tags named go-import into a
-// MetaImport object.
-func GetMetaImport(url string) (*MetaImport, error) {
+// parseMetas parses the go-import and go-source meta tags of doc. Malformed
+// tags are skipped, like the go command does.
+func parseMetas(doc *html.Node) *Metas {
+	metas := new(Metas)
+	for _, content := range metaContents(doc, "go-import") {
+		if f := strings.Fields(content); len(f) == 3 {
+			metas.Imports = append(metas.Imports, MetaImport{Prefix: f[0], VCS: f[1], RepoRoot: f[2]})
+		}
+	}
+	for _, content := range metaContents(doc, "go-source") {
+		if f := strings.Fields(content); len(f) == 4 {
+			metas.Sources = append(metas.Sources, MetaSource{Prefix: f[0], Home: f[1], Directory: f[2], File: f[3]})
+		}
+	}
+	return metas
+}
+
+// GetMetas fetches url, following redirects, and parses its go-import and
+// go-source meta tags. Like the go command, a page served with an error
+// status is only an error if it has no go-import meta tag.
+func GetMetas(url string) (*Metas, error) {
 	resp, err := http.Get(url)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 	doc, err := html.Parse(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	content, err := metaContent(doc, "go-import")
+	metas := parseMetas(doc)
+	if len(metas.Imports) == 0 {
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, fmt.Errorf("unexpected status from %s: %s", url, resp.Status)
+		}
+		return nil, fmt.Errorf("missing <meta name=go-import> in %s", url)
+	}
+	return metas, nil
+}
+
+// GetMetaImport fetches and parses header tags named go-import into a
+// MetaImport object. If there is more than one, the first one whose VCS is
+// not "mod" is returned. Use DiscoverMetaImport to select the tag matching a
+// module.
+func GetMetaImport(url string) (*MetaImport, error) {
+	metas, err := GetMetas(url)
 	if err != nil {
 		return nil, err
 	}
+	for i := range metas.Imports {
+		if metas.Imports[i].VCS != "mod" {
+			return &metas.Imports[i], nil
+		}
+	}
+	return &metas.Imports[0], nil
+}
 
-	f := strings.Fields(content)
+// hasPathPrefix reports whether prefix is importPath or one of its parents.
+func hasPathPrefix(importPath, prefix string) bool {
+	return importPath == prefix || strings.HasPrefix(importPath, prefix+"/")
+}
 
-	return &MetaImport{
-		Prefix:   f[0],
-		VCS:      f[1],
-		RepoRoot: f[2],
-	}, nil
+// MatchImport returns the go-import meta tag with the longest prefix of
+// importPath. Tags with the "mod" VCS, which point to a module proxy, are only
+// returned if no other tag has the same prefix.
+func (m *Metas) MatchImport(importPath string) (*MetaImport, error) {
+	var match, conflict *MetaImport
+	for i := range m.Imports {
+		mi := &m.Imports[i]
+		if !hasPathPrefix(importPath, mi.Prefix) {
+			continue
+		}
+		switch {
+		case match == nil, len(mi.Prefix) > len(match.Prefix):
+			match, conflict = mi, nil
+		case len(mi.Prefix) < len(match.Prefix), mi.VCS == "mod", *mi == *match:
+		case match.VCS == "mod":
+			match = mi
+		default:
+			conflict = mi
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("no go-import meta tag matches %s", importPath)
+	}
+	if conflict != nil {
+		return nil, fmt.Errorf("multiple go-import meta tags match %s: %q and %q", importPath,
+			match.Prefix+" "+match.VCS+" "+match.RepoRoot, conflict.Prefix+" "+conflict.VCS+" "+conflict.RepoRoot)
+	}
+	return match, nil
 }
 
-// ModuleToRepo resolves a go module name to a remote git repo.
+// MatchSource returns the go-source meta tag with the longest prefix of
+// importPath, or nil if there is none.
+func (m *Metas) MatchSource(importPath string) *MetaSource {
+	var match *MetaSource
+	for i := range m.Sources {
+		ms := &m.Sources[i]
+		if hasPathPrefix(importPath, ms.Prefix) && (match == nil || len(ms.Prefix) > len(match.Prefix)) {
+			match = ms
+		}
+	}
+	return match
+}
+
+// DiscoverMetaImport finds the go-import meta tag of importPath the way go get
+// does, and its go-source meta tag, if any. The page of importPath is fetched
+// first, then the pages of its parent paths, until one has a go-import meta
+// tag whose prefix matches importPath.
+func DiscoverMetaImport(importPath string) (*MetaImport, *MetaSource, error) {
+	var errs []string
+	for p := importPath; ; p = path.Dir(p) {
+		url := goGetURL(p)
+		metas, err := GetMetas(url)
+		if err == nil {
+			var mi *MetaImport
+			if mi, err = metas.MatchImport(importPath); err == nil {
+				return mi, metas.MatchSource(importPath), nil
+			}
+		}
+		errs = append(errs, err.Error())
+		if !strings.Contains(p, "/") {
+			break
+		}
+	}
+	return nil, nil, fmt.Errorf("unable to find the go import of %s: %s", importPath, strings.Join(errs, "; "))
+}
+
+// ModuleToRepo resolves a go module name to a remote git repo. If
+// git.DefaultCache is set, both the go import lookup and the remote refs are
+// served from and stored to the cache.
 func ModuleToRepo(module string) (*git.Repo, error) {
-	url := fmt.Sprintf("https://%s?go-get=1", module)
-	meta, err := GetMetaImport(url)
+	meta, err := moduleMetaImport(module)
 	if err != nil {
-		return nil, fmt.Errorf("unable to fetch go import %s: %w", url, err)
+		return nil, err
 	}
 
 	if meta.VCS != "git" {
 		return nil, errors.New("unknown VCS: " + meta.VCS)
 	}
 
-	return git.GetRepo(module, meta.RepoRoot)
+	repo, err := git.GetRepo(module, meta.RepoRoot)
+	if err != nil {
+		return nil, err
+	}
+	repo.Dir = ModuleDir(module, meta.Prefix)
+	return repo, nil
+}
+
+// ModuleDir returns the directory of a module within the repo whose import
+// path is repoPrefix. The major version suffix of the module path is not part
+// of the directory, ex: both "knative.dev/hack/schema" and
+// "knative.dev/hack/schema/v2" are in "schema" of "knative.dev/hack".
+func ModuleDir(modulePath, repoPrefix string) string {
+	prefix, _, ok := module.SplitPathVersion(modulePath)
+	if !ok {
+		prefix = modulePath
+	}
+	if prefix != repoPrefix && !strings.HasPrefix(prefix, repoPrefix+"/") {
+		return ""
+	}
+	return strings.TrimPrefix(prefix[len(repoPrefix):], "/")
+}
+
+func moduleMetaImport(module string) (*MetaImport, error) {
+	cache := git.DefaultCache
+	key := "go-import " + module
+
+	if cache != nil {
+		meta := new(MetaImport)
+		if cache.Get(key, meta) {
+			return meta, nil
+		}
+		if cache.Offline {
+			return nil, fmt.Errorf("unable to fetch go import for %s: %w", module, git.ErrOffline)
+		}
+	}
+
+	meta, _, err := DiscoverMetaImport(module)
+	if err != nil {
+		return nil, err
+	}
+
+	if cache != nil {
+		// Caching is best effort, the cache dir may not be writable.
+		if err := cache.Put(key, meta); err != nil {
+			log.Printf("unable to cache go import for %s: %v", module, err)
+		}
+	}
+	return meta, nil
 }
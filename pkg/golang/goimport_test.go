@@ -17,19 +17,27 @@ limitations under the License.
 package golang
 
 import (
+	"errors"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
 
+	fixtures "github.com/go-git/go-git-fixtures/v4"
+	"github.com/google/go-cmp/cmp"
 	"golang.org/x/net/html"
+
+	"knative.dev/test-infra/pkg/git"
 )
 
 func TestMetaImport_OrgRepo(t *testing.T) {
 	tests := map[string]struct {
-		meta *MetaImport
-		org  string
-		repo string
+		meta    *MetaImport
+		org     string
+		repo    string
+		wantErr bool
 	}{
 		"github": {
 			meta: &MetaImport{
@@ -52,10 +60,32 @@ func TestMetaImport_OrgRepo(t *testing.T) {
 			org:  "oldscott",
 			repo: "boiii",
 		},
+		"scp-like": {
+			meta: &MetaImport{
+				RepoRoot: "git@github.com:n3wscott/buoy.git",
+			},
+			org:  "n3wscott",
+			repo: "buoy",
+		},
+		"host only": {
+			meta: &MetaImport{
+				RepoRoot: "https://github.com",
+			},
+			wantErr: true,
+		},
+		"trailing slash": {
+			meta: &MetaImport{
+				RepoRoot: "https://github.com/n3wscott/",
+			},
+			wantErr: true,
+		},
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			org, repo := tt.meta.OrgRepo()
+			org, repo, err := tt.meta.OrgRepo()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OrgRepo() error = %v, wantErr %v", err, tt.wantErr)
+			}
 			if org != tt.org {
 				t.Errorf("OrgRepo() org = %v, want %v", org, tt.org)
 			}
@@ -66,22 +96,50 @@ func TestMetaImport_OrgRepo(t *testing.T) {
 	}
 }
 
-func TestMetaImport_OrgRepo_UnknownVCS(t *testing.T) {
-	meta := &MetaImport{
-		RepoRoot: "https://github.com",
+func TestModuleDir(t *testing.T) {
+	tests := map[string]struct {
+		module string
+		prefix string
+		want   string
+	}{
+		"root": {
+			module: "knative.dev/hack",
+			prefix: "knative.dev/hack",
+			want:   "",
+		},
+		"root, major version": {
+			module: "example.com/foo/v2",
+			prefix: "example.com/foo",
+			want:   "",
+		},
+		"nested": {
+			module: "knative.dev/hack/schema",
+			prefix: "knative.dev/hack",
+			want:   "schema",
+		},
+		"nested, major version": {
+			module: "knative.dev/hack/schema/v2",
+			prefix: "knative.dev/hack",
+			want:   "schema",
+		},
+		"deeply nested": {
+			module: "example.com/foo/a/b",
+			prefix: "example.com/foo",
+			want:   "a/b",
+		},
+		"unrelated prefix": {
+			module: "example.com/foobar",
+			prefix: "example.com/foo",
+			want:   "",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := ModuleDir(tt.module, tt.prefix); got != tt.want {
+				t.Errorf("ModuleDir() = %q, want %q", got, tt.want)
+			}
+		})
 	}
-
-	defer func() {
-		if r := recover(); r == nil {
-			// expect panic.
-			t.Errorf("Expected OrgRepo to panic.")
-		}
-	}()
-
-	org, repo := meta.OrgRepo()
-
-	// if we get here, it is a fail.
-	t.Errorf("Expected OrgRepo to panic, got: %s, %s", org, repo)
 }
 
 func TestMetaContent(t *testing.T) {
@@ -180,6 +238,18 @@ func TestGetMetaImport_InvalidHost(t *testing.T) {
 	}
 }
 
+func TestGetMetaImport_MalformedGoImport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><meta name="go-import" content="tableflip.dev/buoy git"></head></html>`))
+	}))
+	defer ts.Close()
+
+	_, err := GetMetaImport(ts.URL)
+	if err == nil {
+		t.Errorf("expected error, but did not get it.")
+	}
+}
+
 func TestGetMetaImport_MissingGoImport(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`<html>hi</html>`))
@@ -191,3 +261,285 @@ func TestGetMetaImport_MissingGoImport(t *testing.T) {
 		t.Errorf("expected error, but did not get it.")
 	}
 }
+
+func TestModuleToRepo_Offline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golang-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	git.DefaultCache = &git.Cache{Dir: dir, Offline: true}
+	defer func() { git.DefaultCache = nil }()
+
+	if _, err := ModuleToRepo("example.com/foo"); !errors.Is(err, git.ErrOffline) {
+		t.Error("expected ModuleToRepo to return ErrOffline, got ", err)
+	}
+
+	// Seed the cache, then resolve without the network.
+	repoURL := fixtures.Basic().One().DotGit().Root()
+	online := &git.Cache{Dir: dir}
+	if err := online.Put("go-import example.com/foo", &MetaImport{Prefix: "example.com/foo", VCS: "git", RepoRoot: repoURL}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := online.GetRepo("example.com/foo", repoURL); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := ModuleToRepo("example.com/foo")
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if want := "example.com/foo"; repo.Ref != want {
+		t.Errorf("repo.Ref got = %v, want %v", repo.Ref, want)
+	}
+	if want := "master"; repo.DefaultBranch != want {
+		t.Errorf("repo.DefaultBranch got = %v, want %v", repo.DefaultBranch, want)
+	}
+	if want := ""; repo.Dir != want {
+		t.Errorf("repo.Dir got = %v, want %v", repo.Dir, want)
+	}
+
+	// A nested module of the same repo.
+	if err := online.Put("go-import example.com/foo/bar/v2", &MetaImport{Prefix: "example.com/foo", VCS: "git", RepoRoot: repoURL}); err != nil {
+		t.Fatal(err)
+	}
+	repo, err = ModuleToRepo("example.com/foo/bar/v2")
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if want := "bar"; repo.Dir != want {
+		t.Errorf("repo.Dir got = %v, want %v", repo.Dir, want)
+	}
+}
+
+func TestMetas_MatchImport(t *testing.T) {
+	metas := &Metas{Imports: []MetaImport{
+		{Prefix: "example.com/a", VCS: "mod", RepoRoot: "https://proxy.example.com"},
+		{Prefix: "example.com/a", VCS: "git", RepoRoot: "https://github.com/example/a"},
+		{Prefix: "example.com/a/b", VCS: "git", RepoRoot: "https://github.com/example/b"},
+		{Prefix: "example.com/m", VCS: "mod", RepoRoot: "https://proxy.example.com"},
+		{Prefix: "example.com/c", VCS: "git", RepoRoot: "https://github.com/example/c"},
+		{Prefix: "example.com/c", VCS: "git", RepoRoot: "https://github.com/example/c2"},
+		{Prefix: "example.com/c/d", VCS: "git", RepoRoot: "https://github.com/example/d"},
+	}}
+
+	tests := map[string]struct {
+		importPath string
+		want       string
+		wantErr    bool
+	}{
+		"exact prefix, git over mod": {
+			importPath: "example.com/a",
+			want:       "https://github.com/example/a",
+		},
+		"subpath": {
+			importPath: "example.com/a/x/y",
+			want:       "https://github.com/example/a",
+		},
+		"longest prefix": {
+			importPath: "example.com/a/b/c",
+			want:       "https://github.com/example/b",
+		},
+		"only mod": {
+			importPath: "example.com/m/v2",
+			want:       "https://proxy.example.com",
+		},
+		"conflicting tags": {
+			importPath: "example.com/c",
+			wantErr:    true,
+		},
+		"longer prefix than the conflicting tags": {
+			importPath: "example.com/c/d",
+			want:       "https://github.com/example/d",
+		},
+		"not a path prefix": {
+			importPath: "example.com/ab",
+			wantErr:    true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := metas.MatchImport(tt.importPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MatchImport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != nil && got.RepoRoot != tt.want {
+				t.Errorf("MatchImport() = %q, want %q", got.RepoRoot, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetas_MatchSource(t *testing.T) {
+	metas := &Metas{Sources: []MetaSource{
+		{Prefix: "example.com/a", Home: "https://github.com/example/a"},
+		{Prefix: "example.com/a/b", Home: "https://github.com/example/b"},
+	}}
+	if got := metas.MatchSource("example.com/a/b/c"); got == nil || got.Home != "https://github.com/example/b" {
+		t.Errorf("MatchSource() = %v, want the example.com/a/b source", got)
+	}
+	if got := metas.MatchSource("example.com/c"); got != nil {
+		t.Errorf("MatchSource() = %v, want nil", got)
+	}
+}
+
+func TestGetMetas(t *testing.T) {
+	tests := map[string]struct {
+		status  int
+		body    string
+		want    *Metas
+		wantErr bool
+	}{
+		"several tags": {
+			status: http.StatusOK,
+			body: `<html><head>
+				<meta name="go-import" content="example.com/a mod https://proxy.example.com">
+				<meta name="go-import" content="example.com/a git https://github.com/example/a">
+				<meta name="go-import" content="example.com/a git">
+				<meta name="go-source" content="example.com/a https://github.com/example/a https://github.com/example/a/tree/main{/dir} https://github.com/example/a/blob/main{/dir}/{file}#L{line}">
+				</head></html>`,
+			want: &Metas{
+				Imports: []MetaImport{
+					{Prefix: "example.com/a", VCS: "mod", RepoRoot: "https://proxy.example.com"},
+					{Prefix: "example.com/a", VCS: "git", RepoRoot: "https://github.com/example/a"},
+				},
+				Sources: []MetaSource{{
+					Prefix:    "example.com/a",
+					Home:      "https://github.com/example/a",
+					Directory: "https://github.com/example/a/tree/main{/dir}",
+					File:      "https://github.com/example/a/blob/main{/dir}/{file}#L{line}",
+				}},
+			},
+		},
+		"error status with a go-import": {
+			status: http.StatusNotFound,
+			body:   `<html><head><meta name="go-import" content="example.com/a git https://github.com/example/a"></head></html>`,
+			want: &Metas{
+				Imports: []MetaImport{{Prefix: "example.com/a", VCS: "git", RepoRoot: "https://github.com/example/a"}},
+			},
+		},
+		"error status": {
+			status:  http.StatusInternalServerError,
+			body:    `<html>oops</html>`,
+			wantErr: true,
+		},
+		"only malformed tags": {
+			status:  http.StatusOK,
+			body:    `<html><head><meta name="go-import" content="example.com/a git"></head></html>`,
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer ts.Close()
+
+			got, err := GetMetas(ts.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetMetas() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Error("GetMetas() diff(-want,+got):\n", diff)
+			}
+		})
+	}
+}
+
+func TestDiscoverMetaImport(t *testing.T) {
+	pages := map[string]string{
+		"/example.com/vanity": `<html><head>
+			<meta name="go-import" content="example.com/vanity mod https://proxy.example.com">
+			<meta name="go-import" content="example.com/vanity git https://github.com/example/vanity">
+			<meta name="go-import" content="example.com/vanity/sub git https://github.com/example/sub">
+			<meta name="go-source" content="example.com/vanity/sub https://github.com/example/sub _ _">
+			</head></html>`,
+		"/example.com/new": `<html><head>
+			<meta name="go-import" content="example.com/old git https://github.com/example/new">
+			</head></html>`,
+		"/example.com/other": `<html><head>
+			<meta name="go-import" content="example.com/different git https://github.com/example/different">
+			</head></html>`,
+	}
+	var fetched []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched = append(fetched, r.URL.Path)
+		if r.URL.Query().Get("go-get") != "1" {
+			t.Errorf("missing go-get=1 in %s", r.URL)
+		}
+		switch r.URL.Path {
+		case "/example.com/old":
+			http.Redirect(w, r, "/example.com/new?go-get=1", http.StatusFound)
+		case "/example.com/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			if page, ok := pages[r.URL.Path]; ok {
+				w.Write([]byte(page))
+				return
+			}
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	defer func(old func(string) string) { goGetURL = old }(goGetURL)
+	goGetURL = func(importPath string) string {
+		return ts.URL + "/" + importPath + "?go-get=1"
+	}
+
+	tests := map[string]struct {
+		importPath  string
+		want        *MetaImport
+		wantSource  bool
+		wantFetched []string
+		wantErr     bool
+	}{
+		"parent path, longest prefix": {
+			importPath:  "example.com/vanity/sub/pkg",
+			want:        &MetaImport{Prefix: "example.com/vanity/sub", VCS: "git", RepoRoot: "https://github.com/example/sub"},
+			wantSource:  true,
+			wantFetched: []string{"/example.com/vanity/sub/pkg", "/example.com/vanity/sub", "/example.com/vanity"},
+		},
+		"git over mod": {
+			importPath:  "example.com/vanity",
+			want:        &MetaImport{Prefix: "example.com/vanity", VCS: "git", RepoRoot: "https://github.com/example/vanity"},
+			wantFetched: []string{"/example.com/vanity"},
+		},
+		"redirect": {
+			importPath:  "example.com/old",
+			want:        &MetaImport{Prefix: "example.com/old", VCS: "git", RepoRoot: "https://github.com/example/new"},
+			wantFetched: []string{"/example.com/old", "/example.com/new"},
+		},
+		"prefix mismatch": {
+			importPath:  "example.com/other",
+			wantFetched: []string{"/example.com/other", "/example.com"},
+			wantErr:     true,
+		},
+		"error status": {
+			importPath:  "example.com/broken",
+			wantFetched: []string{"/example.com/broken", "/example.com"},
+			wantErr:     true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			fetched = nil
+			got, source, err := DiscoverMetaImport(tt.importPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DiscoverMetaImport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Error("DiscoverMetaImport() diff(-want,+got):\n", diff)
+			}
+			if (source != nil) != tt.wantSource {
+				t.Errorf("DiscoverMetaImport() source = %v, want source %v", source, tt.wantSource)
+			}
+			if diff := cmp.Diff(tt.wantFetched, fetched); diff != "" {
+				t.Error("DiscoverMetaImport() fetched diff(-want,+got):\n", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package golang
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+
+	"knative.dev/test-infra/pkg/git"
+)
+
+// VersionInfo is the metadata the module proxy protocol returns for a single
+// version of a module.
+type VersionInfo struct {
+	Version string
+	Time    time.Time
+}
+
+// Proxy resolves modules using the module proxy protocol, as described by
+// `go help goproxy`.
+type Proxy struct {
+	// URL is the base URL of the proxy, ex: "https://proxy.golang.org".
+	URL string
+	// Client is the http client used to talk to the proxy.
+	Client *http.Client
+}
+
+// NewProxy returns a Proxy for the first proxy URL in goproxy, which uses the
+// same comma or pipe separated format as the GOPROXY environment variable.
+// The "direct" and "off" keywords are skipped.
+func NewProxy(goproxy string) (*Proxy, error) {
+	for _, u := range strings.FieldsFunc(goproxy, func(r rune) bool { return r == ',' || r == '|' }) {
+		u = strings.TrimSpace(u)
+		if u == "" || u == "direct" || u == "off" {
+			continue
+		}
+		if !strings.Contains(u, "://") {
+			u = "https://" + u
+		}
+		return &Proxy{
+			URL:    strings.TrimSuffix(u, "/"),
+			Client: http.DefaultClient,
+		}, nil
+	}
+	return nil, fmt.Errorf("no proxy URL found in %q", goproxy)
+}
+
+// get fetches a module path relative to the proxy URL, ex: "@v/list".
+func (p *Proxy) get(modulePath, path string) ([]byte, error) {
+	escaped, err := module.EscapePath(modulePath)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/%s/%s", p.URL, escaped, path)
+
+	resp, err := p.Client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status from %s: %s: %s", url, resp.Status, strings.TrimSpace(string(b)))
+	}
+	return b, nil
+}
+
+// List returns the known versions of a module, from "$module/@v/list".
+func (p *Proxy) List(modulePath string) ([]string, error) {
+	b, err := p.get(modulePath, "@v/list")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(b)), nil
+}
+
+// Info returns the metadata of a version of a module, from
+// "$module/@v/$version.info".
+func (p *Proxy) Info(modulePath, version string) (*VersionInfo, error) {
+	escaped, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	b, err := p.get(modulePath, "@v/"+escaped+".info")
+	if err != nil {
+		return nil, err
+	}
+	return parseInfo(b)
+}
+
+// Latest returns the metadata of the version the go command uses when no
+// listed version is suitable, from "$module/@latest".
+func (p *Proxy) Latest(modulePath string) (*VersionInfo, error) {
+	b, err := p.get(modulePath, "@latest")
+	if err != nil {
+		return nil, err
+	}
+	return parseInfo(b)
+}
+
+func parseInfo(b []byte) (*VersionInfo, error) {
+	info := new(VersionInfo)
+	if err := json.Unmarshal(b, info); err != nil {
+		return nil, err
+	}
+	if info.Version == "" {
+		return nil, errors.New("missing version in info")
+	}
+	return info, nil
+}
+
+// ModuleToRepo resolves a go module name to a Repo using the proxy. The
+// listed versions are used as the tags of the Repo, so they are ranked by
+// git.Repo.BestRefFor the same way tags are. The proxy has no notion of
+// branches, so the latest version stands in for the default branch, and is
+// required as is by Update. If git.DefaultCache is set, the result is served
+// from and stored to the cache.
+func (p *Proxy) ModuleToRepo(modulePath string) (*git.Repo, error) {
+	cache := git.DefaultCache
+	key := fmt.Sprintf("goproxy %s %s", p.URL, modulePath)
+
+	if cache != nil {
+		repo := new(git.Repo)
+		if cache.Get(key, repo) {
+			return repo, nil
+		}
+		if cache.Offline {
+			return nil, fmt.Errorf("unable to fetch %s from %s: %w", modulePath, p.URL, git.ErrOffline)
+		}
+	}
+
+	versions, err := p.List(modulePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list versions of %s: %w", modulePath, err)
+	}
+	latest, err := p.Latest(modulePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch latest version of %s: %w", modulePath, err)
+	}
+
+	repo := &git.Repo{
+		Ref:           modulePath,
+		DefaultBranch: latest.Version,
+		Tags:          versions,
+	}
+
+	if cache != nil {
+		// Caching is best effort, the cache dir may not be writable.
+		if err := cache.Put(key, repo); err != nil {
+			log.Printf("unable to cache %s: %v", modulePath, err)
+		}
+	}
+	return repo, nil
+}
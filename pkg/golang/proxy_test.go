@@ -0,0 +1,194 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package golang
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/google/go-cmp/cmp"
+
+	"knative.dev/test-infra/pkg/git"
+)
+
+// fakeProxy serves a module proxy for knative.dev/pkg and
+// github.com/Azure/go-autorest, to test path escaping.
+func fakeProxy() *httptest.Server {
+	files := map[string]string{
+		"/knative.dev/pkg/@v/list":               "v0.18.0\nv0.18.1\nv0.19.0\n",
+		"/knative.dev/pkg/@v/v0.19.0.info":       `{"Version":"v0.19.0","Time":"2020-11-10T12:30:00Z"}`,
+		"/knative.dev/pkg/@latest":               `{"Version":"v0.19.0","Time":"2020-11-10T12:30:00Z"}`,
+		"/github.com/!azure/go-autorest/@v/list": "",
+		"/github.com/!azure/go-autorest/@latest": `{"Version":"v0.0.0-20201110123000-0123456789ab","Time":"2020-11-10T12:30:00Z"}`,
+		"/example.com/bad-info/@v/list":          "v1.0.0\n",
+		"/example.com/bad-info/@latest":          `{"Time":"2020-11-10T12:30:00Z"}`,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.Error(w, "not found: "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+}
+
+func TestNewProxy(t *testing.T) {
+	tests := map[string]struct {
+		goproxy string
+		want    string
+		wantErr bool
+	}{
+		"single": {
+			goproxy: "https://proxy.golang.org",
+			want:    "https://proxy.golang.org",
+		},
+		"list": {
+			goproxy: "direct,https://proxy.example.com/,https://proxy.golang.org",
+			want:    "https://proxy.example.com",
+		},
+		"pipe, no scheme": {
+			goproxy: "off|proxy.example.com",
+			want:    "https://proxy.example.com",
+		},
+		"only direct": {
+			goproxy: "direct",
+			wantErr: true,
+		},
+		"empty": {
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := NewProxy(tt.goproxy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewProxy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && p.URL != tt.want {
+				t.Errorf("NewProxy() URL = %v, want %v", p.URL, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxy(t *testing.T) {
+	ts := fakeProxy()
+	defer ts.Close()
+
+	p, err := NewProxy(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := p.List("knative.dev/pkg")
+	if err != nil {
+		t.Fatal("unexpected List() error: ", err)
+	}
+	if diff := cmp.Diff([]string{"v0.18.0", "v0.18.1", "v0.19.0"}, versions); diff != "" {
+		t.Error("List() diff(-want,+got):\n", diff)
+	}
+
+	info, err := p.Info("knative.dev/pkg", "v0.19.0")
+	if err != nil {
+		t.Fatal("unexpected Info() error: ", err)
+	}
+	want := &VersionInfo{Version: "v0.19.0", Time: time.Date(2020, 11, 10, 12, 30, 0, 0, time.UTC)}
+	if diff := cmp.Diff(want, info); diff != "" {
+		t.Error("Info() diff(-want,+got):\n", diff)
+	}
+
+	if _, err := p.Info("knative.dev/pkg", "v9.9.9"); err == nil {
+		t.Error("expected Info() of an unknown version to fail")
+	}
+	if _, err := p.Latest("example.com/bad-info"); err == nil {
+		t.Error("expected Latest() without a version to fail")
+	}
+}
+
+func TestProxy_ModuleToRepo(t *testing.T) {
+	ts := fakeProxy()
+	defer ts.Close()
+
+	p, err := NewProxy(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		module      string
+		release     string
+		ruleset     git.RulesetType
+		want        string
+		wantRefType git.RefType
+		wantErr     bool
+	}{
+		"release": {
+			module:      "knative.dev/pkg",
+			release:     "0.18",
+			ruleset:     git.ReleaseRule,
+			want:        "knative.dev/pkg@v0.18.1",
+			wantRefType: git.ReleaseRef,
+		},
+		"no release, any": {
+			module:      "knative.dev/pkg",
+			release:     "0.20",
+			ruleset:     git.AnyRule,
+			want:        "knative.dev/pkg@v0.19.0",
+			wantRefType: git.DefaultBranchRef,
+		},
+		"no release, branch": {
+			module:      "knative.dev/pkg",
+			release:     "0.18",
+			ruleset:     git.ReleaseBranchRule,
+			want:        "knative.dev/pkg",
+			wantRefType: git.NoRef,
+		},
+		"escaped path, no versions": {
+			module:      "github.com/Azure/go-autorest",
+			release:     "0.1",
+			ruleset:     git.AnyRule,
+			want:        "github.com/Azure/go-autorest@v0.0.0-20201110123000-0123456789ab",
+			wantRefType: git.DefaultBranchRef,
+		},
+		"unknown module": {
+			module:  "example.com/nope",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			repo, err := p.ModuleToRepo(tt.module)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ModuleToRepo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, refType := repo.BestRefFor(semver.MustParse(tt.release+".0"), tt.ruleset)
+			if got != tt.want {
+				t.Errorf("BestRefFor() = %v, want %v", got, tt.want)
+			}
+			if refType != tt.wantRefType {
+				t.Errorf("BestRefFor() ref type = %v, want %v", refType, tt.wantRefType)
+			}
+		})
+	}
+}
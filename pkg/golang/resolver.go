@@ -0,0 +1,231 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package golang
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+	"golang.org/x/mod/module"
+	"gopkg.in/yaml.v2"
+
+	"knative.dev/test-infra/pkg/git"
+)
+
+// RefResolver resolves a go module to the git repo it is released from, with
+// the tags and branches of the repo.
+type RefResolver interface {
+	ModuleToRepo(module string) (*git.Repo, error)
+}
+
+// RefResolverFunc adapts a function to a RefResolver.
+type RefResolverFunc func(module string) (*git.Repo, error)
+
+// ModuleToRepo calls f(module).
+func (f RefResolverFunc) ModuleToRepo(module string) (*git.Repo, error) {
+	return f(module)
+}
+
+// GoImportResolver resolves modules with their go-import meta tag, and lists
+// the refs of the repo with git ls-remote. See ModuleToRepo.
+var GoImportResolver RefResolver = RefResolverFunc(ModuleToRepo)
+
+// LocalResolver resolves modules to local clones, laid out by import path
+// under Dir like GOPATH/src is, ex: "knative.dev/pkg" is resolved to the clone
+// at "$Dir/knative.dev/pkg". A nested module is resolved to the closest clone
+// holding it. Nothing is fetched, so the refs are as recent as the clones.
+type LocalResolver struct {
+	Dir string
+}
+
+// ModuleToRepo resolves a go module name to the refs of its local clone.
+func (l *LocalResolver) ModuleToRepo(modulePath string) (*git.Repo, error) {
+	prefix, _, ok := module.SplitPathVersion(modulePath)
+	if !ok {
+		prefix = modulePath
+	}
+	for p := prefix; p != "." && p != "/"; p = path.Dir(p) {
+		dir := filepath.Join(l.Dir, filepath.FromSlash(p))
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+			continue
+		}
+		repo, err := git.LocalRepo(modulePath, dir)
+		if err != nil {
+			return nil, err
+		}
+		repo.Dir = ModuleDir(modulePath, p)
+		return repo, nil
+	}
+	return nil, fmt.Errorf("no clone of %s found in %s", modulePath, l.Dir)
+}
+
+// GitHubClient lists the refs of a GitHub repo, ex: ghutil.GithubClient.
+type GitHubClient interface {
+	GetRepository(org, repo string) (*github.Repository, error)
+	ListBranches(org, repo string) ([]*github.Branch, error)
+	ListTags(org, repo string) ([]*github.RepositoryTag, error)
+}
+
+// GitHubResolver resolves modules with their go-import meta tag, like
+// GoImportResolver, but lists the refs of repos hosted on github.com with the
+// GitHub API instead of git, ex: where git traffic is blocked. If
+// git.DefaultCache is set, both the go import lookup and the refs are served
+// from and stored to the cache.
+type GitHubResolver struct {
+	Client GitHubClient
+
+	// metaImport is used to override moduleMetaImport in tests.
+	metaImport func(module string) (*MetaImport, error)
+}
+
+// NewGitHubResolver returns a GitHubResolver listing refs with client.
+func NewGitHubResolver(client GitHubClient) *GitHubResolver {
+	return &GitHubResolver{
+		Client:     client,
+		metaImport: moduleMetaImport,
+	}
+}
+
+// ModuleToRepo resolves a go module name to a GitHub repo.
+func (g *GitHubResolver) ModuleToRepo(module string) (*git.Repo, error) {
+	meta, err := g.metaImport(module)
+	if err != nil {
+		return nil, err
+	}
+	if meta.VCS != "git" {
+		return nil, fmt.Errorf("unknown VCS: %s", meta.VCS)
+	}
+	if host := repoRootHost(meta.RepoRoot); host != "github.com" {
+		return nil, fmt.Errorf("%s is not hosted on github.com: %s", module, meta.RepoRoot)
+	}
+	org, name, err := meta.OrgRepo()
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := g.getRepo(org, name)
+	if err != nil {
+		return nil, err
+	}
+	repo.Ref = module
+	repo.URL = meta.RepoRoot
+	repo.Dir = ModuleDir(module, meta.Prefix)
+	return repo, nil
+}
+
+// getRepo lists the refs of org/name, through git.DefaultCache if set.
+func (g *GitHubResolver) getRepo(org, name string) (*git.Repo, error) {
+	cache := git.DefaultCache
+	key := fmt.Sprintf("github %s/%s", org, name)
+
+	if cache != nil {
+		repo := new(git.Repo)
+		if cache.Get(key, repo) {
+			return repo, nil
+		}
+		if cache.Offline {
+			return nil, fmt.Errorf("unable to list refs of %s/%s: %w", org, name, git.ErrOffline)
+		}
+	}
+
+	gr, err := g.Client.GetRepository(org, name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get %s/%s: %w", org, name, err)
+	}
+	branches, err := g.Client.ListBranches(org, name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list branches of %s/%s: %w", org, name, err)
+	}
+	tags, err := g.Client.ListTags(org, name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list tags of %s/%s: %w", org, name, err)
+	}
+
+	repo := &git.Repo{DefaultBranch: gr.GetDefaultBranch()}
+	for _, b := range branches {
+		repo.Branches = append(repo.Branches, b.GetName())
+	}
+	for _, t := range tags {
+		repo.Tags = append(repo.Tags, t.GetName())
+	}
+
+	if cache != nil {
+		if err := cache.Put(key, repo); err != nil {
+			return nil, fmt.Errorf("unable to cache %s/%s: %w", org, name, err)
+		}
+	}
+	return repo, nil
+}
+
+// repoRootHost returns the host of a repo root URL, or of a scp-like repo
+// root, ex: "github.com" for both "https://github.com/knative/pkg" and
+// "git@github.com:knative/pkg.git".
+func repoRootHost(repoRoot string) string {
+	host := repoRoot
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+len("://"):]
+	}
+	if i := strings.IndexAny(host, "/:"); i >= 0 {
+		host = host[:i]
+	}
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	return host
+}
+
+// StaticResolver resolves modules to a fixed set of repos, keyed by module,
+// ex: fixtures in tests, or a snapshot of the refs to work from. Repos without
+// a Ref use the module.
+type StaticResolver map[string]*git.Repo
+
+// ModuleToRepo returns a copy of the repo of module.
+func (s StaticResolver) ModuleToRepo(module string) (*git.Repo, error) {
+	r, ok := s[module]
+	if !ok || r == nil {
+		return nil, fmt.Errorf("unknown module %s", module)
+	}
+	repo := *r
+	if repo.Ref == "" {
+		repo.Ref = module
+	}
+	return &repo, nil
+}
+
+// LoadStaticResolver reads a StaticResolver from a YAML or JSON file mapping
+// modules to their repos, ex:
+//
+//	knative.dev/pkg:
+//	  defaultBranch: main
+//	  branches: [main, release-0.19]
+//	  tags: [v0.19.0]
+func LoadStaticResolver(path string) (StaticResolver, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := make(StaticResolver)
+	if err := yaml.UnmarshalStrict(b, &s); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package golang
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+
+	"knative.dev/test-infra/pkg/git"
+)
+
+func TestLocalResolver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "localresolver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clone := filepath.Join(dir, "example.com", "foo")
+	r, err := gogit.PlainInit(clone, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := w.Commit("initial commit", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CreateTag("v0.1.0", head, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		module  string
+		want    *git.Repo
+		wantErr bool
+	}{
+		"root": {
+			module: "example.com/foo",
+			want: &git.Repo{
+				Ref:           "example.com/foo",
+				URL:           clone,
+				DefaultBranch: "master",
+				Tags:          []string{"v0.1.0"},
+				Branches:      []string{"master"},
+			},
+		},
+		"nested, major version": {
+			module: "example.com/foo/bar/v2",
+			want: &git.Repo{
+				Ref:           "example.com/foo/bar/v2",
+				URL:           clone,
+				DefaultBranch: "master",
+				Tags:          []string{"v0.1.0"},
+				Branches:      []string{"master"},
+				Dir:           "bar",
+			},
+		},
+		"no clone": {
+			module:  "example.com/foobar",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := (&LocalResolver{Dir: dir}).ModuleToRepo(tt.module)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ModuleToRepo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Error("ModuleToRepo() diff(-want,+got):\n", diff)
+			}
+		})
+	}
+}
+
+type fakeGitHub struct {
+	repos map[string]*github.Repository
+}
+
+func (f *fakeGitHub) GetRepository(org, repo string) (*github.Repository, error) {
+	if r, ok := f.repos[org+"/"+repo]; ok {
+		return r, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (f *fakeGitHub) ListBranches(org, repo string) ([]*github.Branch, error) {
+	return []*github.Branch{{Name: github.String("main")}, {Name: github.String("release-0.19")}}, nil
+}
+
+func (f *fakeGitHub) ListTags(org, repo string) ([]*github.RepositoryTag, error) {
+	return []*github.RepositoryTag{{Name: github.String("v0.19.0")}}, nil
+}
+
+func TestGitHubResolver(t *testing.T) {
+	metas := map[string]*MetaImport{
+		"knative.dev/pkg":         {Prefix: "knative.dev/pkg", VCS: "git", RepoRoot: "https://github.com/knative/pkg"},
+		"knative.dev/hack/schema": {Prefix: "knative.dev/hack", VCS: "git", RepoRoot: "git@github.com:knative/hack.git"},
+		"knative.dev/missing":     {Prefix: "knative.dev/missing", VCS: "git", RepoRoot: "https://github.com/knative/missing"},
+		"example.com/gitlab":      {Prefix: "example.com/gitlab", VCS: "git", RepoRoot: "https://gitlab.com/example/gitlab"},
+		"example.com/hg":          {Prefix: "example.com/hg", VCS: "hg", RepoRoot: "https://github.com/example/hg"},
+	}
+	g := NewGitHubResolver(&fakeGitHub{repos: map[string]*github.Repository{
+		"knative/pkg":  {DefaultBranch: github.String("main")},
+		"knative/hack": {DefaultBranch: github.String("main")},
+	}})
+	g.metaImport = func(module string) (*MetaImport, error) {
+		if m, ok := metas[module]; ok {
+			return m, nil
+		}
+		return nil, errors.New("no go-import")
+	}
+
+	tests := map[string]struct {
+		module  string
+		want    *git.Repo
+		wantErr bool
+	}{
+		"repo": {
+			module: "knative.dev/pkg",
+			want: &git.Repo{
+				Ref:           "knative.dev/pkg",
+				URL:           "https://github.com/knative/pkg",
+				DefaultBranch: "main",
+				Tags:          []string{"v0.19.0"},
+				Branches:      []string{"main", "release-0.19"},
+			},
+		},
+		"nested module, scp-like repo root": {
+			module: "knative.dev/hack/schema",
+			want: &git.Repo{
+				Ref:           "knative.dev/hack/schema",
+				URL:           "git@github.com:knative/hack.git",
+				DefaultBranch: "main",
+				Tags:          []string{"v0.19.0"},
+				Branches:      []string{"main", "release-0.19"},
+				Dir:           "schema",
+			},
+		},
+		"unknown repo": {
+			module:  "knative.dev/missing",
+			wantErr: true,
+		},
+		"not on github": {
+			module:  "example.com/gitlab",
+			wantErr: true,
+		},
+		"not git": {
+			module:  "example.com/hg",
+			wantErr: true,
+		},
+		"no go-import": {
+			module:  "example.com/nope",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := g.ModuleToRepo(tt.module)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ModuleToRepo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Error("ModuleToRepo() diff(-want,+got):\n", diff)
+			}
+		})
+	}
+}
+
+func TestLoadStaticResolver(t *testing.T) {
+	s, err := LoadStaticResolver("./testdata/refs.yaml")
+	if err != nil {
+		t.Fatal("LoadStaticResolver() =", err)
+	}
+
+	tests := map[string]struct {
+		module  string
+		want    *git.Repo
+		wantErr bool
+	}{
+		"ref defaults to the module": {
+			module: "knative.dev/pkg",
+			want: &git.Repo{
+				Ref:           "knative.dev/pkg",
+				DefaultBranch: "main",
+				Branches:      []string{"main", "release-0.19"},
+			},
+		},
+		"all fields": {
+			module: "knative.dev/hack/schema",
+			want: &git.Repo{
+				Ref:           "knative.dev/hack/schema",
+				URL:           "https://github.com/knative/hack",
+				DefaultBranch: "main",
+				Tags:          []string{"schema/v0.19.0"},
+				Dir:           "schema",
+			},
+		},
+		"unknown module": {
+			module:  "knative.dev/serving",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := s.ModuleToRepo(tt.module)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ModuleToRepo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Error("ModuleToRepo() diff(-want,+got):\n", diff)
+			}
+		})
+	}
+
+	if _, err := LoadStaticResolver("./testdata/nope.yaml"); err == nil {
+		t.Error("LoadStaticResolver() expected an error for a missing file")
+	}
+}
+
+func TestRepoRootHost(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/knative/pkg":   "github.com",
+		"git@github.com:knative/pkg.git":   "github.com",
+		"ssh://git@github.com/knative/pkg": "github.com",
+		"https://gitlab.com/a/b":           "gitlab.com",
+	}
+	for repoRoot, want := range tests {
+		t.Run(repoRoot, func(t *testing.T) {
+			if got := repoRootHost(repoRoot); got != want {
+				t.Errorf("repoRootHost() = %q, want %q", got, want)
+			}
+		})
+	}
+}
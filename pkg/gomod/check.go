@@ -17,6 +17,7 @@ limitations under the License.
 package gomod
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"strings"
@@ -24,67 +25,116 @@ import (
 	"github.com/blang/semver/v4"
 
 	"knative.dev/test-infra/pkg/git"
-	"knative.dev/test-infra/pkg/golang"
 )
 
 // Check examines a go mod file for dependencies and  determines if each have a release artifact
 // based on the ruleset provided. Check leverages the same rules used by
 // knative.dev/test-infra/pkg/git.Repo().BestRefFor
-func Check(gomod, release, domain string, ruleset git.RulesetType, out io.Writer) error {
-	modulePkgs, _, err := Modules([]string{gomod}, domain)
-	if err != nil {
-		return err
-	}
+// Dependencies replaced by a fork are checked using the repo of the fork, and
+// dependencies replaced by a local directory are reported as pinned locally.
+func Check(gomod, release, domain string, ruleset git.RulesetType, out io.Writer, opts ...Option) error {
+	return CheckContext(context.Background(), gomod, release, domain, ruleset, out, opts...)
+}
 
-	for module, packages := range modulePkgs {
-		if err := check(module, packages, release, ruleset, out); err != nil {
+// CheckContext is Check with a context. Dependencies are resolved
+// concurrently, and the output keeps the order of the dependencies. If some
+// dependencies fail to resolve, an aggregate of the errors is returned.
+func CheckContext(ctx context.Context, gomod, release, domain string, ruleset git.RulesetType, out io.Writer, opts ...Option) error {
+	statuses, err := CheckStatusContext(ctx, gomod, release, domain, ruleset, opts...)
+	for _, status := range statuses {
+		if out != nil {
+			printStatus(status, out)
+		}
+		if err := status.err(); err != nil {
 			return err
 		}
 	}
-	return nil
+	return err
 }
 
-func check(module string, packages []string, release string, ruleset git.RulesetType, out io.Writer) error {
+// CheckStatusContext is CheckContext, returning the status of the module
+// instead of writing it. The error is the one CheckContext returns.
+func CheckStatusContext(ctx context.Context, gomod, release, domain string, ruleset git.RulesetType, opts ...Option) ([]ModuleStatus, error) {
+	o := newOptions(opts)
+	mf, err := ReadGoMod(gomod, domain, opts...)
+	if err != nil {
+		return nil, err
+	}
+	o.replaces = mf.Replaces
+
 	this, err := semver.ParseTolerant(release)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if out != nil {
-		_, _ = fmt.Fprintln(out, module)
+	status := moduleStatus(ctx, mf, release, this, ruleset, o)
+	return []ModuleStatus{status}, status.err()
+}
+
+// moduleStatus selects the best ref for each dependency of mf, with the
+// replace directives of mf in o.
+func moduleStatus(ctx context.Context, mf *GoMod, release string, this semver.Version, ruleset git.RulesetType, o *options) ModuleStatus {
+	status := ModuleStatus{
+		Module:       mf.Module,
+		Release:      release,
+		Ruleset:      ruleset.String(),
+		Dependencies: dependencyStatuses(ctx, mf.Dependencies(), this, ruleset, o),
 	}
+	status.Ready = status.err() == nil
+	return status
+}
 
-	nonReady := make([]string, 0)
-	for _, pkg := range packages {
-		repo, err := golang.ModuleToRepo(pkg)
-		if err != nil {
-			return err
-		}
+// err returns an aggregate of the resolve errors of the dependencies if there
+// are any, otherwise an Error if some dependencies have no ref.
+func (s ModuleStatus) err() error {
+	if err := statusErrors(s.Dependencies); err != nil {
+		return err
+	}
 
-		ref, refType := repo.BestRefFor(this, ruleset)
-		switch refType {
-		case git.NoRef:
-			nonReady = append(nonReady, ref)
-			if out != nil {
-				_, _ = fmt.Fprintln(out, "✘ ", ref)
-			}
-		default:
-			if out != nil {
-				_, _ = fmt.Fprintln(out, "✔ ", ref)
-			}
+	nonReady := make([]string, 0)
+	for _, d := range s.Dependencies {
+		if !d.Ready {
+			nonReady = append(nonReady, d.Ref)
 		}
 	}
-
 	if len(nonReady) > 0 {
 		return &Error{
-			Module:       module,
+			Module:       s.Module,
 			Dependencies: nonReady,
 		}
 	}
-
 	return nil
 }
 
+func printStatus(status ModuleStatus, out io.Writer) {
+	_, _ = fmt.Fprintln(out, status.Module)
+
+	candidates := make([]string, 0)
+	for _, d := range status.Dependencies {
+		if d.Replace != nil && !d.Pinned {
+			// Show the dependency next to the ref of its fork.
+			d.Ref = d.Module + " => " + d.Ref
+		}
+		switch {
+		case d.Error != "":
+			_, _ = fmt.Fprintln(out, "✘ ", d.Module, d.Error)
+		case d.Pinned:
+			_, _ = fmt.Fprintln(out, "✔ ", d.Module, "=>", d.Replace, "(pinned locally)")
+		case !d.Ready:
+			_, _ = fmt.Fprintln(out, "✘ ", d.Ref)
+		case d.refType == git.ReleaseCandidateRef:
+			candidates = append(candidates, d.Ref)
+			_, _ = fmt.Fprintln(out, "✔ ", d.Ref, "(release candidate)")
+		default:
+			_, _ = fmt.Fprintln(out, "✔ ", d.Ref)
+		}
+	}
+
+	if len(candidates) > 0 {
+		_, _ = fmt.Fprintf(out, "%s depends on release candidates [%s]\n", status.Module, strings.Join(candidates, ", "))
+	}
+}
+
 // DependencyErr is a Dependency Error instance. For use with with error.Is.
 var DependencyErr = &Error{}
 
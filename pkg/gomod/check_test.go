@@ -17,14 +17,15 @@ limitations under the License.
 package gomod
 
 import (
+	"bytes"
 	"errors"
 	"os"
+	"strings"
 	"testing"
 
 	"knative.dev/test-infra/pkg/git"
 )
 
-// TestCheck - This is an integration test, it will make a call out to the internet.
 func TestCheck(t *testing.T) {
 	tests := map[string]struct {
 		gomod   string
@@ -101,7 +102,7 @@ func TestCheck(t *testing.T) {
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			err := Check(tt.gomod, tt.release, tt.domain, tt.rule, os.Stdout)
+			err := Check(tt.gomod, tt.release, tt.domain, tt.rule, os.Stdout, fixtureRefs(t))
 			if (tt.wantErr && err == nil) || (!tt.wantErr && err != nil) {
 				t.Errorf("unexpected error state, want error == %t, got %v", tt.wantErr, err)
 			}
@@ -109,6 +110,62 @@ func TestCheck(t *testing.T) {
 	}
 }
 
+func TestCheck_ReleaseCandidates(t *testing.T) {
+	repos := map[string]*git.Repo{
+		"knative.dev/eventing": {
+			Ref:      "knative.dev/eventing",
+			Tags:     []string{"v0.19.0", "v0.20.0-rc.1", "v0.20.0"},
+			Branches: []string{"release-0.19", "release-0.20"},
+		},
+		"knative.dev/pkg": {
+			Ref:      "knative.dev/pkg",
+			Tags:     []string{"v0.20.0-rc.1", "v0.20.0-rc.2"},
+			Branches: []string{"release-0.20"},
+		},
+		"knative.dev/test-infra": {
+			Ref:      "knative.dev/test-infra",
+			Branches: []string{"release-0.20"},
+		},
+	}
+
+	tests := map[string]struct {
+		rule    git.RulesetType
+		want    []string
+		wantErr bool
+	}{
+		"ReleaseOrCandidate": {
+			rule: git.ReleaseOrCandidateRule,
+			want: []string{
+				"✔  knative.dev/pkg@v0.20.0-rc.2 (release candidate)",
+				"✘  knative.dev/test-infra",
+			},
+			wantErr: true,
+		},
+		"ReleaseOrCandidateOrBranch": {
+			rule: git.ReleaseOrCandidateOrReleaseBranchRule,
+			want: []string{
+				"✔  knative.dev/eventing@v0.20.0\n",
+				"✔  knative.dev/test-infra@release-0.20",
+				"depends on release candidates [knative.dev/pkg@v0.20.0-rc.2]",
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := Check("./testdata/gomod.update1", "v0.20", "knative.dev", tt.rule, &out, fakeResolvers(repos))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("Check() output missing %q, got:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
+
 func TestError(t *testing.T) {
 	tests := map[string]struct {
 		err             error
@@ -0,0 +1,217 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"knative.dev/test-infra/pkg/git"
+)
+
+// CutResult is the outcome of cutting the release branch of a module.
+type CutResult string
+
+const (
+	// BranchExists - the release branch already exists, nothing was done.
+	BranchExists CutResult = "exists"
+	// BranchCut - the release branch was created.
+	BranchCut CutResult = "cut"
+	// BranchWouldCut - the release branch would be created, in a dry run.
+	BranchWouldCut CutResult = "would cut"
+	// CutBlocked - some dependencies have no release branch yet.
+	CutBlocked CutResult = "blocked"
+)
+
+// Cut is the result of cutting the release branch of a single module.
+type Cut struct {
+	// Module is the module, ex: "knative.dev/serving".
+	Module string `json:"module" yaml:"module"`
+	// Stage is the release stage of the module, starting at 1. Modules of a
+	// stage only depend on modules of earlier stages.
+	Stage int `json:"stage" yaml:"stage"`
+	// Branch is the release branch, ex: "release-0.19".
+	Branch string `json:"branch" yaml:"branch"`
+	// Result is the outcome of cutting the branch.
+	Result CutResult `json:"result" yaml:"result"`
+	// From is the default branch the release branch is cut from.
+	From string `json:"from,omitempty" yaml:"from,omitempty"`
+	// Commit is the commit the release branch was cut at.
+	Commit string `json:"commit,omitempty" yaml:"commit,omitempty"`
+	// Blocked lists the dependencies without a release branch.
+	Blocked []string `json:"blocked,omitempty" yaml:"blocked,omitempty"`
+}
+
+// CutReleaseBranches creates the release branch of release for each module of
+// the given go mod files, in release order. The branch of a module is cut from
+// the head of its default branch once each of its dependencies with the
+// prefix of domain has a release branch, based on the Branch ruleset.
+//
+// Modules whose release branch already exists are skipped, so an interrupted
+// run can be resumed by running it again. With dryRun, no branch is created,
+// and the modules that would be cut are considered to have a release branch
+// for their dependents.
+//
+// If some modules are blocked by their dependencies, the other modules are
+// still cut and an Error matching DependencyErr is returned for each blocked
+// module. Creating a branch failing stops at that module.
+func CutReleaseBranches(ctx context.Context, gomods []string, release, domain string, dryRun bool, opts ...Option) ([]Cut, error) {
+	o := newOptions(opts)
+	this, err := semver.ParseTolerant(release)
+	if err != nil {
+		return nil, err
+	}
+	branch := git.ReleaseBranchVersion(this)
+
+	mods := make(map[string]*GoMod, len(gomods))
+	modulePkgs := make(map[string][]string, len(gomods))
+	for _, gomod := range gomods {
+		mf, err := ReadGoMod(gomod, domain, opts...)
+		if err != nil {
+			return nil, err
+		}
+		mods[mf.Module] = mf
+		modulePkgs[mf.Module] = mf.Dependencies()
+	}
+	stages, err := NewGraph(modulePkgs).ReleaseOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	cuts := make([]Cut, 0, len(mods))
+	errs := make([]error, 0)
+	// branched holds the modules with a release branch, or that would have
+	// one in a dry run.
+	branched := sets.NewString()
+	stage := 0
+	for _, modules := range stages {
+		stageCut := false
+		for _, module := range modules {
+			mf, ok := mods[module]
+			if !ok {
+				// Only a dependency, its release branch is checked by its
+				// dependents.
+				continue
+			}
+			if !stageCut {
+				stage++
+				stageCut = true
+			}
+
+			c, err := cutOne(ctx, mf, this, branch, dryRun, branched, o)
+			c.Stage = stage
+			if c.Result != "" {
+				cuts = append(cuts, c)
+			}
+			if err != nil {
+				return cuts, err
+			}
+			if c.Result == CutBlocked {
+				errs = append(errs, &Error{Module: module, Dependencies: c.Blocked})
+				continue
+			}
+			branched.Insert(module)
+		}
+	}
+	return cuts, utilerrors.NewAggregate(errs)
+}
+
+// cutOne cuts the release branch of a single module.
+func cutOne(ctx context.Context, mf *GoMod, this semver.Version, branch string, dryRun bool, branched sets.String, o *options) (Cut, error) {
+	c := Cut{Module: mf.Module, Branch: branch}
+
+	repo, err := resolveOne(ctx, mf.Module, o)
+	if err != nil {
+		return c, err
+	}
+	c.From = repo.DefaultBranch
+	if _, refType := repo.BestRefFor(this, git.ReleaseBranchRule); refType == git.ReleaseBranchRef {
+		c.From = ""
+		c.Result = BranchExists
+		return c, nil
+	}
+
+	mo := *o
+	mo.replaces = mf.Replaces
+	status := moduleStatus(ctx, mf, this.String(), this, git.ReleaseBranchRule, &mo)
+	if err := statusErrors(status.Dependencies); err != nil {
+		return c, err
+	}
+	for _, d := range status.Dependencies {
+		if !d.Ready && !branched.Has(d.Module) {
+			c.Blocked = append(c.Blocked, d.Module)
+		}
+	}
+	if len(c.Blocked) > 0 {
+		c.Result = CutBlocked
+		return c, nil
+	}
+
+	if dryRun {
+		c.Result = BranchWouldCut
+		return c, nil
+	}
+	commit, err := o.createBranch(repo.URL, repo.DefaultBranch, branch)
+	if errors.Is(err, git.ErrBranchExists) {
+		// Created since the branches of the repo were listed, or cached.
+		c.From = ""
+		c.Result = BranchExists
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("unable to cut %s of %s: %w", branch, mf.Module, err)
+	}
+	c.Commit = commit.Hash
+	c.Result = BranchCut
+	return c, nil
+}
+
+// WriteCuts writes the result of cutting each release branch in a human
+// readable form, grouped by stage.
+func WriteCuts(out io.Writer, cuts []Cut) {
+	stage := 0
+	for _, c := range cuts {
+		if c.Stage != stage {
+			stage = c.Stage
+			_, _ = fmt.Fprintf(out, "stage %d\n", stage)
+		}
+		switch c.Result {
+		case BranchExists:
+			_, _ = fmt.Fprintf(out, "✔  %s %s (exists)\n", c.Module, c.Branch)
+		case BranchCut:
+			_, _ = fmt.Fprintf(out, "➜  %s %s (cut from %s at %s)\n", c.Module, c.Branch, c.From, shortHash(c.Commit))
+		case BranchWouldCut:
+			_, _ = fmt.Fprintf(out, "➜  %s %s (would cut from %s)\n", c.Module, c.Branch, c.From)
+		case CutBlocked:
+			_, _ = fmt.Fprintf(out, "✘  %s %s (blocked by %s)\n", c.Module, c.Branch, strings.Join(c.Blocked, ", "))
+		}
+	}
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
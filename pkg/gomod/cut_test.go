@@ -0,0 +1,278 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomod
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/google/go-cmp/cmp"
+
+	"knative.dev/test-infra/pkg/git"
+	"knative.dev/test-infra/pkg/golang"
+)
+
+var cutGomods = []string{"./testdata/gomod.cut-serving", "./testdata/gomod.cut-networking", "./testdata/gomod.cut-pkg"}
+
+// bareRemotes creates a local bare repo for each module, with a single commit
+// on master and the given extra branches. It returns the directory of each
+// remote, and a resolver of the modules to them.
+func bareRemotes(t *testing.T, dir string, branches map[string][]string) (map[string]string, Option) {
+	t.Helper()
+	remotes := make(map[string]string, len(branches))
+	for module, extra := range branches {
+		remote := filepath.Join(dir, filepath.Base(module)+".git")
+		if _, err := gogit.PlainInit(remote, true); err != nil {
+			t.Fatal(err)
+		}
+		work := filepath.Join(dir, filepath.Base(module))
+		r, err := gogit.PlainInit(work, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(work, "README.md"), []byte(module), 0644); err != nil {
+			t.Fatal(err)
+		}
+		w, err := r.Worktree()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Add("README.md"); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := w.Commit("initial commit", &gogit.CommitOptions{
+			Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range extra {
+			if err := r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(b), hash)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := r.CreateRemote(&config.RemoteConfig{Name: gogit.DefaultRemoteName, URLs: []string{remote}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Push(&gogit.PushOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		remotes[module] = remote
+	}
+
+	return remotes, func(o *options) {
+		o.resolver = golang.RefResolverFunc(func(module string) (*git.Repo, error) {
+			remote, ok := remotes[module]
+			if !ok {
+				return nil, fmt.Errorf("unknown module %s", module)
+			}
+			return git.GetRepo(module, remote)
+		})
+	}
+}
+
+func remoteHasBranch(t *testing.T, remote, branch string) bool {
+	t.Helper()
+	r, err := gogit.PlainOpen(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Reference(plumbing.NewBranchReferenceName(branch), false)
+	return err == nil
+}
+
+func TestCutReleaseBranches(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cut")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remotes, resolver := bareRemotes(t, dir, map[string][]string{
+		"knative.dev/hack":       {"release-0.19"},
+		"knative.dev/pkg":        nil,
+		"knative.dev/networking": nil,
+		"knative.dev/serving":    nil,
+	})
+
+	// A dry run cuts nothing.
+	got, err := CutReleaseBranches(context.Background(), cutGomods, "0.19", "knative.dev", true, resolver)
+	if err != nil {
+		t.Fatal("CutReleaseBranches() dry run =", err)
+	}
+	want := []Cut{
+		{Module: "knative.dev/pkg", Stage: 1, Branch: "release-0.19", Result: BranchWouldCut, From: "master"},
+		{Module: "knative.dev/networking", Stage: 2, Branch: "release-0.19", Result: BranchWouldCut, From: "master"},
+		{Module: "knative.dev/serving", Stage: 3, Branch: "release-0.19", Result: BranchWouldCut, From: "master"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("CutReleaseBranches() dry run diff(-want,+got):\n", diff)
+	}
+	for _, module := range []string{"knative.dev/pkg", "knative.dev/networking", "knative.dev/serving"} {
+		if remoteHasBranch(t, remotes[module], "release-0.19") {
+			t.Errorf("dry run cut the release branch of %s", module)
+		}
+	}
+
+	// Cut networking by hand, the run then resumes with the other modules.
+	if _, err := git.CreateBranch(remotes["knative.dev/networking"], "master", "release-0.19", nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err = CutReleaseBranches(context.Background(), cutGomods, "0.19", "knative.dev", false, resolver)
+	if err != nil {
+		t.Fatal("CutReleaseBranches() =", err)
+	}
+	for i := range got {
+		if got[i].Result == BranchCut && len(got[i].Commit) != 40 {
+			t.Errorf("CutReleaseBranches() %s commit = %q, want a hash", got[i].Module, got[i].Commit)
+		}
+		got[i].Commit = ""
+	}
+	want = []Cut{
+		{Module: "knative.dev/pkg", Stage: 1, Branch: "release-0.19", Result: BranchCut, From: "master"},
+		{Module: "knative.dev/networking", Stage: 2, Branch: "release-0.19", Result: BranchExists},
+		{Module: "knative.dev/serving", Stage: 3, Branch: "release-0.19", Result: BranchCut, From: "master"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("CutReleaseBranches() diff(-want,+got):\n", diff)
+	}
+	for _, module := range []string{"knative.dev/pkg", "knative.dev/networking", "knative.dev/serving"} {
+		if !remoteHasBranch(t, remotes[module], "release-0.19") {
+			t.Errorf("the release branch of %s was not cut", module)
+		}
+	}
+}
+
+func TestCutReleaseBranches_StaleCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cut")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remotes, resolver := bareRemotes(t, dir, map[string][]string{
+		"knative.dev/hack":       {"release-0.19"},
+		"knative.dev/pkg":        {"release-0.19"},
+		"knative.dev/networking": nil,
+		"knative.dev/serving":    nil,
+	})
+	// The branches of pkg are listed before its release branch was cut.
+	stale := func(o *options) {
+		resolver(o)
+		fresh := o.resolver
+		o.resolver = golang.RefResolverFunc(func(module string) (*git.Repo, error) {
+			repo, err := fresh.ModuleToRepo(module)
+			if err == nil && module == "knative.dev/pkg" {
+				repo.Branches = []string{"master"}
+			}
+			return repo, err
+		})
+	}
+	before, err := gogit.PlainOpen(remotes["knative.dev/pkg"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := before.Reference(plumbing.NewBranchReferenceName("release-0.19"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := CutReleaseBranches(context.Background(), cutGomods, "0.19", "knative.dev", false, stale)
+	if err != nil {
+		t.Fatal("CutReleaseBranches() =", err)
+	}
+	if got[0].Module != "knative.dev/pkg" || got[0].Result != BranchExists {
+		t.Errorf("CutReleaseBranches() pkg = %+v, want %s", got[0], BranchExists)
+	}
+	ref, err := before.Reference(plumbing.NewBranchReferenceName("release-0.19"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Hash() != want.Hash() {
+		t.Errorf("the existing release branch of pkg moved from %s to %s", want.Hash(), ref.Hash())
+	}
+}
+
+func TestCutReleaseBranches_Blocked(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cut")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	remotes, resolver := bareRemotes(t, dir, map[string][]string{
+		"knative.dev/hack":       nil,
+		"knative.dev/pkg":        nil,
+		"knative.dev/networking": nil,
+		"knative.dev/serving":    nil,
+	})
+
+	for _, dryRun := range []bool{true, false} {
+		t.Run(fmt.Sprint("dry run ", dryRun), func(t *testing.T) {
+			got, err := CutReleaseBranches(context.Background(), cutGomods, "0.19", "knative.dev", dryRun, resolver)
+			if !errors.Is(err, DependencyErr) {
+				t.Errorf("CutReleaseBranches() error = %v, want %v", err, DependencyErr)
+			}
+			want := []Cut{
+				{Module: "knative.dev/pkg", Stage: 1, Branch: "release-0.19", Result: CutBlocked, From: "master", Blocked: []string{"knative.dev/hack"}},
+				{Module: "knative.dev/networking", Stage: 2, Branch: "release-0.19", Result: CutBlocked, From: "master", Blocked: []string{"knative.dev/hack", "knative.dev/pkg"}},
+				{Module: "knative.dev/serving", Stage: 3, Branch: "release-0.19", Result: CutBlocked, From: "master", Blocked: []string{"knative.dev/networking", "knative.dev/pkg"}},
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Error("CutReleaseBranches() diff(-want,+got):\n", diff)
+			}
+		})
+	}
+	if remoteHasBranch(t, remotes["knative.dev/pkg"], "release-0.19") {
+		t.Error("a blocked release branch was cut")
+	}
+}
+
+func TestWriteCuts(t *testing.T) {
+	cuts := []Cut{
+		{Module: "knative.dev/pkg", Stage: 1, Branch: "release-0.19", Result: BranchExists},
+		{Module: "knative.dev/networking", Stage: 1, Branch: "release-0.19", Result: BranchCut, From: "main", Commit: "0123456789abcdef0123456789abcdef01234567"},
+		{Module: "knative.dev/serving", Stage: 2, Branch: "release-0.19", Result: BranchWouldCut, From: "main"},
+		{Module: "knative.dev/eventing", Stage: 2, Branch: "release-0.19", Result: CutBlocked, From: "main", Blocked: []string{"knative.dev/hack", "knative.dev/pkg"}},
+	}
+	var buf bytes.Buffer
+	WriteCuts(&buf, cuts)
+	want := strings.Join([]string{
+		"stage 1",
+		"✔  knative.dev/pkg release-0.19 (exists)",
+		"➜  knative.dev/networking release-0.19 (cut from main at 0123456789ab)",
+		"stage 2",
+		"➜  knative.dev/serving release-0.19 (would cut from main)",
+		"✘  knative.dev/eventing release-0.19 (blocked by knative.dev/hack, knative.dev/pkg)",
+		"",
+	}, "\n")
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Error("WriteCuts() diff(-want,+got):\n", diff)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomod
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+
+	"golang.org/x/mod/semver"
+
+	"knative.dev/test-infra/pkg/git"
+)
+
+// ChangeType is how the required version of a dependency changed.
+type ChangeType string
+
+const (
+	// Added - the dependency is only required by the new go mod file.
+	Added ChangeType = "added"
+	// Removed - the dependency is only required by the old go mod file.
+	Removed ChangeType = "removed"
+	// Upgraded - the new version is higher than the old version.
+	Upgraded ChangeType = "upgraded"
+	// Downgraded - the new version is lower than the old version.
+	Downgraded ChangeType = "downgraded"
+)
+
+// VersionKind classifies a required version.
+type VersionKind string
+
+const (
+	// TagKind - a tagged release or release candidate, ex: "v0.19.1".
+	TagKind VersionKind = "tag"
+	// ReleaseBranchKind - a pseudo-version of a commit after a release tag,
+	// ex: "v0.19.1-0.20201110123000-0123456789ab". With the release process
+	// of knative, such commits are on a release branch.
+	ReleaseBranchKind VersionKind = "release branch"
+	// PseudoVersionKind - a pseudo-version of a commit not after a release tag,
+	// ex: "v0.0.0-20201110123000-0123456789ab", usually the default branch.
+	PseudoVersionKind VersionKind = "pseudo-version"
+)
+
+// noBasePseudoVersionRE matches pseudo-versions without a base release tag.
+var noBasePseudoVersionRE = regexp.MustCompile(`^v[0-9]+\.0\.0-\d{14}-`)
+
+// Change is the difference in the required version of a dependency between
+// two go mod files.
+type Change struct {
+	Module     string      `json:"module" yaml:"module"`
+	Change     ChangeType  `json:"change" yaml:"change"`
+	OldVersion string      `json:"oldVersion,omitempty" yaml:"oldVersion,omitempty"`
+	OldKind    VersionKind `json:"oldKind,omitempty" yaml:"oldKind,omitempty"`
+	NewVersion string      `json:"newVersion,omitempty" yaml:"newVersion,omitempty"`
+	NewKind    VersionKind `json:"newKind,omitempty" yaml:"newKind,omitempty"`
+}
+
+// Diff compares the direct dependencies with the prefix of domain between the
+// contents of two go mod files. Unchanged dependencies are omitted, and the
+// changes are sorted by module.
+func Diff(oldName string, oldGomod []byte, newName string, newGomod []byte, domain string) ([]Change, error) {
+	oldMod, err := ParseGoMod(oldName, oldGomod, domain)
+	if err != nil {
+		return nil, err
+	}
+	newMod, err := ParseGoMod(newName, newGomod, domain)
+	if err != nil {
+		return nil, err
+	}
+	before, after := oldMod.Requires, newMod.Requires
+
+	changes := make([]Change, 0)
+	for module, oldVersion := range before {
+		c := Change{
+			Module:     module,
+			OldVersion: oldVersion,
+			OldKind:    Kind(oldVersion),
+		}
+		newVersion, ok := after[module]
+		if !ok {
+			c.Change = Removed
+			changes = append(changes, c)
+			continue
+		}
+
+		switch semver.Compare(oldVersion, newVersion) {
+		case 0:
+			continue
+		case -1:
+			c.Change = Upgraded
+		default:
+			c.Change = Downgraded
+		}
+		c.NewVersion = newVersion
+		c.NewKind = Kind(newVersion)
+		changes = append(changes, c)
+	}
+	for module, newVersion := range after {
+		if _, ok := before[module]; ok {
+			continue
+		}
+		changes = append(changes, Change{
+			Module:     module,
+			Change:     Added,
+			NewVersion: newVersion,
+			NewKind:    Kind(newVersion),
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Module < changes[j].Module
+	})
+	return changes, nil
+}
+
+// Kind classifies a required version.
+func Kind(version string) VersionKind {
+	if !git.IsPseudoVersion(version) {
+		return TagKind
+	}
+	if noBasePseudoVersionRE.MatchString(version) {
+		return PseudoVersionKind
+	}
+	return ReleaseBranchKind
+}
+
+// WriteChanges writes changes in a human readable form, one per line.
+func WriteChanges(out io.Writer, changes []Change) {
+	for _, c := range changes {
+		switch c.Change {
+		case Added:
+			_, _ = fmt.Fprintf(out, "+ %s %s (%s)\n", c.Module, c.NewVersion, c.NewKind)
+		case Removed:
+			_, _ = fmt.Fprintf(out, "- %s %s (%s)\n", c.Module, c.OldVersion, c.OldKind)
+		case Upgraded:
+			_, _ = fmt.Fprintf(out, "↑ %s %s (%s) → %s (%s)\n", c.Module, c.OldVersion, c.OldKind, c.NewVersion, c.NewKind)
+		case Downgraded:
+			_, _ = fmt.Fprintf(out, "↓ %s %s (%s) → %s (%s)\n", c.Module, c.OldVersion, c.OldKind, c.NewVersion, c.NewKind)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomod
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiff(t *testing.T) {
+	tests := map[string]struct {
+		old     string
+		new     string
+		domain  string
+		want    []Change
+		wantErr bool
+	}{
+		"knative.dev": {
+			old:    "testdata/gomod.diff-old",
+			new:    "testdata/gomod.diff-new",
+			domain: "knative.dev",
+			want: []Change{{
+				Module:     "knative.dev/eventing",
+				Change:     Downgraded,
+				OldVersion: "v0.19.0",
+				OldKind:    TagKind,
+				NewVersion: "v0.18.3",
+				NewKind:    TagKind,
+			}, {
+				Module:     "knative.dev/hack",
+				Change:     Added,
+				NewVersion: "v0.0.0-20201120192952-353db687ec5b",
+				NewKind:    PseudoVersionKind,
+			}, {
+				Module:     "knative.dev/networking",
+				Change:     Upgraded,
+				OldVersion: "v0.0.0-20201110123000-0123456789ab",
+				OldKind:    PseudoVersionKind,
+				NewVersion: "v0.19.0-rc.1",
+				NewKind:    TagKind,
+			}, {
+				Module:     "knative.dev/pkg",
+				Change:     Downgraded,
+				OldVersion: "v0.19.1-0.20201110123000-0123456789ab",
+				OldKind:    ReleaseBranchKind,
+				NewVersion: "v0.0.0-20201120183152-a6a4f25ad8c7",
+				NewKind:    PseudoVersionKind,
+			}, {
+				Module:     "knative.dev/test-infra",
+				Change:     Removed,
+				OldVersion: "v0.0.0-20200921012245-37f1a12adbd3",
+				OldKind:    PseudoVersionKind,
+			}},
+		},
+		"github.com": {
+			old:    "testdata/gomod.diff-old",
+			new:    "testdata/gomod.diff-new",
+			domain: "github.com",
+			want: []Change{{
+				Module:     "github.com/google/go-cmp",
+				Change:     Upgraded,
+				OldVersion: "v0.5.2",
+				OldKind:    TagKind,
+				NewVersion: "v0.5.4",
+				NewKind:    TagKind,
+			}},
+		},
+		"no changes": {
+			old:    "testdata/gomod.diff-old",
+			new:    "testdata/gomod.diff-old",
+			domain: "knative.dev",
+			want:   []Change{},
+		},
+		"bad new": {
+			old:     "testdata/gomod.diff-old",
+			new:     "testdata/bad.example",
+			domain:  "knative.dev",
+			wantErr: true,
+		},
+		"no domain": {
+			old:     "testdata/gomod.diff-old",
+			new:     "testdata/gomod.diff-new",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			before, err := ioutil.ReadFile(tt.old)
+			if err != nil {
+				t.Fatal(err)
+			}
+			after, err := ioutil.ReadFile(tt.new)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Diff(tt.old, before, tt.new, after, tt.domain)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Diff() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Error("Diff() diff(-want,+got):\n", diff)
+			}
+		})
+	}
+}
+
+func TestWriteChanges(t *testing.T) {
+	changes := []Change{{
+		Module:     "knative.dev/hack",
+		Change:     Added,
+		NewVersion: "v0.19.0",
+		NewKind:    TagKind,
+	}, {
+		Module:     "knative.dev/pkg",
+		Change:     Upgraded,
+		OldVersion: "v0.0.0-20201110123000-0123456789ab",
+		OldKind:    PseudoVersionKind,
+		NewVersion: "v0.19.0",
+		NewKind:    TagKind,
+	}, {
+		Module:     "knative.dev/test-infra",
+		Change:     Removed,
+		OldVersion: "v0.19.0",
+		OldKind:    TagKind,
+	}}
+	want := `+ knative.dev/hack v0.19.0 (tag)
+↑ knative.dev/pkg v0.0.0-20201110123000-0123456789ab (pseudo-version) → v0.19.0 (tag)
+- knative.dev/test-infra v0.19.0 (tag)
+`
+
+	var out bytes.Buffer
+	WriteChanges(&out, changes)
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Error("WriteChanges() diff(-want,+got):\n", diff)
+	}
+}
@@ -17,10 +17,11 @@ limitations under the License.
 package gomod
 
 import (
+	"context"
+
 	"github.com/blang/semver/v4"
 
 	"knative.dev/test-infra/pkg/git"
-	"knative.dev/test-infra/pkg/golang"
 )
 
 // Float examines a go mod file for dependencies and then discovers the best
@@ -28,27 +29,49 @@ import (
 // Returns the set of module refs that were found. If no ref is found for a
 // dependency, Float omits that ref from the returned list. Float leverages
 // the same rules used by knative.dev/test-infra/pkg/git.Repo().BestRefFor
-func Float(gomod, release, domain string, ruleset git.RulesetType) ([]string, error) {
-	_, packages, err := Modules([]string{gomod}, domain)
+// Dependencies replaced by a fork or by a local directory are omitted, as the
+// ref of a fork is not a version of the required module; FloatStatusContext
+// reports the ref of the fork.
+func Float(gomod, release, domain string, ruleset git.RulesetType, opts ...Option) ([]string, error) {
+	return FloatContext(context.Background(), gomod, release, domain, ruleset, opts...)
+}
+
+// FloatContext is Float with a context. Dependencies are resolved
+// concurrently, and the returned refs keep the order of the dependencies. If
+// some dependencies fail to resolve, the refs found for the others are
+// returned with an aggregate of the errors.
+func FloatContext(ctx context.Context, gomod, release, domain string, ruleset git.RulesetType, opts ...Option) ([]string, error) {
+	statuses, err := FloatStatusContext(ctx, gomod, release, domain, ruleset, opts...)
+	if statuses == nil {
+		return nil, err
+	}
+
+	refs := make([]string, 0)
+	for _, s := range statuses {
+		if s.Ready && s.Replace == nil {
+			refs = append(refs, s.Ref)
+		}
+	}
+	return refs, err
+}
+
+// FloatStatusContext is FloatContext, returning the status of every
+// dependency instead of only the refs found. Dependencies without a ref are
+// not Ready. If some dependencies fail to resolve, the statuses are returned
+// with an aggregate of the errors.
+func FloatStatusContext(ctx context.Context, gomod, release, domain string, ruleset git.RulesetType, opts ...Option) ([]DependencyStatus, error) {
+	o := newOptions(opts)
+	mf, err := ReadGoMod(gomod, domain, opts...)
 	if err != nil {
 		return nil, err
 	}
+	o.replaces = mf.Replaces
 
 	this, err := semver.ParseTolerant(release)
 	if err != nil {
 		return nil, err
 	}
 
-	refs := make([]string, 0)
-	for _, pkg := range packages {
-		repo, err := golang.ModuleToRepo(pkg)
-		if err != nil {
-			return nil, err
-		}
-
-		if ref, refType := repo.BestRefFor(this, ruleset); refType != git.NoRef {
-			refs = append(refs, ref)
-		}
-	}
-	return refs, nil
+	statuses := dependencyStatuses(ctx, mf.Dependencies(), this, ruleset, o)
+	return statuses, statusErrors(statuses)
 }
@@ -22,7 +22,6 @@ import (
 	"knative.dev/test-infra/pkg/git"
 )
 
-// TestFloat - This is an integration test, it will make a call out to the internet.
 func TestFloat(t *testing.T) {
 	tests := map[string]struct {
 		gomod   string
@@ -100,7 +99,7 @@ func TestFloat(t *testing.T) {
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			deps, err := Float(tt.gomod, tt.release, tt.domain, tt.rule)
+			deps, err := Float(tt.gomod, tt.release, tt.domain, tt.rule, fixtureRefs(t))
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -118,7 +117,6 @@ func TestFloat(t *testing.T) {
 	}
 }
 
-// TestFloatUnhappy - This is an integration test, it will make a call out to the internet.
 func TestFloatUnhappy(t *testing.T) {
 	tests := map[string]struct {
 		gomod   string
@@ -147,7 +145,7 @@ func TestFloatUnhappy(t *testing.T) {
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			_, err := Float(tt.gomod, tt.release, tt.domain, tt.rule)
+			_, err := Float(tt.gomod, tt.release, tt.domain, tt.rule, fixtureRefs(t))
 			if err == nil {
 				t.Error("Expected an error")
 			}
@@ -0,0 +1,243 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomod
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Graph is a directed dependency graph between go modules. An edge from A to
+// B means module A depends on module B.
+type Graph struct {
+	// Modules holds every module known to the graph, sorted.
+	Modules []string `json:"modules" yaml:"modules"`
+	// Dependencies maps a module to its sorted direct dependencies.
+	Dependencies map[string][]string `json:"dependencies" yaml:"dependencies"`
+}
+
+// NewGraph builds a Graph from the module to dependencies map returned by
+// Modules. Dependencies that were not themselves given as modules are added
+// to the graph as leaves.
+func NewGraph(modulePkgs map[string][]string) *Graph {
+	all := sets.NewString()
+	deps := make(map[string][]string, len(modulePkgs))
+	for module, pkgs := range modulePkgs {
+		all.Insert(module)
+		all.Insert(pkgs...)
+		deps[module] = sets.NewString(pkgs...).List()
+	}
+	for _, module := range all.List() {
+		if _, found := deps[module]; !found {
+			deps[module] = []string{}
+		}
+	}
+	return &Graph{
+		Modules:      all.List(),
+		Dependencies: deps,
+	}
+}
+
+// ModuleGraph reads the given go mod files and builds the dependency graph
+// between them, filtered to dependencies that match domain.
+func ModuleGraph(gomod []string, domain string) (*Graph, error) {
+	modulePkgs, _, err := Modules(gomod, domain)
+	if err != nil {
+		return nil, err
+	}
+	return NewGraph(modulePkgs), nil
+}
+
+// Cycles returns each set of modules that depend on one another, directly or
+// transitively. A module that depends on itself is reported as a cycle of one.
+// Each cycle is sorted, and cycles are ordered by their first module.
+func (g *Graph) Cycles() [][]string {
+	// Tarjan's strongly connected components.
+	var (
+		index   = 0
+		indices = make(map[string]int, len(g.Modules))
+		lowlink = make(map[string]int, len(g.Modules))
+		onStack = sets.NewString()
+		stack   = make([]string, 0)
+		cycles  = make([][]string, 0)
+	)
+
+	var connect func(string)
+	connect = func(module string) {
+		indices[module] = index
+		lowlink[module] = index
+		index++
+		stack = append(stack, module)
+		onStack.Insert(module)
+
+		for _, dep := range g.Dependencies[module] {
+			if _, visited := indices[dep]; !visited {
+				connect(dep)
+				if lowlink[dep] < lowlink[module] {
+					lowlink[module] = lowlink[dep]
+				}
+			} else if onStack.Has(dep) && indices[dep] < lowlink[module] {
+				lowlink[module] = indices[dep]
+			}
+		}
+
+		if lowlink[module] != indices[module] {
+			return
+		}
+
+		component := sets.NewString()
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack.Delete(top)
+			component.Insert(top)
+			if top == module {
+				break
+			}
+		}
+		if component.Len() > 1 || g.dependsOn(module, module) {
+			cycles = append(cycles, component.List())
+		}
+	}
+
+	for _, module := range g.Modules {
+		if _, visited := indices[module]; !visited {
+			connect(module)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+func (g *Graph) dependsOn(module, dep string) bool {
+	for _, d := range g.Dependencies[module] {
+		if d == dep {
+			return true
+		}
+	}
+	return false
+}
+
+// ReleaseOrder returns the modules grouped into stages. Every module in a
+// stage only depends on modules from earlier stages, so the modules of one
+// stage can be released together once the previous stages are done. Modules
+// within a stage are sorted. An error is returned if the graph has cycles.
+func (g *Graph) ReleaseOrder() ([][]string, error) {
+	if cycles := g.Cycles(); len(cycles) > 0 {
+		return nil, &CycleError{Cycles: cycles}
+	}
+
+	remaining := make(map[string]int, len(g.Modules))
+	dependents := make(map[string][]string, len(g.Modules))
+	for _, module := range g.Modules {
+		remaining[module] = len(g.Dependencies[module])
+		for _, dep := range g.Dependencies[module] {
+			dependents[dep] = append(dependents[dep], module)
+		}
+	}
+
+	stages := make([][]string, 0)
+	ready := make([]string, 0)
+	for _, module := range g.Modules {
+		if remaining[module] == 0 {
+			ready = append(ready, module)
+		}
+	}
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		stages = append(stages, ready)
+
+		next := make([]string, 0)
+		for _, module := range ready {
+			for _, dependent := range dependents[module] {
+				remaining[dependent]--
+				if remaining[dependent] == 0 {
+					next = append(next, dependent)
+				}
+			}
+		}
+		ready = next
+	}
+	return stages, nil
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language.
+func (g *Graph) WriteDOT(out io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph modules {\n")
+	for _, module := range g.Modules {
+		fmt.Fprintf(&b, "  %q;\n", module)
+	}
+	for _, module := range g.Modules {
+		for _, dep := range g.Dependencies[module] {
+			fmt.Fprintf(&b, "  %q -> %q;\n", module, dep)
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// GraphReport is a Graph with any cycles found and, if the graph is acyclic,
+// the release order.
+type GraphReport struct {
+	Graph        `yaml:",inline"`
+	Cycles       [][]string `json:"cycles,omitempty" yaml:"cycles,omitempty"`
+	ReleaseOrder [][]string `json:"releaseOrder,omitempty" yaml:"releaseOrder,omitempty"`
+}
+
+// Report returns the GraphReport of the graph.
+func (g *Graph) Report() *GraphReport {
+	report := &GraphReport{
+		Graph:  *g,
+		Cycles: g.Cycles(),
+	}
+	if len(report.Cycles) == 0 {
+		report.ReleaseOrder, _ = g.ReleaseOrder() // Cannot fail without cycles.
+	}
+	return report
+}
+
+// CycleErr is a CycleError instance. For use with with error.Is.
+var CycleErr = &CycleError{}
+
+// CycleError holds the cycles that prevent a graph from being ordered.
+type CycleError struct {
+	Cycles [][]string
+}
+
+var _ error = (*CycleError)(nil)
+
+// Is implements error.Is(target)
+func (e *CycleError) Is(target error) bool {
+	_, is := target.(*CycleError)
+	return is
+}
+
+// Error implements error.Error()
+func (e *CycleError) Error() string {
+	cycles := make([]string, 0, len(e.Cycles))
+	for _, c := range e.Cycles {
+		cycles = append(cycles, "["+strings.Join(c, ", ")+"]")
+	}
+	return fmt.Sprintf("dependency cycles found: %s", strings.Join(cycles, ", "))
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomod
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestModuleGraph(t *testing.T) {
+	tests := map[string]struct {
+		files      []string
+		domain     string
+		wantGraph  *Graph
+		wantCycles [][]string
+		wantOrder  [][]string
+		wantErr    bool
+	}{
+		"serving, networking, pkg": {
+			files:  []string{"testdata/gomod.graph-serving", "testdata/gomod.graph-networking", "testdata/gomod.graph-pkg"},
+			domain: "knative.dev",
+			wantGraph: &Graph{
+				Modules: []string{"knative.dev/caching", "knative.dev/networking", "knative.dev/pkg", "knative.dev/serving", "knative.dev/test-infra"},
+				Dependencies: map[string][]string{
+					"knative.dev/caching":    {},
+					"knative.dev/networking": {"knative.dev/pkg", "knative.dev/test-infra"},
+					"knative.dev/pkg":        {"knative.dev/test-infra"},
+					"knative.dev/serving":    {"knative.dev/caching", "knative.dev/networking", "knative.dev/pkg", "knative.dev/test-infra"},
+					"knative.dev/test-infra": {},
+				},
+			},
+			wantCycles: [][]string{},
+			wantOrder: [][]string{
+				{"knative.dev/caching", "knative.dev/test-infra"},
+				{"knative.dev/pkg"},
+				{"knative.dev/networking"},
+				{"knative.dev/serving"},
+			},
+		},
+		"example1, example2, knative.dev": {
+			files:  []string{"testdata/gomod.example1", "testdata/gomod.example2"},
+			domain: "knative.dev",
+			wantGraph: &Graph{
+				Modules: []string{"knative.dev/discovery", "knative.dev/eventing", "knative.dev/pkg", "knative.dev/serving", "knative.dev/test-demo1", "knative.dev/test-demo2", "knative.dev/test-infra"},
+				Dependencies: map[string][]string{
+					"knative.dev/discovery":  {},
+					"knative.dev/eventing":   {},
+					"knative.dev/pkg":        {},
+					"knative.dev/serving":    {},
+					"knative.dev/test-demo1": {"knative.dev/eventing", "knative.dev/pkg", "knative.dev/serving", "knative.dev/test-infra"},
+					"knative.dev/test-demo2": {"knative.dev/discovery", "knative.dev/pkg", "knative.dev/test-infra"},
+					"knative.dev/test-infra": {},
+				},
+			},
+			wantCycles: [][]string{},
+			wantOrder: [][]string{
+				{"knative.dev/discovery", "knative.dev/eventing", "knative.dev/pkg", "knative.dev/serving", "knative.dev/test-infra"},
+				{"knative.dev/test-demo1", "knative.dev/test-demo2"},
+			},
+		},
+		"cycle": {
+			files:  []string{"testdata/gomod.graph-pkg", "testdata/gomod.graph-cycle"},
+			domain: "knative.dev",
+			wantGraph: &Graph{
+				Modules: []string{"knative.dev/pkg", "knative.dev/test-infra"},
+				Dependencies: map[string][]string{
+					"knative.dev/pkg":        {"knative.dev/test-infra"},
+					"knative.dev/test-infra": {"knative.dev/pkg"},
+				},
+			},
+			wantCycles: [][]string{{"knative.dev/pkg", "knative.dev/test-infra"}},
+		},
+		"bad example": {
+			files:   []string{"testdata/gomod.example1", "testdata/bad.example"},
+			domain:  "knative.dev",
+			wantErr: true,
+		},
+		"no file": {
+			domain:  "knative.dev",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			graph, err := ModuleGraph(tt.files, tt.domain)
+			if (tt.wantErr && err == nil) || (!tt.wantErr && err != nil) {
+				t.Errorf("unexpected error state, want error == %t, got %v", tt.wantErr, err)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.wantGraph, graph); diff != "" {
+				t.Error("ModuleGraph() diff(-want,+got):\n", diff)
+			}
+			if diff := cmp.Diff(tt.wantCycles, graph.Cycles()); diff != "" {
+				t.Error("Cycles() diff(-want,+got):\n", diff)
+			}
+
+			order, err := graph.ReleaseOrder()
+			if len(tt.wantCycles) > 0 {
+				if !errors.Is(err, CycleErr) {
+					t.Error("expected ReleaseOrder() to return a CycleError, got ", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("unexpected ReleaseOrder() error: ", err)
+			}
+			if diff := cmp.Diff(tt.wantOrder, order); diff != "" {
+				t.Error("ReleaseOrder() diff(-want,+got):\n", diff)
+			}
+		})
+	}
+}
+
+func TestGraph_SelfCycle(t *testing.T) {
+	graph := NewGraph(map[string][]string{
+		"a": {"a", "b"},
+		"b": {},
+	})
+	want := [][]string{{"a"}}
+	if diff := cmp.Diff(want, graph.Cycles()); diff != "" {
+		t.Error("Cycles() diff(-want,+got):\n", diff)
+	}
+}
+
+func TestGraph_WriteDOT(t *testing.T) {
+	graph := NewGraph(map[string][]string{
+		"a": {"b", "c"},
+		"b": {"c"},
+	})
+	want := `digraph modules {
+  "a";
+  "b";
+  "c";
+  "a" -> "b";
+  "a" -> "c";
+  "b" -> "c";
+}
+`
+	var out bytes.Buffer
+	if err := graph.WriteDOT(&out); err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Error("WriteDOT() diff(-want,+got):\n", diff)
+	}
+}
+
+func TestGraph_Report(t *testing.T) {
+	tests := map[string]struct {
+		graph      *Graph
+		wantCycles [][]string
+		wantOrder  [][]string
+	}{
+		"acyclic": {
+			graph:     NewGraph(map[string][]string{"a": {"b"}}),
+			wantOrder: [][]string{{"b"}, {"a"}},
+		},
+		"cyclic": {
+			graph:      NewGraph(map[string][]string{"a": {"b"}, "b": {"a"}}),
+			wantCycles: [][]string{{"a", "b"}},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			out, err := json.Marshal(tt.graph.Report())
+			if err != nil {
+				t.Fatal("unexpected error: ", err)
+			}
+			var got struct {
+				Modules      []string   `json:"modules"`
+				Cycles       [][]string `json:"cycles"`
+				ReleaseOrder [][]string `json:"releaseOrder"`
+			}
+			if err := json.Unmarshal(out, &got); err != nil {
+				t.Fatal("failed to parse json: ", err)
+			}
+			if diff := cmp.Diff(tt.graph.Modules, got.Modules); diff != "" {
+				t.Error("modules diff(-want,+got):\n", diff)
+			}
+			if diff := cmp.Diff(tt.wantCycles, got.Cycles); diff != "" {
+				t.Error("cycles diff(-want,+got):\n", diff)
+			}
+			if diff := cmp.Diff(tt.wantOrder, got.ReleaseOrder); diff != "" {
+				t.Error("releaseOrder diff(-want,+got):\n", diff)
+			}
+		})
+	}
+}
+
+func TestCycleError_Error(t *testing.T) {
+	err := &CycleError{Cycles: [][]string{{"a", "b"}, {"c"}}}
+	if want := "dependency cycles found: [a, b], [c]"; err.Error() != want {
+		t.Errorf("err.Error() = %v, want %v", err.Error(), want)
+	}
+}
@@ -26,8 +26,9 @@ import (
 )
 
 // Modules returns a map of given given modules to their direct dependencies,
-// and a list of unique dependencies.
-func Modules(gomod []string, domain string) (map[string][]string, []string, error) {
+// and a list of unique dependencies. With WithIndirect, indirect dependencies
+// are included.
+func Modules(gomod []string, domain string, opts ...Option) (map[string][]string, []string, error) {
 	if len(gomod) == 0 {
 		return nil, nil, errors.New("no go module files provided")
 	}
@@ -35,7 +36,7 @@ func Modules(gomod []string, domain string) (map[string][]string, []string, erro
 	packages := make(map[string][]string, 1)
 	cache := make(sets.String, 1)
 	for _, gm := range gomod {
-		name, pkgs, err := Module(gm, domain)
+		name, pkgs, err := Module(gm, domain, opts...)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -52,34 +53,112 @@ func Modules(gomod []string, domain string) (map[string][]string, []string, erro
 }
 
 // Module returns the name and a list of direct dependencies for a given module.
+// With WithIndirect, indirect dependencies are included.
 // TODO: support url and gopath at some point for the gomod string.
-func Module(gomod string, domain string) (string, []string, error) {
-	domain = strings.TrimSpace(domain)
-	if len(domain) == 0 {
-		return "", nil, errors.New("no domain provided")
+func Module(gomod string, domain string, opts ...Option) (string, []string, error) {
+	mf, err := ReadGoMod(gomod, domain, opts...)
+	if err != nil {
+		return "", nil, err
+	}
+	return mf.Module, mf.Dependencies(), nil
+}
+
+// GoMod holds the requirements of a go mod file that have the prefix of a
+// domain, with the replace directives that apply to them.
+type GoMod struct {
+	// Module is the name of the module.
+	Module string
+	// Requires maps each dependency to its required version.
+	Requires map[string]string
+	// Replaces maps each replaced dependency to its replacement.
+	Replaces map[string]Replacement
+}
+
+// Replacement is the target of a replace directive.
+type Replacement struct {
+	// Path is the module path of a fork, or a local directory.
+	Path string `json:"path" yaml:"path"`
+	// Version is the version of the fork, empty for a local directory.
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// Local returns true if the replacement is a local directory, which pins the
+// dependency locally.
+func (r Replacement) Local() bool {
+	// The go command requires a version for module paths, and none for
+	// directories.
+	return r.Version == ""
+}
+
+// String returns the replacement as written in go.mod.
+func (r Replacement) String() string {
+	if r.Local() {
+		return r.Path
+	}
+	return r.Path + " " + r.Version
+}
+
+// Dependencies returns the sorted list of dependencies.
+func (m *GoMod) Dependencies() []string {
+	packages := sets.NewString()
+	for pkg := range m.Requires {
+		packages.Insert(pkg)
 	}
+	return packages.List()
+}
 
+// ReadGoMod reads and parses a go mod file with ParseGoMod.
+func ReadGoMod(gomod, domain string, opts ...Option) (*GoMod, error) {
 	b, err := ioutil.ReadFile(gomod)
 	if err != nil {
-		return "", nil, err
+		return nil, err
+	}
+	return ParseGoMod(gomod, b, domain, opts...)
+}
+
+// ParseGoMod parses the contents b of a go mod file for the dependencies that
+// have the prefix of domain. Only direct dependencies are included, unless
+// WithIndirect is given. A replace directive applies to a dependency if it
+// replaces all versions of it, or the required version. file is only used
+// for error messages.
+func ParseGoMod(file string, b []byte, domain string, opts ...Option) (*GoMod, error) {
+	domain = strings.TrimSpace(domain)
+	if len(domain) == 0 {
+		return nil, errors.New("no domain provided")
 	}
 
-	file, err := modfile.Parse(gomod, b /*VersionFixer func*/, nil)
+	mf, err := modfile.Parse(file, b /*VersionFixer func*/, nil)
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
 
-	packages := sets.NewString()
-	for _, r := range file.Require {
-		// Do not include indirect dependencies.
-		if r.Indirect {
+	o := newOptions(opts)
+	gm := &GoMod{
+		Module:   mf.Module.Mod.Path,
+		Requires: make(map[string]string),
+		Replaces: make(map[string]Replacement),
+	}
+	for _, r := range mf.Require {
+		// Do not include indirect dependencies, unless asked to.
+		if r.Indirect && !o.indirect {
 			continue
 		}
 		// Look for requirements that have the prefix of domain.
-		if strings.HasPrefix(r.Mod.Path, domain) && !packages.Has(r.Mod.Path) {
-			packages.Insert(r.Mod.Path)
+		if _, ok := gm.Requires[r.Mod.Path]; strings.HasPrefix(r.Mod.Path, domain) && !ok {
+			gm.Requires[r.Mod.Path] = r.Mod.Version
+		}
+	}
+	for _, r := range mf.Replace {
+		version, ok := gm.Requires[r.Old.Path]
+		if !ok || (r.Old.Version != "" && r.Old.Version != version) {
+			continue
+		}
+		// A replacement of the required version wins over one of all versions.
+		if _, ok := gm.Replaces[r.Old.Path]; ok && r.Old.Version == "" {
+			continue
 		}
+		gm.Replaces[r.Old.Path] = Replacement{Path: r.New.Path, Version: r.New.Version}
 	}
 
-	return file.Module.Mod.Path, packages.List(), nil
+	return gm, nil
 }
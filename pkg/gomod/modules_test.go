@@ -167,3 +167,71 @@ func TestModules(t *testing.T) {
 		})
 	}
 }
+
+func TestParseGoMod(t *testing.T) {
+	tests := map[string]struct {
+		file    string
+		opts    []Option
+		want    *GoMod
+		wantErr bool
+	}{
+		"direct": {
+			file: "testdata/gomod.replace1",
+			want: &GoMod{
+				Module: "knative.dev/test-demo1",
+				Requires: map[string]string{
+					"knative.dev/eventing":   "v0.18.0",
+					"knative.dev/pkg":        "v0.0.0-20200922164940-4bf40ad82aab",
+					"knative.dev/test-infra": "v0.0.0-20200921012245-37f1a12adbd3",
+				},
+				Replaces: map[string]Replacement{
+					"knative.dev/eventing": {Path: "../eventing"},
+					"knative.dev/pkg":      {Path: "github.com/example/pkg", Version: "v0.18.0"},
+				},
+			},
+		},
+		"indirect": {
+			file: "testdata/gomod.replace1",
+			opts: []Option{WithIndirect(true)},
+			want: &GoMod{
+				Module: "knative.dev/test-demo1",
+				Requires: map[string]string{
+					"knative.dev/eventing":   "v0.18.0",
+					"knative.dev/pkg":        "v0.0.0-20200922164940-4bf40ad82aab",
+					"knative.dev/serving":    "v0.18.0",
+					"knative.dev/test-infra": "v0.0.0-20200921012245-37f1a12adbd3",
+				},
+				Replaces: map[string]Replacement{
+					"knative.dev/eventing": {Path: "../eventing"},
+					"knative.dev/pkg":      {Path: "github.com/example/pkg", Version: "v0.18.0"},
+				},
+			},
+		},
+		"bad example": {
+			file:    "testdata/bad.example",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ReadGoMod(tt.file, "knative.dev", tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadGoMod() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Error("ReadGoMod() diff(-want,+got):\n", diff)
+			}
+		})
+	}
+}
+
+func TestReplacement(t *testing.T) {
+	local := Replacement{Path: "../pkg"}
+	if !local.Local() || local.String() != "../pkg" {
+		t.Errorf("unexpected local replacement %q, Local() = %t", local, local.Local())
+	}
+	fork := Replacement{Path: "github.com/example/pkg", Version: "v0.18.0"}
+	if fork.Local() || fork.String() != "github.com/example/pkg v0.18.0" {
+		t.Errorf("unexpected fork replacement %q, Local() = %t", fork, fork.Local())
+	}
+}
@@ -17,26 +17,26 @@ limitations under the License.
 package gomod
 
 import (
+	"context"
 	"fmt"
 	"io"
 
 	"github.com/blang/semver/v4"
 
 	"knative.dev/test-infra/pkg/git"
-	"knative.dev/test-infra/pkg/golang"
 )
 
 // ReleaseMeta holds metadata important to module release status.
 type ReleaseMeta struct {
-	Module              string
-	ReleaseBranchExists bool
-	ReleaseBranch       string
-	Release             string
+	Module              string `json:"module" yaml:"module"`
+	ReleaseBranchExists bool   `json:"releaseBranchExists" yaml:"releaseBranchExists"`
+	ReleaseBranch       string `json:"releaseBranch" yaml:"releaseBranch"`
+	Release             string `json:"release" yaml:"release"`
 }
 
 // ReleaseStatus collects metadata about release branch status and next released
 // version tags for a given module.
-func ReleaseStatus(gomod, release string, out io.Writer) (*ReleaseMeta, error) {
+func ReleaseStatus(gomod, release string, out io.Writer, opts ...Option) (*ReleaseMeta, error) {
 	this, err := semver.ParseTolerant(release)
 	if err != nil {
 		return nil, err
@@ -53,7 +53,7 @@ func ReleaseStatus(gomod, release string, out io.Writer) (*ReleaseMeta, error) {
 
 	next := &ReleaseMeta{Module: module}
 
-	repo, err := golang.ModuleToRepo(module)
+	repo, err := resolveOne(context.Background(), module, newOptions(opts))
 	if err != nil {
 		return nil, err
 	}
@@ -23,7 +23,6 @@ import (
 	"github.com/google/go-cmp/cmp"
 )
 
-// TestReleaseStatus - This is an integration test, it will make a call out to the internet.
 func TestReleaseStatus(t *testing.T) {
 	tests := map[string]struct {
 		gomod   string
@@ -69,7 +68,7 @@ func TestReleaseStatus(t *testing.T) {
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			got, err := ReleaseStatus(tt.gomod, tt.release, os.Stdout)
+			got, err := ReleaseStatus(tt.gomod, tt.release, os.Stdout, fixtureRefs(t))
 			if (tt.wantErr && err == nil) || (!tt.wantErr && err != nil) {
 				t.Errorf("unexpected error state, want error == %t, got %v", tt.wantErr, err)
 			}
@@ -0,0 +1,186 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomod
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	"knative.dev/test-infra/pkg/git"
+	"knative.dev/test-infra/pkg/golang"
+	"knative.dev/test-infra/pkg/helpers"
+)
+
+// DefaultWorkers is the default number of dependencies resolved concurrently.
+const DefaultWorkers = 8
+
+// Option configures how dependencies are resolved.
+type Option func(*options)
+
+type options struct {
+	workers  int
+	timeout  time.Duration
+	indirect bool
+	// replaces are the replace directives of the go mod file being resolved.
+	replaces map[string]Replacement
+	// resolver resolves modules to their repos.
+	resolver golang.RefResolver
+	// headCommit is used to override git.HeadCommit in tests.
+	headCommit func(url, branch string) (*git.Commit, error)
+	// auth authenticates pushes to remotes, nil for none.
+	auth transport.AuthMethod
+	// createBranch is used to override git.CreateBranch in tests.
+	createBranch func(url, from, branch string) (*git.Commit, error)
+}
+
+// WithWorkers sets the number of dependencies resolved concurrently. Values
+// less than one use a single worker.
+func WithWorkers(workers int) Option {
+	return func(o *options) {
+		o.workers = workers
+	}
+}
+
+// WithTimeout sets how long resolving a single dependency may take. Zero means
+// no timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithIndirect sets whether indirect dependencies are included. By default,
+// only direct dependencies are.
+func WithIndirect(include bool) Option {
+	return func(o *options) {
+		o.indirect = include
+	}
+}
+
+// WithRefResolver sets how modules are resolved to their repos. The default
+// is golang.GoImportResolver.
+func WithRefResolver(resolver golang.RefResolver) Option {
+	return func(o *options) {
+		o.resolver = resolver
+	}
+}
+
+// WithModuleToRepo sets the function used to resolve a module to its repo,
+// like WithRefResolver.
+func WithModuleToRepo(moduleToRepo func(module string) (*git.Repo, error)) Option {
+	return WithRefResolver(golang.RefResolverFunc(moduleToRepo))
+}
+
+// WithGitAuth sets how pushes to git remotes are authenticated, ex: when
+// cutting release branches. By default, pushes are not authenticated.
+func WithGitAuth(auth transport.AuthMethod) Option {
+	return func(o *options) {
+		o.auth = auth
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		workers:    DefaultWorkers,
+		headCommit: git.HeadCommit,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.resolver == nil {
+		o.resolver = golang.GoImportResolver
+	}
+	if o.createBranch == nil {
+		o.createBranch = func(url, from, branch string) (*git.Commit, error) {
+			return git.CreateBranch(url, from, branch, o.auth)
+		}
+	}
+	if o.workers < 1 {
+		o.workers = 1
+	}
+	return o
+}
+
+// resolved is the result of resolving a single module to its repo.
+type resolved struct {
+	module string
+	repo   *git.Repo
+	err    error
+	// replace is the replacement of module, if any. The repo of a fork is
+	// resolved instead of module, a local directory is not resolved.
+	replace *Replacement
+}
+
+// resolveAll resolves each module to its repo with a bounded number of
+// workers. The results are in the same order as modules. Modules not yet
+// started when ctx is done fail with the context error.
+func resolveAll(ctx context.Context, modules []string, o *options) []resolved {
+	results := make([]resolved, len(modules))
+	helpers.ForEach(len(modules), o.workers, func(i int) {
+		results[i] = resolveModule(ctx, modules[i], o)
+	})
+
+	return results
+}
+
+// resolveModule resolves module to its repo, or to the repo of its
+// replacement. Modules replaced by a local directory are pinned locally, and
+// are not resolved.
+func resolveModule(ctx context.Context, module string, o *options) resolved {
+	r := resolved{module: module}
+	target := module
+	if replace, ok := o.replaces[module]; ok {
+		r.replace = &replace
+		if replace.Local() {
+			return r
+		}
+		target = replace.Path
+	}
+	r.repo, r.err = resolveOne(ctx, target, o)
+	return r
+}
+
+// resolveOne resolves module to its repo, giving up when ctx is done or the
+// per module timeout passes.
+func resolveOne(ctx context.Context, module string, o *options) (*git.Repo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("unable to resolve %s: %w", module, err)
+	}
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+
+	// Listing remote refs can not be cancelled, so the lookup runs on its own
+	// and its result is dropped if ctx is done first.
+	done := make(chan resolved, 1)
+	go func() {
+		repo, err := o.resolver.ModuleToRepo(module)
+		done <- resolved{repo: repo, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("unable to resolve %s: %w", module, ctx.Err())
+	case r := <-done:
+		return r.repo, r.err
+	}
+}
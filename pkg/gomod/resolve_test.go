@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"knative.dev/test-infra/pkg/git"
+	"knative.dev/test-infra/pkg/golang"
+)
+
+func TestResolveAll(t *testing.T) {
+	modules := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+
+	var (
+		mu                  sync.Mutex
+		running, maxRunning int
+	)
+	o := newOptions([]Option{WithWorkers(3)})
+	o.resolver = golang.RefResolverFunc(func(module string) (*git.Repo, error) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		if module == "c" || module == "g" {
+			return nil, fmt.Errorf("failed %s", module)
+		}
+		return &git.Repo{Ref: module}, nil
+	})
+
+	results := resolveAll(context.Background(), modules, o)
+
+	if maxRunning > 3 {
+		t.Errorf("expected at most 3 concurrent lookups, got %d", maxRunning)
+	}
+	got := make([]string, 0, len(results))
+	for _, r := range results {
+		if r.err != nil {
+			got = append(got, r.err.Error())
+		} else {
+			got = append(got, r.repo.Ref)
+		}
+	}
+	want := []string{"a", "b", "failed c", "d", "e", "f", "failed g", "h", "i", "j"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("resolveAll() diff(-want,+got):\n", diff)
+	}
+}
+
+func TestResolveAll_Timeout(t *testing.T) {
+	o := newOptions([]Option{WithTimeout(10 * time.Millisecond)})
+	o.resolver = golang.RefResolverFunc(func(module string) (*git.Repo, error) {
+		if module == "slow" {
+			time.Sleep(time.Second)
+		}
+		return &git.Repo{Ref: module}, nil
+	})
+
+	results := resolveAll(context.Background(), []string{"fast", "slow"}, o)
+	if results[0].err != nil {
+		t.Error("unexpected error for fast module: ", results[0].err)
+	}
+	if !errors.Is(results[1].err, context.DeadlineExceeded) {
+		t.Error("expected slow module to time out, got ", results[1].err)
+	}
+}
+
+func TestResolveAll_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	o := newOptions(nil)
+	o.resolver = golang.RefResolverFunc(func(module string) (*git.Repo, error) {
+		t.Error("unexpected lookup of ", module)
+		return nil, nil
+	})
+
+	for _, r := range resolveAll(ctx, []string{"a", "b"}, o) {
+		if !errors.Is(r.err, context.Canceled) {
+			t.Errorf("expected %s to be cancelled, got %v", r.module, r.err)
+		}
+	}
+}
+
+func TestFloat_ResolveErrors(t *testing.T) {
+	repos := map[string]*git.Repo{
+		"knative.dev/eventing": {
+			Ref:           "knative.dev/eventing",
+			DefaultBranch: "master",
+			Tags:          []string{"v0.15.0", "v0.15.1"},
+			Branches:      []string{"master", "release-0.15"},
+		},
+	}
+	opt := fakeResolvers(repos)
+
+	refs, err := Float("./testdata/gomod.float1", "v0.15", "knative.dev", git.AnyRule, opt)
+	if want := []string{"knative.dev/eventing@v0.15.1"}; !cmp.Equal(want, refs) {
+		t.Errorf("Float() = %v, want %v", refs, want)
+	}
+	if want := "unknown module knative.dev/pkg"; err == nil || err.Error() != want {
+		t.Errorf("Float() error = %v, want %v", err, want)
+	}
+
+	err = Check("./testdata/gomod.float1", "v0.15", "knative.dev", git.AnyRule, nil, opt)
+	if err == nil || errors.Is(err, DependencyErr) {
+		t.Error("expected Check() to return the aggregated lookup error, got ", err)
+	}
+}
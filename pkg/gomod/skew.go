@@ -0,0 +1,247 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/blang/semver/v4"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"knative.dev/test-infra/pkg/git"
+)
+
+// DependencySkew holds the release line of the required version of a single
+// dependency, and how far it is from the release line of the target release.
+type DependencySkew struct {
+	// Module is the dependency module, ex: "knative.dev/pkg".
+	Module string `json:"module" yaml:"module"`
+	// Version is the required version, or the version of the fork for a
+	// dependency replaced by a fork.
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Replace is the replacement of the dependency, if any.
+	Replace *Replacement `json:"replace,omitempty" yaml:"replace,omitempty"`
+	// Pinned is true if the dependency is replaced by a local directory. Pinned
+	// dependencies have no release line, and are Allowed.
+	Pinned bool `json:"pinned,omitempty" yaml:"pinned,omitempty"`
+	// Line is the major and minor version of the release line of Version, ex:
+	// "0.19". A pseudo-version of the default branch is on the release line
+	// after the latest release of the dependency.
+	Line string `json:"line,omitempty" yaml:"line,omitempty"`
+	// Skew is the number of minor versions Line is behind the target release.
+	// It is negative if Line is ahead of it.
+	Skew int `json:"skew" yaml:"skew"`
+	// Allowed is true if Line is the release line of the target release, or
+	// at most the allowed number of minor versions behind it.
+	Allowed bool `json:"allowed" yaml:"allowed"`
+	// Suggested is the best release or release branch ref of the dependency
+	// for the target release, if any.
+	Suggested string `json:"suggested,omitempty" yaml:"suggested,omitempty"`
+	// Error is set if the release line could not be determined.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+
+	err error
+}
+
+// ModuleSkew holds the result of checking the release skew of the
+// dependencies of a module.
+type ModuleSkew struct {
+	Module       string           `json:"module" yaml:"module"`
+	Release      string           `json:"release" yaml:"release"`
+	MaxSkew      int              `json:"maxSkew" yaml:"maxSkew"`
+	Ready        bool             `json:"ready" yaml:"ready"`
+	Dependencies []DependencySkew `json:"dependencies" yaml:"dependencies"`
+}
+
+// Skew examines go mod files for dependencies, and checks that the required
+// version of each is on the release line of release, ex: v0.19.x or a
+// pseudo-version of release-0.19 for "0.19", or at most maxSkew minor
+// versions behind it. Dependencies replaced by a fork are checked using the
+// version of the fork, and dependencies replaced by a local directory are
+// allowed.
+func Skew(gomods []string, release, domain string, maxSkew int, opts ...Option) ([]ModuleSkew, error) {
+	return SkewContext(context.Background(), gomods, release, domain, maxSkew, opts...)
+}
+
+// SkewContext is Skew with a context. Dependencies are resolved concurrently,
+// to find the release line of default branch pseudo-versions and the suggested
+// refs. If some dependencies are not allowed, an Error is returned for each
+// module, which matches DependencyErr. Errors of all modules are aggregated.
+func SkewContext(ctx context.Context, gomods []string, release, domain string, maxSkew int, opts ...Option) ([]ModuleSkew, error) {
+	if len(gomods) == 0 {
+		return nil, errors.New("no go module files provided")
+	}
+	this, err := semver.ParseTolerant(release)
+	if err != nil {
+		return nil, err
+	}
+
+	skews := make([]ModuleSkew, 0, len(gomods))
+	errs := make([]error, 0)
+	for _, gomod := range gomods {
+		o := newOptions(opts)
+		mf, err := ReadGoMod(gomod, domain, opts...)
+		if err != nil {
+			return nil, err
+		}
+		o.replaces = mf.Replaces
+
+		s := ModuleSkew{
+			Module:       mf.Module,
+			Release:      release,
+			MaxSkew:      maxSkew,
+			Dependencies: dependencySkews(ctx, mf, this, maxSkew, o),
+		}
+		err = s.err()
+		s.Ready = err == nil
+		if err != nil {
+			errs = append(errs, err)
+		}
+		skews = append(skews, s)
+	}
+	return skews, utilerrors.NewAggregate(errs)
+}
+
+// dependencySkews computes the skew of each dependency of mf, in the order of
+// its dependencies.
+func dependencySkews(ctx context.Context, mf *GoMod, this semver.Version, maxSkew int, o *options) []DependencySkew {
+	skews := make([]DependencySkew, 0, len(mf.Requires))
+	for _, r := range resolveAll(ctx, mf.Dependencies(), o) {
+		s := DependencySkew{Module: r.module, Version: mf.Requires[r.module], Replace: r.replace}
+		if r.replace != nil {
+			if r.replace.Local() {
+				s.Pinned = true
+				s.Allowed = true
+				skews = append(skews, s)
+				continue
+			}
+			s.Version = r.replace.Version
+		}
+		if r.err == nil {
+			if ref, refType := r.repo.BestRefFor(this, git.ReleaseOrReleaseBranchRule); refType != git.NoRef {
+				s.Suggested = ref
+			}
+		}
+
+		line, err := releaseLine(s.Version, r)
+		if err != nil {
+			s.Error = err.Error()
+			s.err = err
+			skews = append(skews, s)
+			continue
+		}
+		s.Line = fmt.Sprintf("%d.%d", line.Major, line.Minor)
+		s.Skew = int(this.Minor) - int(line.Minor)
+		s.Allowed = line.Major == this.Major && s.Skew >= 0 && s.Skew <= maxSkew
+		skews = append(skews, s)
+	}
+	return skews
+}
+
+// releaseLine returns the release line of version, a required version of the
+// resolved dependency r. The release line of a release, release candidate or
+// pseudo-version after one is its major and minor version. A pseudo-version
+// without a base release is a commit of the default branch, which is on the
+// release line after the latest release of the dependency.
+func releaseLine(version string, r resolved) (semver.Version, error) {
+	if Kind(version) != PseudoVersionKind {
+		v, err := semver.ParseTolerant(version)
+		if err != nil {
+			return semver.Version{}, err
+		}
+		return semver.Version{Major: v.Major, Minor: v.Minor}, nil
+	}
+
+	if r.err != nil {
+		return semver.Version{}, r.err
+	}
+	latest, ok := r.repo.LatestReleaseLine()
+	if !ok {
+		return semver.Version{}, fmt.Errorf("%s has no release, the release line of %s is unknown", r.repo.Ref, version)
+	}
+	return semver.Version{Major: latest.Major, Minor: latest.Minor + 1}, nil
+}
+
+// err returns an aggregate of the errors of the dependencies if there are
+// any, otherwise an Error if some dependencies are not allowed.
+func (s ModuleSkew) err() error {
+	errs := make([]error, 0)
+	for _, d := range s.Dependencies {
+		if d.err != nil {
+			errs = append(errs, d.err)
+		}
+	}
+	if len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+
+	skewed := make([]string, 0)
+	for _, d := range s.Dependencies {
+		if !d.Allowed {
+			skewed = append(skewed, d.ref())
+		}
+	}
+	if len(skewed) > 0 {
+		return &Error{
+			Module:       s.Module,
+			Dependencies: skewed,
+		}
+	}
+	return nil
+}
+
+// ref returns the required module ref, or the ref of the fork.
+func (d DependencySkew) ref() string {
+	if d.Replace != nil && !d.Pinned {
+		return d.Replace.Path + "@" + d.Version
+	}
+	return d.Module + "@" + d.Version
+}
+
+// WriteSkews writes the skew of each dependency of each module in a human
+// readable form.
+func WriteSkews(out io.Writer, skews []ModuleSkew) {
+	for _, s := range skews {
+		_, _ = fmt.Fprintf(out, "%s (release %s, max skew %d)\n", s.Module, s.Release, s.MaxSkew)
+		for _, d := range s.Dependencies {
+			switch {
+			case d.Error != "":
+				_, _ = fmt.Fprintln(out, "✘ ", d.Module, d.Error)
+			case d.Pinned:
+				_, _ = fmt.Fprintln(out, "✔ ", d.Module, "=>", d.Replace, "(pinned locally)")
+			case d.Allowed:
+				_, _ = fmt.Fprintf(out, "✔  %s (%s)\n", d.ref(), d.Line)
+			default:
+				line := fmt.Sprintf("✘  %s (%s, %s)", d.ref(), d.Line, skewString(d.Skew))
+				if d.Suggested != "" {
+					line += ", want " + d.Suggested
+				}
+				_, _ = fmt.Fprintln(out, line)
+			}
+		}
+	}
+}
+
+func skewString(skew int) string {
+	if skew < 0 {
+		return fmt.Sprintf("%d ahead", -skew)
+	}
+	return fmt.Sprintf("%d behind", skew)
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomod
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"knative.dev/test-infra/pkg/git"
+)
+
+func skewRepos() map[string]*git.Repo {
+	return map[string]*git.Repo{
+		"knative.dev/caching": {
+			Ref:      "knative.dev/caching",
+			Tags:     []string{"v0.18.0"},
+			Branches: []string{"master", "release-0.18", "release-0.19"},
+		},
+		"knative.dev/eventing": {
+			Ref:      "knative.dev/eventing",
+			Tags:     []string{"v0.19.0"},
+			Branches: []string{"master", "release-0.19"},
+		},
+		"knative.dev/networking": {
+			Ref:      "knative.dev/networking",
+			Tags:     []string{"v0.18.0"},
+			Branches: []string{"master", "release-0.18"},
+		},
+		"knative.dev/pkg": {
+			Ref:      "knative.dev/pkg",
+			Tags:     []string{"v0.19.0", "v0.19.2"},
+			Branches: []string{"master", "release-0.19"},
+		},
+		"knative.dev/serving": {
+			Ref:      "knative.dev/serving",
+			Tags:     []string{"v0.19.0", "v0.20.0-rc.1"},
+			Branches: []string{"master", "release-0.19"},
+		},
+	}
+}
+
+func TestSkewContext(t *testing.T) {
+	caching := DependencySkew{Module: "knative.dev/caching", Version: "v0.0.0-20201110123000-0123456789ab", Line: "0.20", Skew: -1, Suggested: "knative.dev/caching@release-0.19"}
+	eventing := DependencySkew{Module: "knative.dev/eventing", Version: "v0.19.1-0.20201110123000-0123456789ab", Line: "0.19", Allowed: true, Suggested: "knative.dev/eventing@v0.19.0"}
+	networking := DependencySkew{Module: "knative.dev/networking", Version: "v0.18.0", Line: "0.18", Skew: 1}
+	pkg := DependencySkew{Module: "knative.dev/pkg", Version: "v0.19.2", Line: "0.19", Allowed: true, Suggested: "knative.dev/pkg@v0.19.2"}
+	serving := DependencySkew{Module: "knative.dev/serving", Version: "v0.20.0-rc.1", Line: "0.20", Skew: -1, Suggested: "knative.dev/serving@v0.19.0"}
+	testInfra := DependencySkew{Module: "knative.dev/test-infra", Replace: &Replacement{Path: "../test-infra"}, Version: "v0.0.0-20201110123000-0123456789ab", Pinned: true, Allowed: true}
+	allowed := func(d DependencySkew) DependencySkew {
+		d.Allowed = true
+		return d
+	}
+
+	tests := map[string]struct {
+		gomods  []string
+		maxSkew int
+		want    []ModuleSkew
+		wantErr string
+	}{
+		"no skew": {
+			gomods: []string{"./testdata/gomod.skew1"},
+			want: []ModuleSkew{{
+				Module:       "knative.dev/test-demo1",
+				Release:      "0.19",
+				Dependencies: []DependencySkew{caching, eventing, networking, pkg, serving, testInfra},
+			}},
+			wantErr: "knative.dev/test-demo1 failed because of the following dependencies [" +
+				"knative.dev/caching@v0.0.0-20201110123000-0123456789ab, knative.dev/networking@v0.18.0, knative.dev/serving@v0.20.0-rc.1]",
+		},
+		"allowed skew": {
+			gomods:  []string{"./testdata/gomod.skew1", "./testdata/gomod.skew2"},
+			maxSkew: 1,
+			want: []ModuleSkew{{
+				Module:       "knative.dev/test-demo1",
+				Release:      "0.19",
+				MaxSkew:      1,
+				Dependencies: []DependencySkew{caching, eventing, allowed(networking), pkg, serving, testInfra},
+			}, {
+				Module:  "knative.dev/test-demo2",
+				Release: "0.19",
+				MaxSkew: 1,
+				Ready:   true,
+				Dependencies: []DependencySkew{
+					{Module: "knative.dev/networking", Version: "v0.0.0-20201110123000-0123456789ab", Line: "0.19", Allowed: true},
+					{Module: "knative.dev/pkg", Version: "v0.19.0", Line: "0.19", Allowed: true, Suggested: "knative.dev/pkg@v0.19.2"},
+				},
+			}},
+			wantErr: "knative.dev/test-demo1 failed because of the following dependencies [" +
+				"knative.dev/caching@v0.0.0-20201110123000-0123456789ab, knative.dev/serving@v0.20.0-rc.1]",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := SkewContext(context.Background(), tt.gomods, "0.19", "knative.dev", tt.maxSkew, fakeResolvers(skewRepos()))
+			if !errors.Is(err, DependencyErr) || err.Error() != tt.wantErr {
+				t.Errorf("SkewContext() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreUnexported(DependencySkew{})); diff != "" {
+				t.Error("SkewContext() diff(-want,+got):\n", diff)
+			}
+		})
+	}
+}
+
+func TestSkewContext_UnknownLine(t *testing.T) {
+	repos := skewRepos()
+	repos["knative.dev/networking"].Tags = nil
+	repos["knative.dev/networking"].Branches = []string{"master"}
+
+	got, err := SkewContext(context.Background(), []string{"./testdata/gomod.skew2"}, "0.19", "knative.dev", 0, fakeResolvers(repos))
+	if err == nil || errors.Is(err, DependencyErr) {
+		t.Fatalf("SkewContext() error = %v, want the unknown release line", err)
+	}
+	want := "knative.dev/networking has no release, the release line of v0.0.0-20201110123000-0123456789ab is unknown"
+	if got[0].Dependencies[0].Error != want {
+		t.Errorf("SkewContext() dependency error = %q, want %q", got[0].Dependencies[0].Error, want)
+	}
+}
+
+func TestWriteSkews(t *testing.T) {
+	skews, _ := SkewContext(context.Background(), []string{"./testdata/gomod.skew1"}, "0.19", "knative.dev", 0, fakeResolvers(skewRepos()))
+
+	var buf bytes.Buffer
+	WriteSkews(&buf, skews)
+	want := `knative.dev/test-demo1 (release 0.19, max skew 0)
+✘  knative.dev/caching@v0.0.0-20201110123000-0123456789ab (0.20, 1 ahead), want knative.dev/caching@release-0.19
+✔  knative.dev/eventing@v0.19.1-0.20201110123000-0123456789ab (0.19)
+✘  knative.dev/networking@v0.18.0 (0.18, 1 behind)
+✔  knative.dev/pkg@v0.19.2 (0.19)
+✘  knative.dev/serving@v0.20.0-rc.1 (0.20, 1 ahead), want knative.dev/serving@v0.19.0
+✔  knative.dev/test-infra => ../test-infra (pinned locally)
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Error("WriteSkews() diff(-want,+got):\n", diff)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomod
+
+import (
+	"context"
+
+	"github.com/blang/semver/v4"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"knative.dev/test-infra/pkg/git"
+)
+
+// DependencyStatus holds the ref selected for a single dependency based on a
+// ruleset.
+type DependencyStatus struct {
+	// Module is the dependency module, ex: "knative.dev/pkg".
+	Module string `json:"module" yaml:"module"`
+	// Ref is the selected module ref, ex: "knative.dev/pkg@v0.19.1", or the
+	// bare module if no ref was found.
+	Ref string `json:"ref,omitempty" yaml:"ref,omitempty"`
+	// RefType is the human readable git.RefType of Ref.
+	RefType string `json:"refType,omitempty" yaml:"refType,omitempty"`
+	// Replace is the replacement of the dependency, if any. For a fork, Ref is
+	// a ref of the fork.
+	Replace *Replacement `json:"replace,omitempty" yaml:"replace,omitempty"`
+	// Pinned is true if the dependency is replaced by a local directory. Pinned
+	// dependencies are not resolved, and are Ready.
+	Pinned bool `json:"pinned,omitempty" yaml:"pinned,omitempty"`
+	// Ready is true if a ref was found for the ruleset.
+	Ready bool `json:"ready" yaml:"ready"`
+	// Error is set if the dependency could not be resolved.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+
+	refType git.RefType
+	err     error
+}
+
+// ModuleStatus holds the result of checking the dependencies of a module.
+type ModuleStatus struct {
+	Module       string             `json:"module" yaml:"module"`
+	Release      string             `json:"release" yaml:"release"`
+	Ruleset      string             `json:"ruleset" yaml:"ruleset"`
+	Ready        bool               `json:"ready" yaml:"ready"`
+	Dependencies []DependencyStatus `json:"dependencies" yaml:"dependencies"`
+}
+
+// dependencyStatuses resolves packages concurrently and selects the best ref
+// for each of them, honoring the replace directives in o. The statuses keep
+// the order of packages.
+func dependencyStatuses(ctx context.Context, packages []string, this semver.Version, ruleset git.RulesetType, o *options) []DependencyStatus {
+	statuses := make([]DependencyStatus, 0, len(packages))
+	for _, r := range resolveAll(ctx, packages, o) {
+		s := DependencyStatus{Module: r.module, Replace: r.replace}
+		if r.replace != nil && r.replace.Local() {
+			s.Pinned = true
+			s.Ready = true
+			statuses = append(statuses, s)
+			continue
+		}
+		if r.err != nil {
+			s.Error = r.err.Error()
+			s.err = r.err
+			statuses = append(statuses, s)
+			continue
+		}
+
+		s.Ref, s.refType = r.repo.BestRefFor(this, ruleset)
+		s.RefType = s.refType.String()
+		s.Ready = s.refType != git.NoRef
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
+// statusErrors returns an aggregate of the resolve errors of statuses, or nil.
+func statusErrors(statuses []DependencyStatus) error {
+	errs := make([]error, 0)
+	for _, s := range statuses {
+		if s.err != nil {
+			errs = append(errs, s.err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomod
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"knative.dev/test-infra/pkg/git"
+)
+
+func statusRepos() map[string]*git.Repo {
+	return map[string]*git.Repo{
+		"knative.dev/eventing": {
+			Ref:           "knative.dev/eventing",
+			DefaultBranch: "master",
+			Tags:          []string{"v0.19.0", "v0.19.2"},
+			Branches:      []string{"master", "release-0.19"},
+		},
+		"knative.dev/pkg": {
+			Ref:           "knative.dev/pkg",
+			DefaultBranch: "master",
+			Branches:      []string{"master", "release-0.19"},
+		},
+		"knative.dev/test-infra": {
+			Ref:           "knative.dev/test-infra",
+			DefaultBranch: "master",
+			Branches:      []string{"master"},
+		},
+	}
+}
+
+func TestCheckStatusContext(t *testing.T) {
+	tests := map[string]struct {
+		rule    git.RulesetType
+		want    []ModuleStatus
+		wantErr error
+	}{
+		"ready": {
+			rule: git.AnyRule,
+			want: []ModuleStatus{{
+				Module:  "knative.dev/test-demo1",
+				Release: "v0.19",
+				Ruleset: "Any",
+				Ready:   true,
+				Dependencies: []DependencyStatus{
+					{Module: "knative.dev/eventing", Ref: "knative.dev/eventing@v0.19.2", RefType: "Release", Ready: true},
+					{Module: "knative.dev/pkg", Ref: "knative.dev/pkg@release-0.19", RefType: "Release Branch", Ready: true},
+					{Module: "knative.dev/test-infra", Ref: "knative.dev/test-infra@master", RefType: "Default Branch", Ready: true},
+				},
+			}},
+		},
+		"not ready": {
+			rule: git.ReleaseRule,
+			want: []ModuleStatus{{
+				Module:  "knative.dev/test-demo1",
+				Release: "v0.19",
+				Ruleset: "Release",
+				Dependencies: []DependencyStatus{
+					{Module: "knative.dev/eventing", Ref: "knative.dev/eventing@v0.19.2", RefType: "Release", Ready: true},
+					{Module: "knative.dev/pkg", Ref: "knative.dev/pkg", RefType: "No Ref"},
+					{Module: "knative.dev/test-infra", Ref: "knative.dev/test-infra", RefType: "No Ref"},
+				},
+			}},
+			wantErr: DependencyErr,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := CheckStatusContext(context.Background(), "./testdata/gomod.update1", "v0.19", "knative.dev", tt.rule, fakeResolvers(statusRepos()))
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("CheckStatusContext() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreUnexported(DependencyStatus{})); diff != "" {
+				t.Error("CheckStatusContext() diff(-want,+got):\n", diff)
+			}
+		})
+	}
+}
+
+func TestFloatStatusContext(t *testing.T) {
+	repos := statusRepos()
+	delete(repos, "knative.dev/test-infra")
+
+	got, err := FloatStatusContext(context.Background(), "./testdata/gomod.update1", "v0.19", "knative.dev", git.ReleaseRule, fakeResolvers(repos))
+	if err == nil {
+		t.Fatal("expected an error for the unknown module")
+	}
+
+	want := []DependencyStatus{
+		{Module: "knative.dev/eventing", Ref: "knative.dev/eventing@v0.19.2", RefType: "Release", Ready: true},
+		{Module: "knative.dev/pkg", Ref: "knative.dev/pkg", RefType: "No Ref"},
+		{Module: "knative.dev/test-infra", Error: "unknown module knative.dev/test-infra"},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(DependencyStatus{})); diff != "" {
+		t.Error("FloatStatusContext() diff(-want,+got):\n", diff)
+	}
+}
+
+func TestFloatContext_Replace(t *testing.T) {
+	repos := statusRepos()
+	repos["github.com/example/pkg"] = &git.Repo{
+		Ref:  "github.com/example/pkg",
+		Tags: []string{"v0.18.0", "v0.19.0"},
+	}
+
+	// The fork of pkg and the local eventing are not floated.
+	got, err := FloatContext(context.Background(), "./testdata/gomod.replace1", "v0.19", "knative.dev", git.AnyRule, fakeResolvers(repos))
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	want := []string{"knative.dev/test-infra@master"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("FloatContext() diff(-want,+got):\n", diff)
+	}
+
+	statuses, err := FloatStatusContext(context.Background(), "./testdata/gomod.replace1", "v0.19", "knative.dev", git.AnyRule, fakeResolvers(repos))
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	for _, s := range statuses {
+		if s.Module == "knative.dev/pkg" && s.Ref != "github.com/example/pkg@v0.19.0" {
+			t.Errorf("FloatStatusContext() ref of the fork = %q, want %q", s.Ref, "github.com/example/pkg@v0.19.0")
+		}
+	}
+}
+
+func TestCheckStatusContext_Replace(t *testing.T) {
+	repos := statusRepos()
+	repos["github.com/example/pkg"] = &git.Repo{
+		Ref:  "github.com/example/pkg",
+		Tags: []string{"v0.18.0", "v0.19.0"},
+	}
+	repos["knative.dev/serving"] = &git.Repo{
+		Ref:  "knative.dev/serving",
+		Tags: []string{"v0.19.0-rc.1"},
+	}
+
+	want := []ModuleStatus{{
+		Module:  "knative.dev/test-demo1",
+		Release: "v0.19",
+		Ruleset: "ReleaseOrCandidate",
+		Dependencies: []DependencyStatus{
+			{Module: "knative.dev/eventing", Replace: &Replacement{Path: "../eventing"}, Pinned: true, Ready: true},
+			{Module: "knative.dev/pkg", Ref: "github.com/example/pkg@v0.19.0", RefType: "Release", Replace: &Replacement{Path: "github.com/example/pkg", Version: "v0.18.0"}, Ready: true},
+			{Module: "knative.dev/serving", Ref: "knative.dev/serving@v0.19.0-rc.1", RefType: "Release Candidate", Ready: true},
+			{Module: "knative.dev/test-infra", Ref: "knative.dev/test-infra", RefType: "No Ref"},
+		},
+	}}
+
+	got, err := CheckStatusContext(context.Background(), "./testdata/gomod.replace1", "v0.19", "knative.dev", git.ReleaseOrCandidateRule,
+		fakeResolvers(repos), WithIndirect(true))
+	if !errors.Is(err, DependencyErr) {
+		t.Fatalf("CheckStatusContext() error = %v, want %v", err, DependencyErr)
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(DependencyStatus{})); diff != "" {
+		t.Error("CheckStatusContext() diff(-want,+got):\n", diff)
+	}
+}
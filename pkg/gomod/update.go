@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomod
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/blang/semver/v4"
+	"golang.org/x/mod/modfile"
+	modsemver "golang.org/x/mod/semver"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"knative.dev/test-infra/pkg/git"
+)
+
+// Update examines a go mod file for dependencies, floats each to the best ref
+// for a given release based on the provided ruleset, and returns the contents
+// of the go mod file requiring the floated refs. Release tags are required as
+// is, branches are required as the pseudo-version of their head commit.
+// Dependencies without a ref for the ruleset are left unchanged. Update uses
+// the same rules as Float.
+func Update(gomod, release, domain string, ruleset git.RulesetType, opts ...Option) ([]byte, error) {
+	return UpdateContext(context.Background(), gomod, release, domain, ruleset, opts...)
+}
+
+// UpdateContext is Update with a context. If some dependencies fail to
+// resolve, an aggregate of the errors is returned. Dependencies replaced by a
+// fork are floated using the repo of the fork, and the version of the fork is
+// updated in the replace directive instead. Dependencies replaced by a local
+// directory are left unchanged.
+func UpdateContext(ctx context.Context, gomod, release, domain string, ruleset git.RulesetType, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	mf, err := ReadGoMod(gomod, domain, opts...)
+	if err != nil {
+		return nil, err
+	}
+	o.replaces = mf.Replaces
+
+	this, err := semver.ParseTolerant(release)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(gomod)
+	if err != nil {
+		return nil, err
+	}
+	file, err := modfile.Parse(gomod, b /*VersionFixer func*/, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, 0)
+	for _, r := range resolveAll(ctx, mf.Dependencies(), o) {
+		if r.replace != nil && r.replace.Local() {
+			continue
+		}
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+
+		ref, refType := r.repo.BestRefFor(this, ruleset)
+		if refType == git.NoRef {
+			continue
+		}
+		version, err := requireVersion(ref, refType, r.repo, o)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if r.replace != nil {
+			updateReplace(file, r.module, mf.Requires[r.module], *r.replace, version)
+			continue
+		}
+		if err := file.AddRequire(r.module, version); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+
+	file.Cleanup()
+	return modfile.Format(file.Syntax), nil
+}
+
+// requireVersion converts a ref from BestRefFor into a version go.mod accepts.
+func requireVersion(ref string, refType git.RefType, repo *git.Repo, o *options) (string, error) {
+	_, version, _ := git.ParseRef(ref)
+	if refType == git.ReleaseRef || refType == git.ReleaseCandidateRef {
+		return version, nil
+	}
+	// Refs resolved through a module proxy are already module versions, ex:
+	// the latest version standing in for the default branch. There is no
+	// branch to look up the head commit of.
+	if modsemver.Canonical(version) == version {
+		return version, nil
+	}
+
+	commit, err := o.headCommit(repo.URL, version)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve %s: %w", ref, err)
+	}
+	return repo.PseudoVersion(commit), nil
+}
+
+// updateReplace sets the version of the fork in the replace directive of
+// module that applies to the required version.
+func updateReplace(file *modfile.File, module, required string, fork Replacement, version string) {
+	for _, r := range file.Replace {
+		if r.Old.Path != module || (r.Old.Version != "" && r.Old.Version != required) {
+			continue
+		}
+		if r.New.Path != fork.Path || r.New.Version != fork.Version {
+			continue
+		}
+		r.New.Version = version
+		// The version of the fork is always the last token of the line.
+		r.Syntax.Token[len(r.Syntax.Token)-1] = version
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gomod
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"knative.dev/test-infra/pkg/git"
+	"knative.dev/test-infra/pkg/golang"
+)
+
+// fakeResolvers resolves modules to the given repos. The head commit of any
+// branch of a repo with a URL has all the tags of the repo as ancestors.
+func fakeResolvers(repos map[string]*git.Repo) Option {
+	return func(o *options) {
+		o.resolver = golang.StaticResolver(repos)
+		o.headCommit = func(url, branch string) (*git.Commit, error) {
+			c := &git.Commit{
+				Hash: "0123456789abcdef0123456789abcdef01234567",
+				Time: time.Date(2020, 11, 10, 12, 30, 0, 0, time.UTC),
+			}
+			for _, r := range repos {
+				if url != "" && r.URL == url {
+					c.ReachableTags = r.Tags
+				}
+			}
+			return c, nil
+		}
+	}
+}
+
+// fixtureRefs resolves modules to the repos in testdata/refs.yaml.
+func fixtureRefs(t *testing.T) Option {
+	t.Helper()
+	refs, err := golang.LoadStaticResolver("./testdata/refs.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return WithRefResolver(refs)
+}
+
+func TestUpdate(t *testing.T) {
+	repos := map[string]*git.Repo{
+		"knative.dev/eventing": {
+			Ref:           "knative.dev/eventing",
+			URL:           "https://github.com/knative/eventing",
+			DefaultBranch: "master",
+			Tags:          []string{"v0.19.0", "v0.19.2"},
+			Branches:      []string{"master", "release-0.19"},
+		},
+		"knative.dev/pkg": {
+			Ref:           "knative.dev/pkg",
+			DefaultBranch: "master",
+			Branches:      []string{"master", "release-0.19"},
+		},
+		"knative.dev/test-infra": {
+			Ref:           "knative.dev/test-infra",
+			DefaultBranch: "master",
+			Branches:      []string{"master"},
+		},
+	}
+
+	tests := map[string]struct {
+		rule    git.RulesetType
+		want    string
+		wantErr bool
+	}{
+		"any rule": {
+			rule: git.AnyRule,
+			want: `module knative.dev/test-demo1
+
+go 1.14
+
+require (
+	github.com/google/go-cmp v0.5.2
+	knative.dev/eventing v0.19.2
+	knative.dev/pkg v0.0.0-20201110123000-0123456789ab
+	knative.dev/serving v0.17.1-0.20200923161440-615c2258f296 // indirect
+	knative.dev/test-infra v0.0.0-20201110123000-0123456789ab
+)
+`,
+		},
+		"release branch rule": {
+			rule: git.ReleaseBranchRule,
+			want: `module knative.dev/test-demo1
+
+go 1.14
+
+require (
+	github.com/google/go-cmp v0.5.2
+	knative.dev/eventing v0.19.3-0.20201110123000-0123456789ab
+	knative.dev/pkg v0.0.0-20201110123000-0123456789ab
+	knative.dev/serving v0.17.1-0.20200923161440-615c2258f296 // indirect
+	knative.dev/test-infra v0.0.0-20200921012245-37f1a12adbd3
+)
+`,
+		},
+		"release rule": {
+			rule: git.ReleaseRule,
+			want: `module knative.dev/test-demo1
+
+go 1.14
+
+require (
+	github.com/google/go-cmp v0.5.2
+	knative.dev/eventing v0.19.2
+	knative.dev/pkg v0.0.0-20200922164940-4bf40ad82aab
+	knative.dev/serving v0.17.1-0.20200923161440-615c2258f296 // indirect
+	knative.dev/test-infra v0.0.0-20200921012245-37f1a12adbd3
+)
+`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Update("./testdata/gomod.update1", "v0.19", "knative.dev", tt.rule, fakeResolvers(repos))
+			if err != nil {
+				t.Fatal("unexpected error: ", err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Error("Update() diff(-want,+got):\n", diff)
+			}
+		})
+	}
+}
+
+func TestUpdate_Replace(t *testing.T) {
+	repos := map[string]*git.Repo{
+		"github.com/example/pkg": {
+			Ref:      "github.com/example/pkg",
+			Tags:     []string{"v0.18.0", "v0.19.0"},
+			Branches: []string{"master"},
+		},
+		"knative.dev/serving": {
+			Ref:  "knative.dev/serving",
+			Tags: []string{"v0.19.0-rc.1"},
+		},
+		"knative.dev/test-infra": {
+			Ref:      "knative.dev/test-infra",
+			Branches: []string{"master", "release-0.19"},
+		},
+	}
+	want := `module knative.dev/test-demo1
+
+go 1.14
+
+require (
+	github.com/google/go-cmp v0.5.2
+	knative.dev/eventing v0.18.0
+	knative.dev/pkg v0.0.0-20200922164940-4bf40ad82aab
+	knative.dev/serving v0.19.0-rc.1 // indirect
+	knative.dev/test-infra v0.0.0-20201110123000-0123456789ab
+)
+
+replace (
+	knative.dev/eventing => ../eventing
+	knative.dev/pkg => github.com/example/pkg v0.19.0
+	knative.dev/test-infra v0.0.1 => ../test-infra
+)
+`
+
+	got, err := Update("./testdata/gomod.replace1", "v0.19", "knative.dev", git.ReleaseOrCandidateOrReleaseBranchRule,
+		fakeResolvers(repos), WithIndirect(true))
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Error("Update() diff(-want,+got):\n", diff)
+	}
+}
+
+func TestUpdate_Proxy(t *testing.T) {
+	files := map[string]string{
+		"/knative.dev/eventing/@v/list":   "v0.18.0\nv0.19.0\nv0.19.1\n",
+		"/knative.dev/eventing/@latest":   `{"Version":"v0.19.1","Time":"2020-11-10T12:30:00Z"}`,
+		"/knative.dev/pkg/@v/list":        "",
+		"/knative.dev/pkg/@latest":        `{"Version":"v0.0.0-20201201123000-0123456789ab","Time":"2020-12-01T12:30:00Z"}`,
+		"/knative.dev/test-infra/@v/list": "v0.18.0\n",
+		"/knative.dev/test-infra/@latest": `{"Version":"v0.18.0","Time":"2020-10-10T12:30:00Z"}`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.Error(w, "not found: "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer ts.Close()
+	proxy, err := golang.NewProxy(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Versions from the proxy are required as is, without looking up commits.
+	noCommits := func(o *options) {
+		o.headCommit = func(url, branch string) (*git.Commit, error) {
+			return nil, fmt.Errorf("unexpected head commit lookup of %s@%s", url, branch)
+		}
+	}
+
+	want := `module knative.dev/test-demo1
+
+go 1.14
+
+require (
+	github.com/google/go-cmp v0.5.2
+	knative.dev/eventing v0.19.1
+	knative.dev/pkg v0.0.0-20201201123000-0123456789ab
+	knative.dev/serving v0.17.1-0.20200923161440-615c2258f296 // indirect
+	knative.dev/test-infra v0.18.0
+)
+`
+	got, err := Update("./testdata/gomod.update1", "v0.19", "knative.dev", git.AnyRule, WithRefResolver(proxy), noCommits)
+	if err != nil {
+		t.Fatal("unexpected error: ", err)
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Error("Update() diff(-want,+got):\n", diff)
+	}
+}
+
+func TestUpdateUnhappy(t *testing.T) {
+	tests := map[string]struct {
+		gomod   string
+		release string
+	}{
+		"bad go mod file": {
+			gomod:   "./testdata/bad.example",
+			release: "v0.19",
+		},
+		"bad version": {
+			gomod:   "./testdata/gomod.update1",
+			release: "jupiter/is/angry",
+		},
+		"unknown modules": {
+			gomod:   "./testdata/gomod.update1",
+			release: "v0.19",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Update(tt.gomod, tt.release, "knative.dev", git.AnyRule, fakeResolvers(nil))
+			if err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
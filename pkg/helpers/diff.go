@@ -0,0 +1,133 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+type diffLine struct {
+	op   byte // ' ', '-' or '+'
+	text string
+}
+
+// UnifiedDiff returns the line oriented unified diff turning before into
+// after, labeled with the given file names. An empty string is returned if
+// there are no changes.
+func UnifiedDiff(beforeName, afterName, before, after string) string {
+	lines := make([]diffLine, 0)
+	for _, d := range diff.Do(before, after) {
+		op := byte(' ')
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			op = '-'
+		case diffmatchpatch.DiffInsert:
+			op = '+'
+		}
+		for _, l := range strings.SplitAfter(d.Text, "\n") {
+			if l != "" {
+				lines = append(lines, diffLine{op: op, text: l})
+			}
+		}
+	}
+
+	var b strings.Builder
+	// Walk the lines, emitting a hunk for each group of changes that are
+	// within 2*diffContext lines of each other.
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			oldLine++
+			newLine++
+			continue
+		}
+
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for unchanged := 0; end < len(lines) && unchanged <= 2*diffContext; end++ {
+			if lines[end].op == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		// Trim the trailing context down to diffContext lines.
+		for end > i && lines[end-1].op == ' ' {
+			end--
+		}
+		end += diffContext
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		hunkOld, hunkNew := oldLine-(i-start), newLine-(i-start)
+		oldCount, newCount := 0, 0
+		var hunk strings.Builder
+		for _, l := range lines[start:end] {
+			hunk.WriteByte(l.op)
+			hunk.WriteString(l.text)
+			if !strings.HasSuffix(l.text, "\n") {
+				hunk.WriteString("\n\\ No newline at end of file\n")
+			}
+			if l.op != '+' {
+				oldCount++
+			}
+			if l.op != '-' {
+				newCount++
+			}
+		}
+
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", beforeName, afterName)
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(hunkOld, oldCount), hunkRange(hunkNew, newCount))
+		b.WriteString(hunk.String())
+
+		for _, l := range lines[i:end] {
+			if l.op != '+' {
+				oldLine++
+			}
+			if l.op != '-' {
+				newLine++
+			}
+		}
+		i = end
+	}
+	return b.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// An empty range starts at the line before the change.
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := map[string]struct {
+		before string
+		after  string
+		want   string
+	}{
+		"no changes": {
+			before: "a\nb\n",
+			after:  "a\nb\n",
+			want:   "",
+		},
+		"single change": {
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			after:  "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: `--- a
++++ b
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`,
+		},
+		"two hunks": {
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			after:  "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			want: `--- a
++++ b
+@@ -1,4 +1,4 @@
+-1
++one
+ 2
+ 3
+ 4
+@@ -9,4 +9,3 @@
+ 9
+ 10
+ 11
+-12
+`,
+		},
+		"insert into empty": {
+			before: "",
+			after:  "a\n",
+			want: `--- a
++++ b
+@@ -0,0 +1 @@
++a
+`,
+		},
+		"no newline": {
+			before: "a\nb",
+			after:  "a\nc",
+			want: `--- a
++++ b
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++c
+\ No newline at end of file
+`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := UnifiedDiff("a", "b", tt.before, tt.after)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Error("UnifiedDiff() diff(-want,+got):\n", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestForEach(t *testing.T) {
+	tests := map[string]struct {
+		n       int
+		workers int
+	}{
+		"more items than workers": {n: 10, workers: 3},
+		"more workers than items": {n: 2, workers: 8},
+		"no items":                {n: 0, workers: 4},
+		"zero workers":            {n: 5, workers: 0},
+		"negative workers":        {n: 5, workers: -2},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			calls := make([]int32, tt.n)
+			var running, peak int32
+			ForEach(tt.n, tt.workers, func(i int) {
+				r := atomic.AddInt32(&running, 1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if r <= p || atomic.CompareAndSwapInt32(&peak, p, r) {
+						break
+					}
+				}
+				atomic.AddInt32(&calls[i], 1)
+				atomic.AddInt32(&running, -1)
+			})
+			for i, c := range calls {
+				if c != 1 {
+					t.Errorf("index %d called %d times, want 1", i, c)
+				}
+			}
+			max := int32(tt.workers)
+			if max < 1 {
+				max = 1
+			}
+			if peak > max {
+				t.Errorf("%d calls ran at a time, want at most %d", peak, max)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package inventory lists the repos of GitHub orgs with the attributes our
+// automation selects repos by, such as whether they are archived, their
+// default branch, their topics and the go module they declare.
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+	"golang.org/x/mod/modfile"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"knative.dev/test-infra/pkg/ghutil"
+	"knative.dev/test-infra/pkg/helpers"
+)
+
+// DefaultWorkers is the default number of repos to read the go.mod file of
+// concurrently.
+const DefaultWorkers = 8
+
+// Client is the subset of ghutil.GithubClient used to list repos.
+type Client interface {
+	ListRepositories(org string) ([]*github.Repository, error)
+	GetFileContent(org, repo, ref, path string) ([]byte, error)
+}
+
+// Repo is a GitHub repo and its attributes.
+type Repo struct {
+	// Org is the GitHub org of the repo, ex: "knative".
+	Org string `json:"org" yaml:"org"`
+	// Name is the name of the repo, ex: "serving".
+	Name string `json:"name" yaml:"name"`
+	// Archived is true for archived, read-only, repos.
+	Archived bool `json:"archived" yaml:"archived"`
+	// DefaultBranch is the name of the default branch, ex: "main".
+	DefaultBranch string `json:"defaultBranch" yaml:"defaultBranch"`
+	// Topics are the GitHub topics of the repo.
+	Topics []string `json:"topics,omitempty" yaml:"topics,omitempty"`
+	// GoMod is true if a go.mod file exists at the root of the default branch.
+	// It is only set when the go.mod files were read.
+	GoMod bool `json:"goMod" yaml:"goMod"`
+	// Module is the module path declared by the go.mod file, if any.
+	Module string `json:"module,omitempty" yaml:"module,omitempty"`
+}
+
+// String returns "org/name".
+func (r Repo) String() string {
+	return r.Org + "/" + r.Name
+}
+
+// Read reads the repos written by Write, or printed by `buoy repos --output
+// yaml`. The org, name and default branch of each repo are required.
+func Read(r io.Reader) ([]Repo, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var repos []Repo
+	if err := yaml.UnmarshalStrict(b, &repos); err != nil {
+		return nil, err
+	}
+	for _, repo := range repos {
+		if repo.Org == "" || repo.Name == "" || repo.DefaultBranch == "" {
+			return nil, fmt.Errorf("org, name and defaultBranch are required, got %+v", repo)
+		}
+	}
+	return repos, nil
+}
+
+// Write writes repos as yaml, sorted by "org/name".
+func Write(w io.Writer, repos []Repo) error {
+	sorted := append([]Repo{}, repos...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].String() < sorted[j].String() })
+	b, err := yaml.Marshal(sorted)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// Filter selects repos by their attributes. Zero values do not filter.
+type Filter struct {
+	// Archived selects archived repos if true, and other repos if false.
+	Archived *bool
+	// DefaultBranch selects repos with this default branch.
+	DefaultBranch string
+	// Topics selects repos with all these topics.
+	Topics []string
+	// GoMod selects repos with a go.mod file if true, and without if false.
+	GoMod *bool
+	// ModulePrefix selects repos declaring a go module with this prefix.
+	ModulePrefix string
+}
+
+// NeedsGoMod returns true if the filter selects repos by their go.mod file.
+func (f Filter) NeedsGoMod() bool {
+	return f.GoMod != nil || f.ModulePrefix != ""
+}
+
+// Match returns true if the repo has all the attributes selected by the
+// filter.
+func (f Filter) Match(r Repo) bool {
+	return f.matchRepo(r) && f.matchGoMod(r)
+}
+
+// matchRepo matches the attributes listed with the repo.
+func (f Filter) matchRepo(r Repo) bool {
+	if f.Archived != nil && *f.Archived != r.Archived {
+		return false
+	}
+	if f.DefaultBranch != "" && f.DefaultBranch != r.DefaultBranch {
+		return false
+	}
+	return sets.NewString(r.Topics...).HasAll(f.Topics...)
+}
+
+// matchGoMod matches the attributes read from the go.mod file of the repo.
+func (f Filter) matchGoMod(r Repo) bool {
+	if f.GoMod != nil && *f.GoMod != r.GoMod {
+		return false
+	}
+	return strings.HasPrefix(r.Module, f.ModulePrefix)
+}
+
+// Options configure List.
+type Options struct {
+	// Filter selects the listed repos.
+	Filter Filter
+	// ReadGoMod reads the go.mod file of the listed repos, to set GoMod and
+	// Module. It is implied by a filter on them.
+	ReadGoMod bool
+	// Workers is the number of repos to read the go.mod file of concurrently.
+	// Defaults to DefaultWorkers.
+	Workers int
+}
+
+// List lists the repos of orgs matching the filter of opts, in the order of
+// orgs and then of the repos GitHub lists. Reading go.mod files costs an API
+// call per repo, so they are only read for the repos matching the rest of the
+// filter, and only when needed.
+func List(client Client, orgs []string, opts Options) ([]Repo, error) {
+	repos := make([]Repo, 0)
+	for _, org := range orgs {
+		ghRepos, err := client.ListRepositories(org)
+		if err != nil {
+			return nil, err
+		}
+		for _, gr := range ghRepos {
+			r := Repo{
+				Org:           org,
+				Name:          gr.GetName(),
+				Archived:      gr.GetArchived(),
+				DefaultBranch: gr.GetDefaultBranch(),
+				Topics:        gr.Topics,
+			}
+			if opts.Filter.matchRepo(r) {
+				repos = append(repos, r)
+			}
+		}
+	}
+
+	if !opts.ReadGoMod && !opts.Filter.NeedsGoMod() {
+		return repos, nil
+	}
+	if err := readGoMods(client, repos, opts.Workers); err != nil {
+		return nil, err
+	}
+
+	matching := make([]Repo, 0, len(repos))
+	for _, r := range repos {
+		if opts.Filter.matchGoMod(r) {
+			matching = append(matching, r)
+		}
+	}
+	return matching, nil
+}
+
+// readGoMods reads the go.mod file at the root of the default branch of each
+// repo concurrently, setting GoMod and Module.
+func readGoMods(client Client, repos []Repo, workers int) error {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	errs := make([]error, len(repos))
+	helpers.ForEach(len(repos), workers, func(i int) {
+		errs[i] = readGoMod(client, &repos[i])
+	})
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readGoMod reads the go.mod file of r. A missing go.mod file is not an error.
+func readGoMod(client Client, r *Repo) error {
+	b, err := client.GetFileContent(r.Org, r.Name, r.DefaultBranch, "go.mod")
+	if errors.Is(err, ghutil.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	r.GoMod = true
+	r.Module = modfile.ModulePath(b)
+	return nil
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+
+	"knative.dev/test-infra/pkg/ghutil"
+)
+
+type fakeClient struct {
+	repos map[string][]*github.Repository
+	// files are keyed by "org/repo@ref:path".
+	files map[string]string
+
+	mu    sync.Mutex
+	reads int
+}
+
+func (c *fakeClient) ListRepositories(org string) ([]*github.Repository, error) {
+	repos, ok := c.repos[org]
+	if !ok {
+		return nil, fmt.Errorf("unknown org %s", org)
+	}
+	return repos, nil
+}
+
+func (c *fakeClient) GetFileContent(org, repo, ref, path string) ([]byte, error) {
+	c.mu.Lock()
+	c.reads++
+	c.mu.Unlock()
+	content, ok := c.files[fmt.Sprintf("%s/%s@%s:%s", org, repo, ref, path)]
+	if !ok {
+		return nil, ghutil.ErrNotFound
+	}
+	return []byte(content), nil
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		repos: map[string][]*github.Repository{
+			"knative": {
+				{Name: github.String("serving"), DefaultBranch: github.String("main"), Topics: []string{"knative", "serverless"}},
+				{Name: github.String("docs"), DefaultBranch: github.String("main"), Topics: []string{"knative"}},
+				{Name: github.String("build"), DefaultBranch: github.String("master"), Archived: github.Bool(true)},
+			},
+			"knative-sandbox": {
+				{Name: github.String("sample-controller"), DefaultBranch: github.String("main")},
+			},
+		},
+		files: map[string]string{
+			"knative/serving@main:go.mod":                   "module knative.dev/serving\n\ngo 1.15\n",
+			"knative/build@master:go.mod":                   "module github.com/knative/build\n",
+			"knative-sandbox/sample-controller@main:go.mod": "module knative.dev/sample-controller\n",
+		},
+	}
+}
+
+func TestList(t *testing.T) {
+	yes, no := true, false
+	serving := Repo{Org: "knative", Name: "serving", DefaultBranch: "main", Topics: []string{"knative", "serverless"}}
+	docs := Repo{Org: "knative", Name: "docs", DefaultBranch: "main", Topics: []string{"knative"}}
+	build := Repo{Org: "knative", Name: "build", DefaultBranch: "master", Archived: true}
+	sample := Repo{Org: "knative-sandbox", Name: "sample-controller", DefaultBranch: "main"}
+	withGoMod := func(r Repo, module string) Repo {
+		r.GoMod = true
+		r.Module = module
+		return r
+	}
+
+	tests := map[string]struct {
+		orgs      []string
+		opts      Options
+		want      []Repo
+		wantReads int
+		wantErr   bool
+	}{
+		"all": {
+			orgs: []string{"knative", "knative-sandbox"},
+			want: []Repo{serving, docs, build, sample},
+		},
+		"not archived": {
+			orgs: []string{"knative"},
+			opts: Options{Filter: Filter{Archived: &no}},
+			want: []Repo{serving, docs},
+		},
+		"archived": {
+			orgs: []string{"knative"},
+			opts: Options{Filter: Filter{Archived: &yes}},
+			want: []Repo{build},
+		},
+		"default branch": {
+			orgs: []string{"knative", "knative-sandbox"},
+			opts: Options{Filter: Filter{DefaultBranch: "master"}},
+			want: []Repo{build},
+		},
+		"topics": {
+			orgs: []string{"knative", "knative-sandbox"},
+			opts: Options{Filter: Filter{Topics: []string{"serverless", "knative"}}},
+			want: []Repo{serving},
+		},
+		"read go mod": {
+			orgs:      []string{"knative", "knative-sandbox"},
+			opts:      Options{ReadGoMod: true},
+			want:      []Repo{withGoMod(serving, "knative.dev/serving"), docs, withGoMod(build, "github.com/knative/build"), withGoMod(sample, "knative.dev/sample-controller")},
+			wantReads: 4,
+		},
+		"without go mod": {
+			orgs:      []string{"knative", "knative-sandbox"},
+			opts:      Options{Filter: Filter{GoMod: &no}},
+			want:      []Repo{docs},
+			wantReads: 4,
+		},
+		"module prefix, only reads go mod of matching repos": {
+			orgs:      []string{"knative", "knative-sandbox"},
+			opts:      Options{Filter: Filter{Archived: &no, ModulePrefix: "knative.dev/"}, Workers: 1},
+			want:      []Repo{withGoMod(serving, "knative.dev/serving"), withGoMod(sample, "knative.dev/sample-controller")},
+			wantReads: 3,
+		},
+		"unknown org": {
+			orgs:    []string{"knative", "nope"},
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := newFakeClient()
+			got, err := List(client, tt.orgs, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("List() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Error("List() diff(-want,+got):\n", diff)
+			}
+			if client.reads != tt.wantReads {
+				t.Errorf("List() read %d files, want %d", client.reads, tt.wantReads)
+			}
+		})
+	}
+}
+
+func TestList_GetFileContentError(t *testing.T) {
+	client := &errClient{newFakeClient()}
+	if _, err := List(client, []string{"knative"}, Options{ReadGoMod: true}); !errors.Is(err, errBoom) {
+		t.Errorf("List() error = %v, want %v", err, errBoom)
+	}
+}
+
+var errBoom = errors.New("boom")
+
+type errClient struct {
+	*fakeClient
+}
+
+func (c *errClient) GetFileContent(org, repo, ref, path string) ([]byte, error) {
+	return nil, errBoom
+}
+
+func TestReadWrite(t *testing.T) {
+	repos := []Repo{
+		{Org: "knative", Name: "serving", DefaultBranch: "main", Topics: []string{"knative"}, GoMod: true, Module: "knative.dev/serving"},
+		{Org: "knative-sandbox", Name: "net-istio", DefaultBranch: "master"},
+		{Org: "knative", Name: "build", DefaultBranch: "master", Archived: true},
+	}
+	var b bytes.Buffer
+	if err := Write(&b, repos); err != nil {
+		t.Fatal("Write() =", err)
+	}
+	got, err := Read(&b)
+	if err != nil {
+		t.Fatal("Read() =", err)
+	}
+	want := []Repo{repos[1], repos[2], repos[0]}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("Read() diff(-want,+got):\n", diff)
+	}
+}
+
+func TestRead_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":     "- org: knative\n  name: serving\n  defaultBranch: main\n  branch: main\n",
+		"no default branch": "- org: knative\n  name: serving\n",
+		"no name":           "- org: knative\n  defaultBranch: main\n",
+		"not a list":        "org: knative\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Read(strings.NewReader(content)); err == nil {
+				t.Error("Read() succeeded")
+			}
+		})
+	}
+}
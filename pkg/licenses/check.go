@@ -0,0 +1,363 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package licenses
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// DefaultLicenseDir is the license tree of knative repos, relative to the
+// root of the repo.
+const DefaultLicenseDir = "third_party/VENDOR-LICENSE"
+
+// IssueKind is the kind of a problem found by Check.
+type IssueKind string
+
+const (
+	// NoLicense - a vendored package has no license file in its module.
+	NoLicense IssueKind = "no license"
+	// NotAllowed - a license is not recognized, or not in the allowlist.
+	NotAllowed IssueKind = "not allowed"
+	// Missing - a license is not copied to the license tree.
+	Missing IssueKind = "missing"
+	// Outdated - the copy of a license in the license tree differs from the
+	// vendored license.
+	Outdated IssueKind = "outdated"
+	// Stale - the license tree has a license of code that is not vendored.
+	Stale IssueKind = "stale"
+)
+
+// Library is a directory of vendored code with its own license file, usually
+// the root of a module.
+type Library struct {
+	// Module is the path of the module holding the library.
+	Module string `json:"module" yaml:"module"`
+	// Dir is the import path of the directory holding the license file,
+	// ex: "github.com/davecgh/go-spew".
+	Dir string `json:"dir" yaml:"dir"`
+	// Files are the license and notice files in Dir, license files first.
+	// The first one is the primary license file, which is the one classified
+	// and compared with the license tree.
+	Files []string `json:"files" yaml:"files"`
+	// License is the SPDX identifier of the license, or empty if unknown.
+	License string `json:"license" yaml:"license"`
+
+	// root is the directory Dir is relative to, ex: the vendor directory.
+	root string
+}
+
+// Issue is a problem found by Check.
+type Issue struct {
+	Kind IssueKind `json:"kind" yaml:"kind"`
+	// Path is the import path of the library or package, or the path of the
+	// directory in the license tree for Stale issues.
+	Path   string `json:"path" yaml:"path"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+// Report is the result of Check.
+type Report struct {
+	Libraries []Library `json:"libraries" yaml:"libraries"`
+	Issues    []Issue   `json:"issues" yaml:"issues"`
+}
+
+// Options configures Check and Update.
+type Options struct {
+	// RepoDir is the root of the repo, holding go.mod and vendor/.
+	RepoDir string
+	// LicenseDir is the license tree relative to RepoDir, DefaultLicenseDir if
+	// empty.
+	LicenseDir string
+	// Allowed are the SPDX identifiers of the allowed licenses, DefaultAllowed
+	// if empty.
+	Allowed []string
+}
+
+func (o Options) licenseDir() string {
+	dir := o.LicenseDir
+	if dir == "" {
+		dir = DefaultLicenseDir
+	}
+	return filepath.Join(o.RepoDir, filepath.FromSlash(dir))
+}
+
+func (o Options) allowed() sets.String {
+	if len(o.Allowed) == 0 {
+		return sets.NewString(DefaultAllowed...)
+	}
+	return sets.NewString(o.Allowed...)
+}
+
+// Check finds the license of each package in vendor/modules.txt linked by a
+// main package of the repo, and of the main module, classifies them against the allowlist, and compares them with
+// the license tree. A license of the license tree in a sub directory of a
+// library, ex: "github.com/davecgh/go-spew/spew" for
+// "github.com/davecgh/go-spew", counts as a copy of the license of the
+// library.
+func Check(opts Options) (*Report, error) {
+	libraries, issues, err := findLibraries(opts.RepoDir)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := opts.allowed()
+	for _, lib := range libraries {
+		if !allowed.Has(lib.License) {
+			license := lib.License
+			if license == "" {
+				license = "unknown license"
+			}
+			issues = append(issues, Issue{Kind: NotAllowed, Path: lib.Dir, Detail: fmt.Sprintf("%s in %s", license, lib.Files[0])})
+		}
+	}
+
+	treeIssues, err := compareTree(opts.licenseDir(), libraries)
+	if err != nil {
+		return nil, err
+	}
+	issues = append(issues, treeIssues...)
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Path != issues[j].Path {
+			return issues[i].Path < issues[j].Path
+		}
+		return issues[i].Kind < issues[j].Kind
+	})
+	return &Report{Libraries: libraries, Issues: issues}, nil
+}
+
+// findLibraries finds the library of each vendored package linked by a main
+// package, and the library of the main module. Packages without a license are
+// returned as issues.
+func findLibraries(repoDir string) ([]Library, []Issue, error) {
+	vendorDir := filepath.Join(repoDir, "vendor")
+	modules, err := ReadVendorModules(vendorDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	linked, err := linkedPackages(repoDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var issues []Issue
+	byDir := make(map[string]*Library)
+	for _, m := range modules {
+		noLicense, packages := 0, 0
+		for _, pkg := range m.Packages {
+			if !linked.Has(pkg) {
+				continue
+			}
+			packages++
+			lib, err := findLibrary(vendorDir, m.Path, pkg)
+			if err != nil {
+				return nil, nil, err
+			}
+			if lib == nil {
+				noLicense++
+				continue
+			}
+			if _, ok := byDir[lib.Dir]; !ok {
+				byDir[lib.Dir] = lib
+			}
+		}
+		if noLicense > 0 {
+			issues = append(issues, Issue{Kind: NoLicense, Path: m.Path, Detail: fmt.Sprintf("%d of %d linked packages have no license file", noLicense, packages)})
+		}
+	}
+
+	// The main module is part of the binaries built from the repo too.
+	b, err := ioutil.ReadFile(filepath.Join(repoDir, "go.mod"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if mainModule := modfile.ModulePath(b); mainModule != "" {
+		lib, err := newLibrary(repoDir, "", mainModule, mainModule)
+		if err != nil {
+			return nil, nil, err
+		}
+		if lib != nil {
+			byDir[lib.Dir] = lib
+		}
+	}
+
+	libraries := make([]Library, 0, len(byDir))
+	for _, lib := range byDir {
+		libraries = append(libraries, *lib)
+	}
+	sort.Slice(libraries, func(i, j int) bool { return libraries[i].Dir < libraries[j].Dir })
+	return libraries, issues, nil
+}
+
+// findLibrary returns the library of pkg, which is the closest directory from
+// pkg up to the root of module holding a license file, or nil if there is
+// none.
+func findLibrary(vendorDir, module, pkg string) (*Library, error) {
+	for dir := pkg; ; dir = path.Dir(dir) {
+		lib, err := newLibrary(vendorDir, dir, module, dir)
+		if err != nil || lib != nil {
+			return lib, err
+		}
+		if dir == module || !strings.HasPrefix(dir, module+"/") {
+			return nil, nil
+		}
+	}
+}
+
+// newLibrary returns the library of the directory rel of root, with the
+// import path dir, or nil if rel has no license file.
+func newLibrary(root, rel, module, dir string) (*Library, error) {
+	licenses, notices, err := licenseFiles(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil || len(licenses) == 0 {
+		return nil, err
+	}
+	text, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(rel), licenses[0]))
+	if err != nil {
+		return nil, err
+	}
+	return &Library{
+		Module:  module,
+		Dir:     dir,
+		Files:   append(licenses, notices...),
+		License: Classify(text),
+		root:    filepath.Join(root, filepath.FromSlash(rel)),
+	}, nil
+}
+
+// licenseFiles returns the names of the license and notice files in dir, the
+// primary license files first. A missing dir has none.
+func licenseFiles(dir string) (licenses, notices []string, err error) {
+	infos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, info := range infos {
+		switch {
+		case info.IsDir():
+		case licenseFileRE.MatchString(info.Name()):
+			licenses = append(licenses, info.Name())
+		case noticeFileRE.MatchString(info.Name()):
+			notices = append(notices, info.Name())
+		}
+	}
+	sort.SliceStable(licenses, func(i, j int) bool {
+		return primaryLicenseFileRE.MatchString(licenses[i]) && !primaryLicenseFileRE.MatchString(licenses[j])
+	})
+	return licenses, notices, nil
+}
+
+// compareTree compares the primary license files of the license tree at
+// treeDir with the licenses of libraries.
+func compareTree(treeDir string, libraries []Library) ([]Issue, error) {
+	var issues []Issue
+	copied := sets.NewString()
+	err := filepath.Walk(treeDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == treeDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		licenses, _, err := licenseFiles(p)
+		if err != nil || len(licenses) == 0 {
+			return err
+		}
+
+		rel, err := filepath.Rel(treeDir, p)
+		if err != nil {
+			return err
+		}
+		entry := filepath.ToSlash(rel)
+		lib := libraryOf(libraries, entry)
+		if lib == nil {
+			issues = append(issues, Issue{Kind: Stale, Path: entry, Detail: "not a vendored library"})
+			return nil
+		}
+		copied.Insert(lib.Dir)
+
+		// Only the primary license file is required, other license and
+		// notice files are copied by Update but not compared.
+		name := lib.Files[0]
+		diff, err := compareFile(filepath.Join(lib.root, name), filepath.Join(p, name))
+		if err != nil {
+			return err
+		}
+		if diff != "" {
+			issues = append(issues, Issue{Kind: Outdated, Path: lib.Dir, Detail: fmt.Sprintf("%s %s", path.Join(entry, name), diff)})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, lib := range libraries {
+		if !copied.Has(lib.Dir) {
+			issues = append(issues, Issue{Kind: Missing, Path: lib.Dir, Detail: fmt.Sprintf("%s is not in the license tree", lib.Files[0])})
+		}
+	}
+	return issues, nil
+}
+
+// libraryOf returns the library with the longest Dir that is entry or one of
+// its parents, or nil.
+func libraryOf(libraries []Library, entry string) *Library {
+	var match *Library
+	for i := range libraries {
+		lib := &libraries[i]
+		if (entry == lib.Dir || strings.HasPrefix(entry, lib.Dir+"/")) && (match == nil || len(lib.Dir) > len(match.Dir)) {
+			match = lib
+		}
+	}
+	return match
+}
+
+// compareFile returns why the file at b is not a copy of the file at a, or an
+// empty string if it is.
+func compareFile(a, b string) (string, error) {
+	ab, err := ioutil.ReadFile(a)
+	if err != nil {
+		return "", err
+	}
+	bb, err := ioutil.ReadFile(b)
+	if os.IsNotExist(err) {
+		return "is missing from the license tree", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !bytes.Equal(ab, bb) {
+		return "differs from the vendored copy", nil
+	}
+	return "", nil
+}
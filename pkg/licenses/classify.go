@@ -0,0 +1,80 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package licenses
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultAllowed are the SPDX identifiers of the licenses allowed by default.
+var DefaultAllowed = []string{"Apache-2.0", "BSD-2-Clause", "BSD-3-Clause", "ISC", "MIT", "MPL-2.0"}
+
+// reciprocal are the licenses requiring the source code to be redistributed
+// along with the license, which is copied to the license tree too.
+var reciprocal = map[string]bool{
+	"MPL-2.0": true,
+}
+
+// licenseFileRE matches the names of license files.
+var licenseFileRE = regexp.MustCompile(`(?i)^(LICEN[CS]E|COPYING)([-._][A-Za-z0-9]+)*(\.(txt|md))?$`)
+
+// primaryLicenseFileRE matches the names of the license files that hold the
+// license of a library, as opposed to variants such as LICENSE.docs or
+// LICENSE-THIRD-PARTY.
+var primaryLicenseFileRE = regexp.MustCompile(`(?i)^(LICEN[CS]E|COPYING)(\.(txt|md))?$`)
+
+// noticeFileRE matches the names of files that are copied along with license
+// files.
+var noticeFileRE = regexp.MustCompile(`(?i)^NOTICE(\.(txt|md))?$`)
+
+// licenseRule identifies a license by phrases found in its normalized text.
+type licenseRule struct {
+	id  string
+	all []string
+}
+
+// licenseRules are checked in order, so more specific licenses come first.
+var licenseRules = []licenseRule{
+	{"MPL-2.0", []string{"mozilla public license", "version 2.0"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"ISC", []string{"permission to use, copy, modify, and", "distribute this software for any purpose with or without fee is hereby granted"}},
+	{"MIT", []string{"permission is hereby granted, free of charge, to any person obtaining a copy"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name of"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "names of its contributors may be used to endorse"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+}
+
+// Classify returns the SPDX identifier of a license text, ex: "Apache-2.0",
+// or an empty string if the license is not recognized.
+func Classify(text []byte) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(string(text))), " ")
+	for _, rule := range licenseRules {
+		matched := true
+		for _, phrase := range rule.all {
+			if !strings.Contains(normalized, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return rule.id
+		}
+	}
+	return ""
+}
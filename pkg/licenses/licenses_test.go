@@ -0,0 +1,276 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package licenses
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParseModulesTxt(t *testing.T) {
+	tests := map[string]struct {
+		contents string
+		want     []Module
+		wantErr  bool
+	}{
+		"modules": {
+			contents: `# github.com/a/mit v1.0.0
+## explicit
+github.com/a/mit
+github.com/a/mit/sub
+# github.com/f/unused v1.0.0
+## explicit
+# github.com/e/nested v1.0.0 => github.com/e/fork v1.0.1
+github.com/e/nested/pkg
+# github.com/g/local => ../local
+github.com/g/local
+`,
+			want: []Module{
+				{Path: "github.com/a/mit", Version: "v1.0.0", Packages: []string{"github.com/a/mit", "github.com/a/mit/sub"}},
+				{Path: "github.com/e/nested", Version: "v1.0.0", Packages: []string{"github.com/e/nested/pkg"}},
+				{Path: "github.com/g/local", Packages: []string{"github.com/g/local"}},
+			},
+		},
+		"empty": {
+			contents: "",
+		},
+		"package without module": {
+			contents: "github.com/a/mit\n",
+			wantErr:  true,
+		},
+		"module without path": {
+			contents: "# \n",
+			wantErr:  true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseModulesTxt([]byte(tt.contents))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseModulesTxt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Error("parseModulesTxt() diff(-want,+got):\n", diff)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := map[string]struct {
+		path string
+		want string
+	}{
+		"apache": {path: "testdata/repo/LICENSE", want: "Apache-2.0"},
+		"mit":    {path: "testdata/repo/vendor/github.com/a/mit/LICENSE", want: "MIT"},
+		"mpl":    {path: "testdata/repo/vendor/github.com/b/mpl/LICENSE", want: "MPL-2.0"},
+		"gpl":    {path: "testdata/repo/vendor/github.com/c/gpl/COPYING", want: ""},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			text, err := ioutil.ReadFile(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Classify(text); got != tt.want {
+				t.Errorf("Classify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassify_Text(t *testing.T) {
+	tests := map[string]struct {
+		text string
+		want string
+	}{
+		"bsd-3-clause": {
+			text: `Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+    * Neither the name of Google Inc. nor the names of its contributors`,
+			want: "BSD-3-Clause",
+		},
+		"bsd-2-clause": {
+			text: `Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:`,
+			want: "BSD-2-Clause",
+		},
+		"isc": {
+			text: `Permission to use, copy, modify, and distribute this software for any
+purpose with or without fee is hereby granted`,
+			want: "ISC",
+		},
+		"unknown": {
+			text: "All rights reserved.",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := Classify([]byte(tt.text)); got != tt.want {
+				t.Errorf("Classify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+var wantLibraries = []Library{
+	{Module: "example.com/app", Dir: "example.com/app", Files: []string{"LICENSE"}, License: "Apache-2.0"},
+	{Module: "github.com/a/mit", Dir: "github.com/a/mit", Files: []string{"LICENSE", "COPYING.docs"}, License: "MIT"},
+	{Module: "github.com/b/mpl", Dir: "github.com/b/mpl", Files: []string{"LICENSE"}, License: "MPL-2.0"},
+	{Module: "github.com/c/gpl", Dir: "github.com/c/gpl", Files: []string{"COPYING"}},
+	{Module: "github.com/e/nested", Dir: "github.com/e/nested/pkg", Files: []string{"LICENSE.txt", "NOTICE"}, License: "Apache-2.0"},
+}
+
+func TestCheck(t *testing.T) {
+	tests := map[string]struct {
+		opts       Options
+		wantIssues []Issue
+	}{
+		"default allowlist": {
+			opts: Options{RepoDir: "testdata/repo"},
+			wantIssues: []Issue{
+				{Kind: Missing, Path: "example.com/app", Detail: "LICENSE is not in the license tree"},
+				{Kind: Outdated, Path: "github.com/b/mpl", Detail: "github.com/b/mpl/LICENSE differs from the vendored copy"},
+				{Kind: Missing, Path: "github.com/c/gpl", Detail: "COPYING is not in the license tree"},
+				{Kind: NotAllowed, Path: "github.com/c/gpl", Detail: "unknown license in COPYING"},
+				{Kind: NoLicense, Path: "github.com/d/nolicense", Detail: "1 of 1 linked packages have no license file"},
+				{Kind: Stale, Path: "github.com/z/gone", Detail: "not a vendored library"},
+			},
+		},
+		"allowlist": {
+			opts: Options{RepoDir: "testdata/repo", Allowed: []string{"Apache-2.0", "MIT"}},
+			wantIssues: []Issue{
+				{Kind: Missing, Path: "example.com/app", Detail: "LICENSE is not in the license tree"},
+				{Kind: NotAllowed, Path: "github.com/b/mpl", Detail: "MPL-2.0 in LICENSE"},
+				{Kind: Outdated, Path: "github.com/b/mpl", Detail: "github.com/b/mpl/LICENSE differs from the vendored copy"},
+				{Kind: Missing, Path: "github.com/c/gpl", Detail: "COPYING is not in the license tree"},
+				{Kind: NotAllowed, Path: "github.com/c/gpl", Detail: "unknown license in COPYING"},
+				{Kind: NoLicense, Path: "github.com/d/nolicense", Detail: "1 of 1 linked packages have no license file"},
+				{Kind: Stale, Path: "github.com/z/gone", Detail: "not a vendored library"},
+			},
+		},
+		"no license tree": {
+			opts: Options{RepoDir: "testdata/repo", LicenseDir: "third_party/NOPE"},
+			wantIssues: []Issue{
+				{Kind: Missing, Path: "example.com/app", Detail: "LICENSE is not in the license tree"},
+				{Kind: Missing, Path: "github.com/a/mit", Detail: "LICENSE is not in the license tree"},
+				{Kind: Missing, Path: "github.com/b/mpl", Detail: "LICENSE is not in the license tree"},
+				{Kind: Missing, Path: "github.com/c/gpl", Detail: "COPYING is not in the license tree"},
+				{Kind: NotAllowed, Path: "github.com/c/gpl", Detail: "unknown license in COPYING"},
+				{Kind: NoLicense, Path: "github.com/d/nolicense", Detail: "1 of 1 linked packages have no license file"},
+				{Kind: Missing, Path: "github.com/e/nested/pkg", Detail: "LICENSE.txt is not in the license tree"},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Check(tt.opts)
+			if err != nil {
+				t.Fatal("Check() =", err)
+			}
+			if diff := cmp.Diff(wantLibraries, got.Libraries, cmpopts.IgnoreUnexported(Library{})); diff != "" {
+				t.Error("Check() libraries diff(-want,+got):\n", diff)
+			}
+			if diff := cmp.Diff(tt.wantIssues, got.Issues); diff != "" {
+				t.Error("Check() issues diff(-want,+got):\n", diff)
+			}
+		})
+	}
+}
+
+func TestUpdate_LicenseDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "licenses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := copyTree("testdata/repo", dir); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, licenseDir := range []string{".", "/", "..", "../third_party", "third_party/.."} {
+		t.Run(licenseDir, func(t *testing.T) {
+			if _, err := Update(Options{RepoDir: dir, LicenseDir: licenseDir}); err == nil {
+				t.Error("Update() expected an error for a license tree outside of the repo")
+			}
+			if _, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil {
+				t.Fatal("Update() removed the repo:", err)
+			}
+		})
+	}
+}
+
+func TestCheck_NoVendor(t *testing.T) {
+	if _, err := Check(Options{RepoDir: "testdata"}); err == nil {
+		t.Error("Check() expected an error without vendor/modules.txt")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "licenses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := copyTree("testdata/repo", dir); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Update(Options{RepoDir: dir})
+	if err != nil {
+		t.Fatal("Update() =", err)
+	}
+	want := []Issue{
+		{Kind: NotAllowed, Path: "github.com/c/gpl", Detail: "unknown license in COPYING"},
+		{Kind: NoLicense, Path: "github.com/d/nolicense", Detail: "1 of 1 linked packages have no license file"},
+	}
+	if diff := cmp.Diff(want, got.Issues); diff != "" {
+		t.Error("Update() issues diff(-want,+got):\n", diff)
+	}
+
+	tree := filepath.Join(dir, DefaultLicenseDir)
+	for _, f := range []string{
+		"example.com/app/LICENSE",
+		"github.com/a/mit/LICENSE",
+		"github.com/a/mit/COPYING.docs",
+		"github.com/b/mpl/LICENSE",
+		"github.com/b/mpl/mpl.go",
+		"github.com/c/gpl/COPYING",
+		"github.com/e/nested/pkg/LICENSE.txt",
+		"github.com/e/nested/pkg/NOTICE",
+	} {
+		if _, err := os.Stat(filepath.Join(tree, f)); err != nil {
+			t.Error("Update() did not copy", f)
+		}
+	}
+	for _, f := range []string{
+		"github.com/a/mit/mit.go",
+		"github.com/a/mit/sub/LICENSE",
+		"github.com/z/gone/LICENSE",
+		"github.com/h/testonly/COPYING",
+		"example.com/app/go.mod",
+	} {
+		if _, err := os.Stat(filepath.Join(tree, f)); !os.IsNotExist(err) {
+			t.Error("Update() should not have", f)
+		}
+	}
+}
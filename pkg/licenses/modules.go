@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package licenses checks that the licenses of vendored go modules are
+// allowed, and that they are copied to a license tree such as
+// third_party/VENDOR-LICENSE.
+package licenses
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Module is a module listed in vendor/modules.txt, with its vendored
+// packages.
+type Module struct {
+	Path     string
+	Version  string
+	Packages []string
+}
+
+// ReadVendorModules reads the modules listed in modules.txt of vendorDir.
+// Modules without vendored packages are omitted.
+func ReadVendorModules(vendorDir string) ([]Module, error) {
+	b, err := ioutil.ReadFile(filepath.Join(vendorDir, "modules.txt"))
+	if err != nil {
+		return nil, err
+	}
+	return parseModulesTxt(b)
+}
+
+// parseModulesTxt parses the contents of vendor/modules.txt, which lists each
+// module as "# path version [=> replacement [version]]", followed by its
+// packages, one per line. Lines starting with "##" are annotations.
+func parseModulesTxt(b []byte) ([]Module, error) {
+	var modules []Module
+	var current *Module
+	flush := func() {
+		if current != nil && len(current.Packages) > 0 {
+			modules = append(modules, *current)
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "", strings.HasPrefix(line, "##"):
+		case strings.HasPrefix(line, "# "):
+			flush()
+			f := strings.Fields(strings.TrimPrefix(line, "# "))
+			if len(f) == 0 {
+				return nil, fmt.Errorf("line %d: missing module path", n)
+			}
+			current = &Module{Path: f[0]}
+			if len(f) > 1 && f[1] != "=>" {
+				current.Version = f[1]
+			}
+		default:
+			if current == nil {
+				return nil, fmt.Errorf("line %d: package %s is not part of a module", n, line)
+			}
+			current.Packages = append(current.Packages, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return modules, nil
+}
+
+// linkedPackages returns the import paths of the packages linked into the
+// binaries of the main packages of the module at repoDir, the same way
+// "go list -deps" does. Packages only imported by tests or by libraries of the
+// module are not linked.
+func linkedPackages(repoDir string) (sets.String, error) {
+	cmd := exec.Command("go", "list", "-mod=vendor", "-f", `{{if eq .Name "main"}}{{join .Deps "\n"}}{{end}}`, "./...")
+	cmd.Dir = repoDir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the packages of the main packages in %s: %w: %s", repoDir, err, strings.TrimSpace(stderr.String()))
+	}
+	return sets.NewString(strings.Fields(string(out))...), nil
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	_ "github.com/a/mit"
+	_ "github.com/b/mpl"
+	_ "github.com/c/gpl"
+	_ "github.com/d/nolicense"
+	_ "github.com/e/nested/pkg"
+)
+
+func main() {}
@@ -0,0 +1,15 @@
+module example.com/app
+
+go 1.15
+
+require (
+	github.com/a/mit v1.0.0
+	github.com/b/mpl v1.2.0
+	github.com/c/gpl v0.1.0
+	github.com/d/nolicense v0.0.0-20201110123000-0123456789ab
+	github.com/e/nested v1.0.0
+	github.com/f/unused v1.0.0
+	github.com/h/testonly v1.0.0
+)
+
+replace github.com/e/nested => github.com/e/fork v1.0.1
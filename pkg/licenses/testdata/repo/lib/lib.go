@@ -0,0 +1,7 @@
+// Package lib is not linked by any main package.
+package lib
+
+import (
+	_ "github.com/a/mit/sub"
+	_ "github.com/h/testonly"
+)
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package licenses
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Update regenerates the license tree from the vendored licenses, and then
+// checks it. The license and notice files of each library are copied to the
+// directory of its import path in the tree. For reciprocal licenses, such as
+// MPL-2.0, the vendored source of the library is copied too. The license tree
+// is removed first, so it has to be a sub directory of the repo.
+func Update(opts Options) (*Report, error) {
+	treeDir := opts.licenseDir()
+	if err := checkTreeDir(opts.RepoDir, treeDir); err != nil {
+		return nil, err
+	}
+	libraries, _, err := findLibraries(opts.RepoDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.RemoveAll(treeDir); err != nil {
+		return nil, err
+	}
+	for _, lib := range libraries {
+		dst := filepath.Join(treeDir, filepath.FromSlash(lib.Dir))
+		if reciprocal[lib.License] && lib.root != filepath.Clean(opts.RepoDir) {
+			if err := copyTree(lib.root, dst); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for _, name := range lib.Files {
+			if err := copyFile(filepath.Join(lib.root, name), filepath.Join(dst, name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return Check(opts)
+}
+
+// checkTreeDir returns an error unless treeDir is strictly below repoDir, so
+// that regenerating the license tree never removes the repo, or anything
+// outside of it.
+func checkTreeDir(repoDir, treeDir string) error {
+	repo, err := filepath.Abs(repoDir)
+	if err != nil {
+		return err
+	}
+	tree, err := filepath.Abs(treeDir)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(repo, tree)
+	if err != nil {
+		return err
+	}
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("the license tree %s is not a sub directory of the repo %s", treeDir, repoDir)
+	}
+	return nil
+}
+
+// copyTree copies the regular files under src to dst.
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		return copyFile(p, filepath.Join(dst, rel))
+	})
+}
+
+func copyFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
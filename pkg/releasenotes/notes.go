@@ -0,0 +1,229 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package releasenotes generates the release notes of a repo from the
+// release-note blocks and kind labels of the PRs merged between two refs.
+package releasenotes
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/google/go-github/v32/github"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"knative.dev/test-infra/pkg/git"
+)
+
+// PullRequestLister lists the PRs containing a commit, ex:
+// ghutil.GithubClient.
+type PullRequestLister interface {
+	ListPullRequestsByCommitID(org, repo, commitID string) ([]*github.PullRequest, error)
+}
+
+// Note is the release note of a single PR.
+type Note struct {
+	// PR is the number of the PR.
+	PR int `json:"pr" yaml:"pr"`
+	// URL is the URL of the PR.
+	URL string `json:"url" yaml:"url"`
+	// Author is the GitHub login of the author of the PR.
+	Author string `json:"author" yaml:"author"`
+	// Kind is the kind label of the PR, ex: "kind/bug", or empty.
+	Kind string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	// Text is the content of the release-note block of the PR.
+	Text string `json:"text" yaml:"text"`
+}
+
+// Notes are the release notes of a range of commits.
+type Notes struct {
+	// From and To are the revisions of the range.
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+	// Notes are the release notes, ordered by PR number.
+	Notes []Note `json:"notes" yaml:"notes"`
+	// WithoutNote are the PRs without a release note, or with a release note
+	// of "NONE".
+	WithoutNote []int `json:"withoutNote,omitempty" yaml:"withoutNote,omitempty"`
+	// WithoutPR are the commits that were not merged by a PR.
+	WithoutPR []string `json:"withoutPR,omitempty" yaml:"withoutPR,omitempty"`
+	// Failed are the commits whose PR could not be looked up.
+	Failed []string `json:"failed,omitempty" yaml:"failed,omitempty"`
+}
+
+// kinds are the kind labels release notes are grouped by, in the order of
+// the groups.
+var kinds = []struct {
+	label string
+	title string
+}{
+	{"kind/api-change", "API Changes"},
+	{"kind/feature", "Features"},
+	{"kind/bug", "Bug Fixes"},
+	{"kind/cleanup", "Cleanups"},
+	{"kind/documentation", "Documentation"},
+}
+
+// otherTitle is the title of the group of release notes of PRs without one of
+// kinds.
+const otherTitle = "Other Changes"
+
+// ReleaseRange returns the default range of the release notes of release in
+// repo: the tag of the previous release and the release branch, ex: "v0.18.0"
+// and "release-0.19" for "0.19", or "v0.19.0" and "release-0.19" for
+// "0.19.1". The previous release is picked from the tags of repo, see
+// git.Repo.PreviousRelease. The tag is empty if there is no previous release.
+func ReleaseRange(repo *git.Repo, release string) (from, to string, err error) {
+	this, err := semver.ParseTolerant(release)
+	if err != nil {
+		return "", "", err
+	}
+	to = git.ReleaseBranchVersion(this)
+	from, _ = repo.PreviousRelease(this)
+	return from, to, nil
+}
+
+// Collect maps each commit to the PR that merged it, and extracts the release
+// note and kind label of each PR. Commits merged by the same PR are collapsed.
+// Commits without a merged PR are listed in WithoutPR. Commits whose PRs can't
+// be listed are listed in Failed, and an error is returned along with the
+// notes of the other commits.
+func Collect(gh PullRequestLister, org, repo string, commits []git.Commit) (*Notes, error) {
+	notes := &Notes{Notes: make([]Note, 0)}
+	seen := make(map[int]bool)
+	errs := make([]error, 0)
+	for _, c := range commits {
+		prs, err := gh.ListPullRequestsByCommitID(org, repo, c.Hash)
+		if err != nil {
+			notes.Failed = append(notes.Failed, c.Hash)
+			errs = append(errs, fmt.Errorf("unable to find the PR of %s in %s/%s: %w", c.Hash, org, repo, err))
+			continue
+		}
+		pr := mergedPR(prs)
+		if pr == nil {
+			notes.WithoutPR = append(notes.WithoutPR, c.Hash)
+			continue
+		}
+		if seen[pr.GetNumber()] {
+			continue
+		}
+		seen[pr.GetNumber()] = true
+
+		text, ok := ExtractReleaseNote(pr.GetBody())
+		if !ok {
+			notes.WithoutNote = append(notes.WithoutNote, pr.GetNumber())
+			continue
+		}
+		notes.Notes = append(notes.Notes, Note{
+			PR:     pr.GetNumber(),
+			URL:    pr.GetHTMLURL(),
+			Author: pr.GetUser().GetLogin(),
+			Kind:   kind(pr.Labels),
+			Text:   text,
+		})
+	}
+	sort.Slice(notes.Notes, func(i, j int) bool { return notes.Notes[i].PR < notes.Notes[j].PR })
+	sort.Ints(notes.WithoutNote)
+	return notes, utilerrors.NewAggregate(errs)
+}
+
+// mergedPR returns the first merged PR of prs, or nil if none was merged.
+func mergedPR(prs []*github.PullRequest) *github.PullRequest {
+	for _, pr := range prs {
+		if pr.MergedAt != nil {
+			return pr
+		}
+	}
+	return nil
+}
+
+// releaseNoteRE matches a fenced release-note block.
+var releaseNoteRE = regexp.MustCompile("(?s)```release-note\\s*\\r?\\n(.*?)\\r?\\n?```")
+
+// ExtractReleaseNote returns the content of the release-note block of a PR
+// body. Returns false if there is no block, or if it is empty or "NONE".
+func ExtractReleaseNote(body string) (string, bool) {
+	m := releaseNoteRE.FindStringSubmatch(body)
+	if m == nil {
+		return "", false
+	}
+	text := strings.TrimSpace(strings.ReplaceAll(m[1], "\r\n", "\n"))
+	if text == "" || strings.EqualFold(text, "NONE") {
+		return "", false
+	}
+	return text, true
+}
+
+// kind returns the first of kinds found in labels, or the first kind label
+// if none of them is found.
+func kind(labels []*github.Label) string {
+	names := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		names[l.GetName()] = true
+	}
+	for _, k := range kinds {
+		if names[k.label] {
+			return k.label
+		}
+	}
+	for _, l := range labels {
+		if strings.HasPrefix(l.GetName(), "kind/") {
+			return l.GetName()
+		}
+	}
+	return ""
+}
+
+// WriteMarkdown writes the release notes as markdown, grouped by kind.
+func (n *Notes) WriteMarkdown(out io.Writer) error {
+	var b strings.Builder
+	groups := make(map[string][]Note)
+	for _, note := range n.Notes {
+		groups[groupTitle(note.Kind)] = append(groups[groupTitle(note.Kind)], note)
+	}
+
+	titles := make([]string, 0, len(kinds)+1)
+	for _, k := range kinds {
+		titles = append(titles, k.title)
+	}
+	titles = append(titles, otherTitle)
+	for _, title := range titles {
+		if len(groups[title]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "## %s\n\n", title)
+		for _, note := range groups[title] {
+			text := strings.ReplaceAll(note.Text, "\n", "\n  ")
+			fmt.Fprintf(&b, "- %s ([#%d](%s), @%s)\n", text, note.PR, note.URL, note.Author)
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func groupTitle(kind string) string {
+	for _, k := range kinds {
+		if k.label == kind {
+			return k.title
+		}
+	}
+	return otherTitle
+}
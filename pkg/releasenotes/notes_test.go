@@ -0,0 +1,245 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package releasenotes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+
+	"knative.dev/test-infra/pkg/git"
+)
+
+// fakeLister maps commit hashes to PRs. Listing the PRs of a commit mapped to
+// nil fails.
+type fakeLister map[string]*github.PullRequest
+
+func (f fakeLister) ListPullRequestsByCommitID(org, repo, commitID string) ([]*github.PullRequest, error) {
+	pr, ok := f[commitID]
+	switch {
+	case !ok:
+		return nil, nil
+	case pr == nil:
+		return nil, errors.New("API rate limit exceeded")
+	}
+	return []*github.PullRequest{pr}, nil
+}
+
+func pr(number int, author, body string, labels ...string) *github.PullRequest {
+	merged := time.Now()
+	p := &github.PullRequest{
+		Number:   github.Int(number),
+		HTMLURL:  github.String(fmt.Sprintf("https://github.com/knative/serving/pull/%d", number)),
+		Body:     github.String(body),
+		User:     &github.User{Login: github.String(author)},
+		MergedAt: &merged,
+	}
+	for _, l := range labels {
+		p.Labels = append(p.Labels, &github.Label{Name: github.String(l)})
+	}
+	return p
+}
+
+func TestExtractReleaseNote(t *testing.T) {
+	tests := map[string]struct {
+		body   string
+		want   string
+		wantOK bool
+	}{
+		"note": {
+			body:   "Fixes #1\n\n```release-note\nAdd a flag\n```\n",
+			want:   "Add a flag",
+			wantOK: true,
+		},
+		"multi-line note with CRLF": {
+			body:   "```release-note\r\nFirst line\r\nSecond line\r\n```",
+			want:   "First line\nSecond line",
+			wantOK: true,
+		},
+		"none": {
+			body: "```release-note\nNONE\n```",
+		},
+		"empty": {
+			body: "```release-note\n\n```",
+		},
+		"no block": {
+			body: "Just a description",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := ExtractReleaseNote(tt.body)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ExtractReleaseNote() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestReleaseRange(t *testing.T) {
+	repo := &git.Repo{
+		Tags:     []string{"v0.17.0", "v0.18.0", "v0.18.1", "v0.19.0", "v0.19.1", "v0.20.0", "schema/v0.1.0"},
+		Branches: []string{"master", "release-0.18", "release-0.19", "release-0.20"},
+	}
+	tests := map[string]struct {
+		repo     *git.Repo
+		release  string
+		wantFrom string
+		wantTo   string
+		wantErr  bool
+	}{
+		"minor": {
+			repo:     repo,
+			release:  "0.19",
+			wantFrom: "v0.18.0",
+			wantTo:   "release-0.19",
+		},
+		"first patch": {
+			repo:     repo,
+			release:  "v0.20.1",
+			wantFrom: "v0.20.0",
+			wantTo:   "release-0.20",
+		},
+		"patch": {
+			repo:     repo,
+			release:  "0.19.2",
+			wantFrom: "v0.19.1",
+			wantTo:   "release-0.19",
+		},
+		"skipped minor": {
+			repo:     &git.Repo{Tags: []string{"v0.17.0", "v0.17.1"}},
+			release:  "0.19",
+			wantFrom: "v0.17.0",
+			wantTo:   "release-0.19",
+		},
+		"nested module": {
+			repo:     &git.Repo{Dir: "schema", Tags: repo.Tags},
+			release:  "0.2",
+			wantFrom: "schema/v0.1.0",
+			wantTo:   "release-0.2",
+		},
+		"first release": {
+			repo:    repo,
+			release: "0.17",
+			wantTo:  "release-0.17",
+		},
+		"invalid": {
+			repo:    repo,
+			release: "latest",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			from, to, err := ReleaseRange(tt.repo, tt.release)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReleaseRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if from != tt.wantFrom || to != tt.wantTo {
+				t.Errorf("ReleaseRange() = %q, %q, want %q, %q", from, to, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	gh := fakeLister{
+		"a": pr(3, "alice", "```release-note\nFix a crash\n```", "kind/bug"),
+		"b": pr(1, "bob", "```release-note\nNew API field\n```", "kind/feature", "kind/api-change"),
+		"c": pr(2, "carol", "```release-note\nNONE\n```", "kind/cleanup"),
+		"d": pr(3, "alice", "```release-note\nFix a crash\n```", "kind/bug"),
+		"e": pr(4, "dave", "```release-note\nFaster\n```", "kind/perf"),
+	}
+	unmerged := pr(5, "erin", "```release-note\nUnmerged\n```")
+	unmerged.MergedAt = nil
+	gh["f"] = unmerged
+
+	commits := []git.Commit{{Hash: "a"}, {Hash: "b"}, {Hash: "c"}, {Hash: "d"}, {Hash: "e"}, {Hash: "f"}, {Hash: "g"}}
+	got, err := Collect(gh, "knative", "serving", commits)
+	if err != nil {
+		t.Fatal("Collect() =", err)
+	}
+	want := &Notes{
+		Notes: []Note{
+			{PR: 1, URL: gh["b"].GetHTMLURL(), Author: "bob", Kind: "kind/api-change", Text: "New API field"},
+			{PR: 3, URL: gh["a"].GetHTMLURL(), Author: "alice", Kind: "kind/bug", Text: "Fix a crash"},
+			{PR: 4, URL: gh["e"].GetHTMLURL(), Author: "dave", Kind: "kind/perf", Text: "Faster"},
+		},
+		WithoutNote: []int{2},
+		WithoutPR:   []string{"f", "g"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("Collect() diff(-want,+got):\n", diff)
+	}
+
+	// Failed lookups are reported, not counted as commits without a PR.
+	gh["b"], gh["e"] = nil, nil
+	got, err = Collect(gh, "knative", "serving", commits)
+	if err == nil {
+		t.Error("Collect() expected an error when a lookup fails")
+	}
+	want = &Notes{
+		Notes: []Note{
+			{PR: 3, URL: gh["a"].GetHTMLURL(), Author: "alice", Kind: "kind/bug", Text: "Fix a crash"},
+		},
+		WithoutNote: []int{2},
+		WithoutPR:   []string{"f", "g"},
+		Failed:      []string{"b", "e"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("Collect() with failures diff(-want,+got):\n", diff)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	notes := &Notes{Notes: []Note{
+		{PR: 1, URL: "https://example.com/1", Author: "bob", Kind: "kind/feature", Text: "A feature"},
+		{PR: 2, URL: "https://example.com/2", Author: "alice", Kind: "kind/bug", Text: "A fix\nwith details"},
+		{PR: 3, URL: "https://example.com/3", Author: "carol", Text: "Something else"},
+		{PR: 4, URL: "https://example.com/4", Author: "dave", Kind: "kind/api-change", Text: "An API change"},
+	}}
+	want := `## API Changes
+
+- An API change ([#4](https://example.com/4), @dave)
+
+## Features
+
+- A feature ([#1](https://example.com/1), @bob)
+
+## Bug Fixes
+
+- A fix
+  with details ([#2](https://example.com/2), @alice)
+
+## Other Changes
+
+- Something else ([#3](https://example.com/3), @carol)
+
+`
+	var buf bytes.Buffer
+	if err := notes.WriteMarkdown(&buf); err != nil {
+		t.Fatal("WriteMarkdown() =", err)
+	}
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Error("WriteMarkdown() diff(-want,+got):\n", diff)
+	}
+}
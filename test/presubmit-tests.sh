@@ -34,6 +34,8 @@ function post_build_tests() {
     echo "*** Checking ${makefile}"
     make -n -C $(dirname "${makefile}") || { failed=1; echo "--- FAIL: ${makefile}"; }
   done
+  subheader "Validating Prow config"
+  go run ./tools/config-generator --validate config/prod/prow/config_knative.yaml || failed=1
   return ${failed}
 }
 
@@ -0,0 +1,216 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// orderFileName is the name of the file, at the root of a config directory,
+// that lists the repositories of each section in order, with no jobs. It is
+// read before the other files, so the repositories are merged in that order.
+const orderFileName = "order.yaml"
+
+// configFile is an input config file.
+type configFile struct {
+	Name    string
+	Content []byte
+}
+
+// readConfigFiles reads the config at the given path. If it is a directory,
+// all the yaml files in it and its subdirectories are read, in the order of
+// configFilePaths.
+func readConfigFiles(path string) ([]configFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %q: %w", path, err)
+	}
+	paths := []string{path}
+	if info.IsDir() {
+		if paths, err = configFilePaths(path); err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no yaml files in %q", path)
+		}
+	}
+	files := make([]configFile, 0, len(paths))
+	for _, p := range paths {
+		content, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read file %q: %w", p, err)
+		}
+		files = append(files, configFile{Name: p, Content: content})
+	}
+	return files, nil
+}
+
+// configFilePaths returns the paths of the yaml files in the given directory
+// and its subdirectories, the order file first if any, then the others in the
+// lexical order of filepath.Walk.
+func configFilePaths(dir string) ([]string, error) {
+	var paths []string
+	orderFile := filepath.Join(dir, orderFileName)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !(strings.HasSuffix(p, ".yaml") || strings.HasSuffix(p, ".yml")) {
+			return nil
+		}
+		if p == orderFile {
+			paths = append([]string{p}, paths...)
+		} else {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the config files in %q: %w", dir, err)
+	}
+	return paths, nil
+}
+
+// parseConfigFiles parses and merges the given config files.
+func parseConfigFiles(files []configFile) (yaml.MapSlice, error) {
+	configs := make([]yaml.MapSlice, 0, len(files))
+	for _, f := range files {
+		var config yaml.MapSlice
+		if err := yaml.Unmarshal(f.Content, &config); err != nil {
+			return nil, fmt.Errorf("cannot parse config %q: %w", f.Name, err)
+		}
+		configs = append(configs, config)
+	}
+	return mergeConfigs(configs)
+}
+
+// mergeConfigs merges the sections of the given configs. The jobs of a
+// repository present in several configs are concatenated, in the order of the
+// configs. Sections and repositories are kept in order of first appearance,
+// so the output only depends on the order of the configs.
+func mergeConfigs(configs []yaml.MapSlice) (yaml.MapSlice, error) {
+	var merged yaml.MapSlice
+	sections := make(map[interface{}]int)
+	repos := make(map[interface{}]map[interface{}]int)
+	for _, config := range configs {
+		for _, section := range config {
+			repoConfigs, ok := section.Value.(yaml.MapSlice)
+			if !ok {
+				return nil, fmt.Errorf("section %q is expected to be a map of repositories", section.Key)
+			}
+			i, ok := sections[section.Key]
+			if !ok {
+				i = len(merged)
+				sections[section.Key] = i
+				repos[section.Key] = make(map[interface{}]int)
+				merged = append(merged, yaml.MapItem{Key: section.Key, Value: yaml.MapSlice{}})
+			}
+			mergedRepos := merged[i].Value.(yaml.MapSlice)
+			for _, repo := range repoConfigs {
+				jobs, ok := repo.Value.([]interface{})
+				if !ok {
+					return nil, fmt.Errorf("%s of %q are expected to be a list of jobs", section.Key, repo.Key)
+				}
+				if j, ok := repos[section.Key][repo.Key]; ok {
+					mergedRepos[j].Value = append(mergedRepos[j].Value.([]interface{}), jobs...)
+					continue
+				}
+				repos[section.Key][repo.Key] = len(mergedRepos)
+				mergedRepos = append(mergedRepos, yaml.MapItem{Key: repo.Key, Value: append([]interface{}{}, jobs...)})
+			}
+			merged[i].Value = mergedRepos
+		}
+	}
+	return merged, nil
+}
+
+// splitConfig splits the given config file into one file per repository,
+// written as <org>/<repo>.yaml in the given directory, with the sections of
+// each repository in the same order as in the config file. The order of the
+// repositories in each section is written to the order file, so the configs
+// generated from the directory are the same as from the config file.
+func splitConfig(fileName, dir string) error {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("cannot read file %q: %w", fileName, err)
+	}
+	var config yaml.MapSlice
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return fmt.Errorf("cannot parse config %q: %w", fileName, err)
+	}
+
+	var repoNames []string
+	repoConfigs := make(map[string][]yaml.MapSlice)
+	order := make(yaml.MapSlice, 0, len(config))
+	for _, section := range config {
+		repos, ok := section.Value.(yaml.MapSlice)
+		if !ok {
+			return fmt.Errorf("section %q is expected to be a map of repositories", section.Key)
+		}
+		sectionOrder := make(yaml.MapSlice, 0, len(repos))
+		for _, repo := range repos {
+			repoName, ok := repo.Key.(string)
+			if !ok || len(strings.Split(repoName, "/")) != 2 {
+				return fmt.Errorf("repository %v is expected to be \"org/repo\"", repo.Key)
+			}
+			sectionOrder = append(sectionOrder, yaml.MapItem{Key: repoName, Value: []interface{}{}})
+			if _, ok := repoConfigs[repoName]; !ok {
+				repoNames = append(repoNames, repoName)
+			}
+			repoConfigs[repoName] = append(repoConfigs[repoName],
+				yaml.MapSlice{{Key: section.Key, Value: yaml.MapSlice{repo}}})
+		}
+		order = append(order, yaml.MapItem{Key: section.Key, Value: sectionOrder})
+	}
+
+	out, err := yaml.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("failed marshal the order of the repositories: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create directory %q: %w", dir, err)
+	}
+	p := filepath.Join(dir, orderFileName)
+	if err := ioutil.WriteFile(p, out, 0644); err != nil {
+		return fmt.Errorf("cannot write file %q: %w", p, err)
+	}
+
+	for _, repoName := range repoNames {
+		repoConfig, err := mergeConfigs(repoConfigs[repoName])
+		if err != nil {
+			return err
+		}
+		out, err := yaml.Marshal(repoConfig)
+		if err != nil {
+			return fmt.Errorf("failed marshal the config of %q: %w", repoName, err)
+		}
+		p := filepath.Join(dir, repoName+".yaml")
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return fmt.Errorf("cannot create directory for %q: %w", p, err)
+		}
+		if err := ioutil.WriteFile(p, out, 0644); err != nil {
+			return fmt.Errorf("cannot write file %q: %w", p, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v2"
+)
+
+func TestReadConfigFiles(t *testing.T) {
+	emptyDir, err := ioutil.TempDir("", "config-generator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(emptyDir)
+
+	tests := map[string]struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		"file": {
+			path: "testdata/config.yaml",
+			want: []string{"testdata/config.yaml"},
+		},
+		"directory": {
+			path: "testdata/jobs",
+			want: []string{
+				"testdata/jobs/order.yaml",
+				"testdata/jobs/google/knative-gcp.yaml",
+				"testdata/jobs/knative/docs.yaml",
+				"testdata/jobs/knative/eventing.yaml",
+				"testdata/jobs/knative/operator.yaml",
+				"testdata/jobs/knative/serving.yaml",
+				"testdata/jobs/knative-sandbox/net-kourier.yaml",
+			},
+		},
+		"directory without yaml files": {
+			path:    emptyDir,
+			wantErr: true,
+		},
+		"missing": {
+			path:    "testdata/missing.yaml",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			files, err := readConfigFiles(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readConfigFiles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, f := range files {
+				got = append(got, f.Name)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("readConfigFiles() diff(-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMergeConfigs(t *testing.T) {
+	tests := map[string]struct {
+		configs []string
+		want    string
+		wantErr bool
+	}{
+		"single": {
+			configs: []string{"presubmits:\n  a/b:\n  - unit-tests: true\n"},
+			want:    "presubmits:\n  a/b:\n  - unit-tests: true\n",
+		},
+		"jobs of a repository are concatenated": {
+			configs: []string{
+				"presubmits:\n  a/b:\n  - unit-tests: true\n",
+				"periodics:\n  a/b:\n  - continuous: true\npresubmits:\n  a/c:\n  - build-tests: true\n  a/b:\n  - build-tests: true\n",
+			},
+			want: "presubmits:\n  a/b:\n  - unit-tests: true\n  - build-tests: true\n  a/c:\n  - build-tests: true\nperiodics:\n  a/b:\n  - continuous: true\n",
+		},
+		"repositories are ordered by an empty config": {
+			configs: []string{
+				"periodics:\n  b/a: []\n  a/c: []\n  a/b: []\n",
+				"periodics:\n  a/b:\n  - continuous: true\n  a/c:\n  - continuous: true\n",
+				"periodics:\n  b/a:\n  - continuous: true\n",
+			},
+			want: "periodics:\n  b/a:\n  - continuous: true\n  a/c:\n  - continuous: true\n  a/b:\n  - continuous: true\n",
+		},
+		"section is not a map": {
+			configs: []string{"presubmits:\n- unit-tests: true\n"},
+			wantErr: true,
+		},
+		"jobs are not a list": {
+			configs: []string{"presubmits:\n  a/b:\n    unit-tests: true\n"},
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var configs []yaml.MapSlice
+			for _, c := range tt.configs {
+				var config yaml.MapSlice
+				if err := yaml.Unmarshal([]byte(c), &config); err != nil {
+					t.Fatal(err)
+				}
+				configs = append(configs, config)
+			}
+			merged, err := mergeConfigs(configs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("mergeConfigs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := yaml.Marshal(merged)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("mergeConfigs() diff(-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSplitConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-generator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := splitConfig("testdata/config.yaml", dir); err != nil {
+		t.Fatalf("splitConfig() = %v", err)
+	}
+	want, err := configFilePaths("testdata/jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := configFilePaths(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("splitConfig() wrote %d files, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if rel, _ := filepath.Rel(dir, got[i]); rel != mustRel(t, "testdata/jobs", want[i]) {
+			t.Errorf("splitConfig() wrote %q, want %q", rel, want[i])
+			continue
+		}
+		wantContent, _ := ioutil.ReadFile(want[i])
+		gotContent, _ := ioutil.ReadFile(got[i])
+		if diff := cmp.Diff(string(wantContent), string(gotContent)); diff != "" {
+			t.Errorf("splitConfig() %s diff(-want,+got):\n%s", want[i], diff)
+		}
+	}
+}
+
+func mustRel(t *testing.T, base, p string) string {
+	t.Helper()
+	rel, err := filepath.Rel(base, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rel
+}
+
+// TestGenerateConfigsFromSplitConfig checks that the configs generated from
+// the config split into one file per repository are the same as from the
+// single file.
+func TestGenerateConfigsFromSplitConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-generator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := splitConfig("testdata/config.yaml", dir); err != nil {
+		t.Fatalf("splitConfig() = %v", err)
+	}
+
+	generate := func(path string) map[string]string {
+		SetupForTesting()
+		logFatalf = t.Fatalf
+		setupFlagDefaults()
+		files, err := readConfigFiles(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config, err := parseConfigFiles(files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inv, err := readInventory("testdata/inventory.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := loadInventory(inv, configRepos(config)); err != nil {
+			t.Fatal(err)
+		}
+		generated := make(map[string]*bytes.Buffer)
+		generateConfigs(files, true, true, true, func(kind string) {
+			generated[kind] = &bytes.Buffer{}
+			output = newOutputter(generated[kind])
+		})
+		configs := make(map[string]string)
+		for kind, b := range generated {
+			configs[kind] = b.String()
+		}
+		return configs
+	}
+	want := generate("testdata/config.yaml")
+	if len(want) != 3 {
+		t.Fatalf("generateConfigs() generated %d configs, want 3", len(want))
+	}
+	if diff := cmp.Diff(want, generate(dir)); diff != "" {
+		t.Errorf("generateConfigs() diff(-file,+directory):\n%s", diff)
+	}
+}
@@ -0,0 +1,345 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Kinds of generated configs.
+const (
+	prowJobsConfigKind    = "Prow jobs"
+	testgridConfigKind    = "TestGrid"
+	k8sTestgridConfigKind = "k8s TestGrid"
+)
+
+// entryChange is the change of an entry (a job, a test group, a dashboard...)
+// between the existing and the generated config.
+type entryChange struct {
+	Entry   string
+	Added   bool
+	Removed bool
+	Fields  []fieldChange
+}
+
+// fieldChange is the change of a field of a changed entry. Values are in
+// JSON, and empty if the field is unset.
+type fieldChange struct {
+	Path     string
+	Old, New string
+}
+
+// configKind returns the kind of the given generated config, based on its
+// sections.
+func configKind(content []byte) (string, error) {
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return "", err
+	}
+	for _, section := range []string{"presubmits", "postsubmits", "periodics"} {
+		if _, ok := config[section]; ok {
+			return prowJobsConfigKind, nil
+		}
+	}
+	if _, ok := config["test_groups"]; ok {
+		return testgridConfigKind, nil
+	}
+	if _, ok := config["dashboards"]; ok {
+		return k8sTestgridConfigKind, nil
+	}
+	return "", fmt.Errorf("neither Prow jobs nor TestGrid sections found")
+}
+
+// diffConfigs compares the entries of the existing and generated configs.
+// Jobs are matched by name (and repository for presubmits and postsubmits),
+// TestGrid test groups, dashboards and dashboard groups by name. Entries with
+// the same name are matched in order. Changes are
+// sorted by entry, added and removed entries first.
+func diffConfigs(existing, generated []byte) ([]entryChange, error) {
+	oldEntries, err := configEntries(existing)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse the existing config: %w", err)
+	}
+	newEntries, err := configEntries(generated)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse the generated config: %w", err)
+	}
+
+	var changes []entryChange
+	for entry := range newEntries {
+		if _, ok := oldEntries[entry]; !ok {
+			changes = append(changes, entryChange{Entry: entry, Added: true})
+		}
+	}
+	for entry, oldFields := range oldEntries {
+		newFields, ok := newEntries[entry]
+		if !ok {
+			changes = append(changes, entryChange{Entry: entry, Removed: true})
+			continue
+		}
+		if fields := diffFields(oldFields, newFields); len(fields) > 0 {
+			changes = append(changes, entryChange{Entry: entry, Fields: fields})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if ci, cj := changeOrder(changes[i]), changeOrder(changes[j]); ci != cj {
+			return ci < cj
+		}
+		return changes[i].Entry < changes[j].Entry
+	})
+	return changes, nil
+}
+
+func changeOrder(c entryChange) int {
+	switch {
+	case c.Added:
+		return 0
+	case c.Removed:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// diffFields compares the flattened fields of an entry.
+func diffFields(oldFields, newFields map[string]string) []fieldChange {
+	var changes []fieldChange
+	for p, v := range newFields {
+		if oldFields[p] != v {
+			changes = append(changes, fieldChange{Path: p, Old: oldFields[p], New: v})
+		}
+	}
+	for p, v := range oldFields {
+		if _, ok := newFields[p]; !ok {
+			changes = append(changes, fieldChange{Path: p, Old: v})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// configEntries returns the flattened fields of the entries of a Prow jobs
+// or TestGrid config, by entry.
+func configEntries(content []byte) (map[string]map[string]string, error) {
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, err
+	}
+	entries := make(map[string]map[string]string)
+	add := func(kind string, items interface{}, repo string) error {
+		list, ok := items.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s entries are expected to be a list", kind)
+		}
+		for _, item := range list {
+			name := fmt.Sprint(nameOf(item))
+			entry := kind + " " + name
+			if repo != "" {
+				entry += " (" + repo + ")"
+			}
+			// Prow allows jobs with the same name on different branches,
+			// tell them apart by order.
+			for n := 2; entries[entry] != nil; n++ {
+				entry = fmt.Sprintf("%s %s #%d", kind, name, n)
+				if repo != "" {
+					entry += " (" + repo + ")"
+				}
+			}
+			fields := make(map[string]string)
+			flatten("", item, fields)
+			delete(fields, "name")
+			entries[entry] = fields
+		}
+		return nil
+	}
+
+	for section, kind := range map[string]string{"presubmits": "presubmit", "postsubmits": "postsubmit"} {
+		repos, ok := config[section].(map[interface{}]interface{})
+		if !ok && config[section] != nil {
+			return nil, fmt.Errorf("%s are expected to be a map of repositories", section)
+		}
+		for repo, jobs := range repos {
+			if err := add(kind, jobs, fmt.Sprint(repo)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sections := map[string]string{
+		"periodics":        "periodic",
+		"test_groups":      "test group",
+		"dashboards":       "dashboard",
+		"dashboard_groups": "dashboard group",
+	}
+	for section, kind := range sections {
+		if config[section] == nil {
+			continue
+		}
+		if err := add(kind, config[section], ""); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// nameOf returns the name of v if it is a map.
+func nameOf(v interface{}) interface{} {
+	if m, ok := v.(map[interface{}]interface{}); ok {
+		return m["name"]
+	}
+	return nil
+}
+
+// flatten adds the leaf values of v to fields, by path. Lists of maps with a
+// name (e.g. env, volumes, dashboard tabs) are indexed by name so reordering
+// them is not a change, other lists of maps by position. Lists of scalars
+// (e.g. args, branches) are a single value.
+func flatten(p string, v interface{}, fields map[string]string) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		if len(v) == 0 {
+			fields[p] = "{}"
+		}
+		for k, child := range v {
+			key := fmt.Sprint(k)
+			if p != "" {
+				key = p + "." + key
+			}
+			flatten(key, child, fields)
+		}
+	case []interface{}:
+		if !isListOfMaps(v) {
+			fields[p] = jsonValue(v)
+			return
+		}
+		byName := true
+		names := make(map[string]bool)
+		for _, item := range v {
+			name, ok := nameOf(item).(string)
+			if !ok || names[name] {
+				byName = false
+				break
+			}
+			names[name] = true
+		}
+		for i, item := range v {
+			if byName {
+				key := fmt.Sprintf("%s[%s]", p, nameOf(item))
+				flatten(key, item, fields)
+				// The name is already in the path, unless it is the only field.
+				if len(item.(map[interface{}]interface{})) > 1 {
+					delete(fields, key+".name")
+				}
+				continue
+			}
+			flatten(fmt.Sprintf("%s[%d]", p, i), item, fields)
+		}
+	default:
+		fields[p] = jsonValue(v)
+	}
+}
+
+func isListOfMaps(list []interface{}) bool {
+	for _, item := range list {
+		if _, ok := item.(map[interface{}]interface{}); !ok {
+			return false
+		}
+	}
+	return len(list) > 0
+}
+
+// jsonValue formats a scalar or a list of scalars as JSON.
+func jsonValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// writeChanges writes a summary of the changes of a config.
+func writeChanges(w io.Writer, title string, changes []entryChange) {
+	if len(changes) == 0 {
+		fmt.Fprintf(w, "%s: no changes\n", title)
+		return
+	}
+	var added, removed, changed int
+	for _, c := range changes {
+		switch {
+		case c.Added:
+			added++
+		case c.Removed:
+			removed++
+		default:
+			changed++
+		}
+	}
+	fmt.Fprintf(w, "%s: %d added, %d removed, %d changed\n", title, added, removed, changed)
+	for _, c := range changes {
+		switch {
+		case c.Added:
+			fmt.Fprintf(w, "+ %s\n", c.Entry)
+		case c.Removed:
+			fmt.Fprintf(w, "- %s\n", c.Entry)
+		default:
+			fmt.Fprintf(w, "~ %s\n", c.Entry)
+			for _, f := range c.Fields {
+				fmt.Fprintf(w, "    %s: %s -> %s\n", f.Path, orUnset(f.Old), orUnset(f.New))
+			}
+		}
+	}
+}
+
+func orUnset(v string) string {
+	if v == "" {
+		return "(unset)"
+	}
+	return v
+}
+
+// writeDiffs compares each of the existing configs in diffs with the
+// generated config of the same kind, and writes a summary of the changes.
+func writeDiffs(w io.Writer, generated map[string]*bytes.Buffer) error {
+	for _, fileName := range diffs {
+		existing, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return fmt.Errorf("cannot read file %q: %w", fileName, err)
+		}
+		kind, err := configKind(existing)
+		if err != nil {
+			return fmt.Errorf("cannot compare %q: %w", fileName, err)
+		}
+		content, ok := generated[kind]
+		if !ok {
+			return fmt.Errorf("cannot compare %q: the %s config is not generated", fileName, kind)
+		}
+		changes, err := diffConfigs(existing, content.Bytes())
+		if err != nil {
+			return fmt.Errorf("cannot compare %q: %w", fileName, err)
+		}
+		writeChanges(w, fmt.Sprintf("%s (%s)", kind, fileName), changes)
+	}
+	return nil
+}
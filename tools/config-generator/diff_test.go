@@ -0,0 +1,256 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConfigKind(t *testing.T) {
+	tests := map[string]struct {
+		config  string
+		want    string
+		wantErr bool
+	}{
+		"prow jobs":    {config: "periodics:\n- name: ci-foo\n", want: prowJobsConfigKind},
+		"testgrid":     {config: "test_groups:\n- name: ci-foo\ndashboards: []\n", want: testgridConfigKind},
+		"k8s testgrid": {config: "dashboards:\n- name: foo\n", want: k8sTestgridConfigKind},
+		"unknown":      {config: "plank: {}\n", wantErr: true},
+		"invalid":      {config: "[", wantErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := configKind([]byte(tt.config))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("configKind() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("configKind() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+const existingJobs = `presubmits:
+  knative/serving:
+  - name: pull-knative-serving-unit-tests
+    always_run: true
+    branches:
+    - master
+    spec:
+      containers:
+      - args:
+        - --unit-tests
+        env:
+        - name: A
+          value: "1"
+        - name: B
+          value: "2"
+  - name: pull-knative-serving-build-tests
+    always_run: true
+periodics:
+- name: ci-knative-serving-continuous
+  cron: 0 */4 * * *
+  annotations:
+    testgrid-dashboards: knative-serving
+- name: ci-knative-serving-nightly-release
+  cron: 0 9 * * *
+`
+
+const generatedJobs = `presubmits:
+  knative/serving:
+  - name: pull-knative-serving-build-tests
+    always_run: true
+  - name: pull-knative-serving-unit-tests
+    always_run: true
+    branches:
+    - master
+    - main
+    spec:
+      containers:
+      - args:
+        - --unit-tests
+        - --verbose
+        env:
+        - name: B
+          value: "2"
+        - name: A
+          value: "3"
+  knative/eventing:
+  - name: pull-knative-eventing-build-tests
+periodics:
+- name: ci-knative-serving-continuous
+  cron: 0 */5 * * *
+  annotations:
+    testgrid-dashboards: knative-serving
+    testgrid-alert-email: foo@example.com
+`
+
+func TestDiffConfigs(t *testing.T) {
+	tests := map[string]struct {
+		existing  string
+		generated string
+		want      []entryChange
+	}{
+		"same": {
+			existing:  existingJobs,
+			generated: existingJobs,
+		},
+		"same names": {
+			existing:  "periodics:\n- name: a\n  cron: 1 * * * *\n- name: a\n  cron: 2 * * * *\n",
+			generated: "periodics:\n- name: a\n  cron: 1 * * * *\n",
+			want:      []entryChange{{Entry: "periodic a #2", Removed: true}},
+		},
+		"prow jobs": {
+			existing:  existingJobs,
+			generated: generatedJobs,
+			want: []entryChange{
+				{Entry: "presubmit pull-knative-eventing-build-tests (knative/eventing)", Added: true},
+				{Entry: "periodic ci-knative-serving-nightly-release", Removed: true},
+				{Entry: "periodic ci-knative-serving-continuous", Fields: []fieldChange{
+					{Path: "annotations.testgrid-alert-email", New: `"foo@example.com"`},
+					{Path: "cron", Old: `"0 */4 * * *"`, New: `"0 */5 * * *"`},
+				}},
+				{Entry: "presubmit pull-knative-serving-unit-tests (knative/serving)", Fields: []fieldChange{
+					{Path: "branches", Old: `["master"]`, New: `["master","main"]`},
+					{Path: "spec.containers[0].args", Old: `["--unit-tests"]`, New: `["--unit-tests","--verbose"]`},
+					{Path: "spec.containers[0].env[A].value", Old: `"1"`, New: `"3"`},
+				}},
+			},
+		},
+		"testgrid": {
+			existing: `test_groups:
+- name: ci-foo
+  gcs_prefix: knative-prow/logs/ci-foo
+- name: ci-bar
+  gcs_prefix: knative-prow/logs/ci-bar
+dashboards:
+- name: foo
+  dashboard_tab:
+  - name: continuous
+    test_group_name: ci-foo
+  - name: bar
+    test_group_name: ci-bar
+`,
+			generated: `test_groups:
+- name: ci-foo
+  gcs_prefix: knative-prow/logs/ci-foo
+  alert_stale_results_hours: 3
+dashboards:
+- name: foo
+  dashboard_tab:
+  - name: continuous
+    test_group_name: ci-foo
+    num_failures_to_alert: 3
+dashboard_groups:
+- name: knative
+  dashboard_names:
+  - foo
+`,
+			want: []entryChange{
+				{Entry: "dashboard group knative", Added: true},
+				{Entry: "test group ci-bar", Removed: true},
+				{Entry: "dashboard foo", Fields: []fieldChange{
+					{Path: "dashboard_tab[bar].test_group_name", Old: `"ci-bar"`},
+					{Path: "dashboard_tab[continuous].num_failures_to_alert", New: "3"},
+				}},
+				{Entry: "test group ci-foo", Fields: []fieldChange{
+					{Path: "alert_stale_results_hours", New: "3"},
+				}},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := diffConfigs([]byte(tt.existing), []byte(tt.generated))
+			if err != nil {
+				t.Fatalf("diffConfigs() = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diffConfigs() diff(-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiffConfigsErrors(t *testing.T) {
+	tests := map[string]struct {
+		existing  string
+		generated string
+	}{
+		"invalid existing":     {existing: "[", generated: generatedJobs},
+		"invalid generated":    {existing: existingJobs, generated: "["},
+		"presubmits not a map": {existing: "presubmits:\n- name: a\n", generated: generatedJobs},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := diffConfigs([]byte(tt.existing), []byte(tt.generated)); err == nil {
+				t.Error("diffConfigs() = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestWriteChanges(t *testing.T) {
+	changes, err := diffConfigs([]byte(existingJobs), []byte(generatedJobs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	writeChanges(&b, "Prow jobs", changes)
+	writeChanges(&b, "TestGrid", nil)
+	want := `Prow jobs: 1 added, 1 removed, 2 changed
++ presubmit pull-knative-eventing-build-tests (knative/eventing)
+- periodic ci-knative-serving-nightly-release
+~ periodic ci-knative-serving-continuous
+    annotations.testgrid-alert-email: (unset) -> "foo@example.com"
+    cron: "0 */4 * * *" -> "0 */5 * * *"
+~ presubmit pull-knative-serving-unit-tests (knative/serving)
+    branches: ["master"] -> ["master","main"]
+    spec.containers[0].args: ["--unit-tests"] -> ["--unit-tests","--verbose"]
+    spec.containers[0].env[A].value: "1" -> "3"
+TestGrid: no changes
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("writeChanges() diff(-want,+got):\n%s", diff)
+	}
+}
+
+func TestWriteDiffs(t *testing.T) {
+	defer func() { diffs = nil }()
+	content, err := ioutil.ReadFile("testdata/prow_jobs.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diffs = stringArrayFlag{"testdata/prow_jobs.yaml"}
+	var b bytes.Buffer
+	if err := writeDiffs(&b, map[string]*bytes.Buffer{prowJobsConfigKind: bytes.NewBuffer(content)}); err != nil {
+		t.Fatalf("writeDiffs() = %v", err)
+	}
+	if want := "Prow jobs (testdata/prow_jobs.yaml): no changes\n"; b.String() != want {
+		t.Errorf("writeDiffs() wrote %q, want %q", b.String(), want)
+	}
+
+	if err := writeDiffs(&b, map[string]*bytes.Buffer{testgridConfigKind: bytes.NewBuffer(content)}); err == nil {
+		t.Error("writeDiffs() = nil, want an error for the config not generated")
+	}
+}
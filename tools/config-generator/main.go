@@ -21,7 +21,6 @@ package main
 
 import (
 	"bytes"
-	"context"
 	"flag"
 	"fmt"
 	"io"
@@ -41,6 +40,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"knative.dev/test-infra/pkg/ghutil"
+	"knative.dev/test-infra/pkg/inventory"
 )
 
 const (
@@ -61,11 +61,8 @@ var (
 	// GitHub repos that are not using knative.dev path alias.
 	nonPathAliasRepos = sets.NewString("knative/docs")
 
-	// Repos that have changed the branch name from master to main. We'll automatically
-	// look up everything in the knative and knative-sandbox org.
-	mainBranchRepos = sets.NewString(
-		"google/knative-gcp",
-	)
+	// Inventory of the repos in the config, keyed by "org/repo".
+	reposInventory = make(map[string]inventory.Repo)
 )
 
 type logFatalfFunc func(string, ...interface{})
@@ -109,19 +106,18 @@ type baseProwJobTemplateData struct {
 	RepoURI             string
 	RepoBranch          string
 	CloneURI            string
-	SecurityContext     []string
+	SecurityContext     *SecurityContext
 	SkipBranches        []string
 	Branches            []string
-	DecorationConfig    []string
-	ExtraRefs           []string
+	DecorationConfig    *DecorationConfig
+	ExtraRefs           []Refs
 	Command             string
 	Args                []string
-	Env                 []string
-	Volumes             []string
-	VolumeMounts        []string
-	Resources           []string
-	ReporterConfig      []string
-	JobStatesToReport   []string
+	Env                 []EnvVar
+	Volumes             []Volume
+	VolumeMounts        []VolumeMount
+	Resources           *ResourceRequirements
+	ReporterConfig      *ReporterConfig
 	Timeout             int
 	AlwaysRun           bool
 	Optional            bool
@@ -130,11 +126,11 @@ type baseProwJobTemplateData struct {
 	ReleaseGcs          string
 	GoCoverageThreshold int
 	Image               string
-	Labels              []string
+	Labels              map[string]string
 	PathAlias           string
 	Cluster             string
 	NeedsMonitor        bool
-	Annotations         []string
+	Annotations         map[string]string
 }
 
 // ####################################################################################################
@@ -201,14 +197,15 @@ var (
 	jobNameFilter      string
 	preCommand         string
 	extraEnvVars       stringArrayFlag
+	diffs              stringArrayFlag
 	timeoutOverride    int
 
 	// List of Knative repositories.
 	// Not guaranteed unique by any value of the struct
 	repositories []repositoryData
 
-	// Map which sections of the config.yaml were written to stdout.
-	sectionMap map[string]bool
+	// The Prow jobs generated from the config.yaml.
+	prowJobs ProwJobs
 
 	releaseRegex = regexp.MustCompile(`.+-[0-9\.]+$`)
 )
@@ -237,18 +234,10 @@ func newbaseProwJobTemplateData(repo string) baseProwJobTemplateData {
 	data.Timeout = 50
 	data.OrgName = strings.Split(repo, "/")[0]
 	data.RepoName = strings.Replace(repo, data.OrgName+"/", "", 1)
-	data.ExtraRefs = []string{"- org: " + data.OrgName, "  repo: " + data.RepoName}
-	if pathAliasOrgs.Has(data.OrgName) && !nonPathAliasRepos.Has(repo) {
-		data.PathAlias = "path_alias: knative.dev/" + data.RepoName
-		data.ExtraRefs = append(data.ExtraRefs, "  "+data.PathAlias)
-	}
+	data.PathAlias = repoPathAlias(repo)
+	data.ExtraRefs = []Refs{{Org: data.OrgName, Repo: data.RepoName, PathAlias: data.PathAlias}}
 	data.RepoNameForJob = strings.ToLower(strings.Replace(repo, "/", "-", -1))
-
-	if mainBranchRepos.Has(repo) {
-		data.RepoBranch = "main" // Default to be main for repos that have changed the branch name from master to main
-	} else {
-		data.RepoBranch = "master" // Default to be master for other repos
-	}
+	data.RepoBranch = repoDefaultBranch(repo)
 	data.GcsBucket = GCSBucket
 	data.RepoURI = "github.com/" + repo
 	data.CloneURI = fmt.Sprintf("\"https://%s.git\"", data.RepoURI)
@@ -261,12 +250,12 @@ func newbaseProwJobTemplateData(repo string) baseProwJobTemplateData {
 	data.ServiceAccount = testAccount
 	data.Command = ""
 	data.Args = make([]string, 0)
-	data.Volumes = make([]string, 0)
-	data.VolumeMounts = make([]string, 0)
-	data.Env = make([]string, 0)
-	data.Labels = make([]string, 0)
-	data.Annotations = make([]string, 0)
-	data.Cluster = "cluster: \"build-knative\""
+	data.Volumes = make([]Volume, 0)
+	data.VolumeMounts = make([]VolumeMount, 0)
+	data.Env = make([]EnvVar, 0)
+	data.Labels = make(map[string]string)
+	data.Annotations = make(map[string]string)
+	data.Cluster = "build-knative"
 	return data
 }
 
@@ -282,27 +271,17 @@ func createCommand(data baseProwJobTemplateData) []string {
 	return append(c, data.Args...)
 }
 
-func envNameToKey(key string) string {
-	return "- name: " + key
-}
-
-func envValueToValue(value string) string {
-	return "  value: " + value
-}
-
 // addEnvToJob adds the given key/pair environment variable to the job.
 func (data *baseProwJobTemplateData) addEnvToJob(key, value string) {
-	// Value should always be string. Add quotes if we get a number
-	if isNum(value) {
-		value = "\"" + value + "\""
-	}
-
-	data.Env = append(data.Env, envNameToKey(key), envValueToValue(value))
+	data.Env = append(data.Env, EnvVar{Name: key, Value: value})
 }
 
 // addLabelToJob adds extra labels to a job
 func addLabelToJob(data *baseProwJobTemplateData, key, value string) {
-	(*data).Labels = append((*data).Labels, []string{key + ": " + value}...)
+	if data.Labels == nil {
+		data.Labels = make(map[string]string)
+	}
+	data.Labels[key] = value
 }
 
 // addPubsubLabelsToJob adds the pubsub labels so the prow job message will be picked up by test-infra monitoring
@@ -312,21 +291,16 @@ func addMonitoringPubsubLabelsToJob(data *baseProwJobTemplateData, runID string)
 	addLabelToJob(data, "prow.k8s.io/pubsub.runID", runID)
 }
 
-// addVolumeToJob adds the given mount path as volume for the job.
-func addVolumeToJob(data *baseProwJobTemplateData, mountPath, name string, isSecret bool, content []string) {
-	(*data).VolumeMounts = append((*data).VolumeMounts, []string{"- name: " + name, "  mountPath: " + mountPath}...)
-	if isSecret {
-		(*data).VolumeMounts = append((*data).VolumeMounts, "  readOnly: true")
-	}
-	s := []string{"- name: " + name}
+// addVolumeToJob adds the given mount path as volume for the job. Secret
+// volumes are backed by the secret of the same name, other volumes by the
+// source set in volume.
+func addVolumeToJob(data *baseProwJobTemplateData, mountPath, name string, isSecret bool, volume Volume) {
+	data.VolumeMounts = append(data.VolumeMounts, VolumeMount{Name: name, MountPath: mountPath, ReadOnly: isSecret})
+	volume.Name = name
 	if isSecret {
-		arr := []string{"  secret:", "    secretName: " + name}
-		s = append(s, arr...)
+		volume.Secret = &SecretVolumeSource{SecretName: name}
 	}
-	for _, line := range content {
-		s = append(s, "  "+line)
-	}
-	(*data).Volumes = append((*data).Volumes, s...)
+	data.Volumes = append(data.Volumes, volume)
 }
 
 // configureServiceAccountForJob adds the necessary volumes for the service account for the job.
@@ -339,7 +313,7 @@ func configureServiceAccountForJob(data *baseProwJobTemplateData) {
 		logFatalf("Service account path %q is expected to be \"/etc/<name>/service-account.json\"", data.ServiceAccount)
 	}
 	name := p[2]
-	addVolumeToJob(data, "/etc/"+name, name, true, nil)
+	addVolumeToJob(data, "/etc/"+name, name, true, Volume{})
 }
 
 // addExtraEnvVarsToJob adds extra environment variables to a job.
@@ -347,7 +321,7 @@ func addExtraEnvVarsToJob(envVars []string, data *baseProwJobTemplateData) {
 	for _, env := range envVars {
 		pair := strings.SplitN(env, "=", 2)
 		if len(pair) == 2 {
-			data.addEnvToJob(pair[0], pair[1])
+			data.addEnvToJob(pair[0], unquote(pair[1]))
 		} else {
 			logFatalf("Environment variable %q is expected to be \"key=value\"", env)
 		}
@@ -358,36 +332,57 @@ func addExtraEnvVarsToJob(envVars []string, data *baseProwJobTemplateData) {
 func setupDockerInDockerForJob(data *baseProwJobTemplateData) {
 	// These volumes are required for running docker command and creating kind clusters.
 	// Reference: https://github.com/kubernetes-sigs/kind/issues/303
-	addVolumeToJob(data, "/docker-graph", "docker-graph", false, []string{"emptyDir: {}"})
-	addVolumeToJob(data, "/lib/modules", "modules", false, []string{"hostPath:", "  path: /lib/modules", "  type: Directory"})
-	addVolumeToJob(data, "/sys/fs/cgroup", "cgroup", false, []string{"hostPath:", "  path: /sys/fs/cgroup", "  type: Directory"})
-	data.addEnvToJob("DOCKER_IN_DOCKER_ENABLED", "\"true\"")
-	(*data).SecurityContext = []string{"privileged: true"}
+	addVolumeToJob(data, "/docker-graph", "docker-graph", false, Volume{EmptyDir: &EmptyDirVolumeSource{}})
+	addVolumeToJob(data, "/lib/modules", "modules", false, Volume{HostPath: &HostPathVolumeSource{Path: "/lib/modules", Type: "Directory"}})
+	addVolumeToJob(data, "/sys/fs/cgroup", "cgroup", false, Volume{HostPath: &HostPathVolumeSource{Path: "/sys/fs/cgroup", Type: "Directory"}})
+	data.addEnvToJob("DOCKER_IN_DOCKER_ENABLED", "true")
+	privileged := true
+	data.SecurityContext = &SecurityContext{Privileged: &privileged}
 }
 
 // setResourcesReqForJob sets resource requirement for job
 func setResourcesReqForJob(res yaml.MapSlice, data *baseProwJobTemplateData) {
-	data.Resources = nil
+	data.Resources = &ResourceRequirements{}
 	for _, val := range res {
-		data.Resources = append(data.Resources, fmt.Sprintf("  %s:", getString(val.Key)))
+		resources := make(map[string]string)
 		for _, item := range getMapSlice(val.Value) {
-			data.Resources = append(data.Resources, fmt.Sprintf("    %s: %s", getString(item.Key), getString(item.Value)))
+			resources[getString(item.Key)] = getString(item.Value)
+		}
+		switch getString(val.Key) {
+		case "requests":
+			data.Resources.Requests = resources
+		case "limits":
+			data.Resources.Limits = resources
+		default:
+			logFatalf("Unknown entry %q for resources", val.Key)
 		}
 	}
 }
 
 // setReporterConfigReqForJob sets reporter requirement for job
 func setReporterConfigReqForJob(res yaml.MapSlice, data *baseProwJobTemplateData) {
-	data.ReporterConfig = nil
+	data.ReporterConfig = &ReporterConfig{}
 	for _, val := range res {
-		data.ReporterConfig = append(data.ReporterConfig, fmt.Sprintf("  %s:", getString(val.Key)))
+		if getString(val.Key) != "slack" {
+			logFatalf("Unknown reporter %q", val.Key)
+			continue
+		}
+		slack := &SlackReporterConfig{}
 		for _, item := range getMapSlice(val.Value) {
-			if arr, ok := item.Value.([]interface{}); ok {
-				data.JobStatesToReport = getStringArray(arr)
-			} else {
-				data.ReporterConfig = append(data.ReporterConfig, fmt.Sprintf("    %s: %s", getString(item.Key), getString(item.Value)))
+			switch getString(item.Key) {
+			case "host":
+				slack.Host = getString(item.Value)
+			case "channel":
+				slack.Channel = getString(item.Value)
+			case "job_states_to_report":
+				slack.JobStatesToReport = getStringArray(item.Value)
+			case "report_template":
+				slack.ReportTemplate = unquote(getString(item.Value))
+			default:
+				logFatalf("Unknown entry %q for slack reporter", item.Key)
 			}
 		}
+		data.ReporterConfig.Slack = slack
 	}
 }
 
@@ -395,7 +390,7 @@ func setReporterConfigReqForJob(res yaml.MapSlice, data *baseProwJobTemplateData
 
 // parseBasicJobConfigOverrides updates the given baseProwJobTemplateData with any base option present in the given config.
 func parseBasicJobConfigOverrides(data *baseProwJobTemplateData, config yaml.MapSlice) {
-	(*data).ExtraRefs = append((*data).ExtraRefs, "  base_ref: "+(*data).RepoBranch)
+	(*data).ExtraRefs[0].BaseRef = (*data).RepoBranch
 	for i, item := range config {
 		switch item.Key {
 		case "skip_branches":
@@ -512,24 +507,6 @@ func gitHubRepo(data baseProwJobTemplateData) string {
 	return s
 }
 
-// executeTemplate outputs the given job template with the given data, respecting any filtering.
-func executeJobTemplate(name, templ, title, repoName, jobName string, groupByRepo bool, data interface{}) {
-	if jobNameFilter != "" && jobNameFilter != jobName {
-		return
-	}
-	if !sectionMap[title] {
-		output.outputConfig(title + ":")
-		sectionMap[title] = true
-	}
-	if groupByRepo {
-		if !sectionMap[title+repoName] {
-			output.outputConfig(baseIndent + repoName + ":")
-			sectionMap[title+repoName] = true
-		}
-	}
-	executeTemplate(name, templ, data)
-}
-
 // executeTemplate outputs the given template with the given data.
 func executeTemplate(name, templ string, data interface{}) {
 	var res bytes.Buffer
@@ -719,6 +696,7 @@ func main() {
 	var generateTestgridConfig = flag.Bool("generate-testgrid-config", true, "Whether to generate the testgrid config from the template file")
 	var generateK8sTestgridConfig = flag.Bool("generate-k8s-testgrid-config", true, "Whether to generate the k8s testgrid config from the template file")
 	var includeConfig = flag.Bool("include-config", true, "Whether to include general configuration (e.g., plank) in the generated config")
+	var validate = flag.Bool("validate", false, "Only check the config file, reporting all problems found instead of generating the configs")
 	var dockerImagesBase = flag.String("image-docker", "gcr.io/knative-tests/test-infra", "Default registry for the docker images used by the jobs")
 	flag.StringVar(&prowJobsConfigOutput, "prow-jobs-config-output", "", "The destination for the prow jobs config output, default to be stdout")
 	flag.StringVar(&testgridConfigOutput, "testgrid-config-output", "", "The destination for the testgrid config output, default to be stdout")
@@ -742,87 +720,167 @@ func main() {
 	flag.StringVar(&jobNameFilter, "job-filter", "", "Generate only this job, instead of all jobs")
 	flag.StringVar(&preCommand, "pre-command", "", "Executable for running instead of the real command of a job")
 	flag.BoolVar(&upgradeReleaseBranches, "upgrade-release-branches", false, "Update release branches jobs based on active branches")
-	flag.StringVar(&githubTokenPath, "github-token-path", "", "Token path for authenticating with github, used only when --upgrade-release-branches is on")
+	flag.StringVar(&githubTokenPath, "github-token-path", "", "Token path for authenticating with github, used only when --upgrade-release-branches or --refresh-inventory is on, or without --inventory")
+	var inventoryPath = flag.String("inventory", "", "Inventory of the repositories, as written by --refresh-inventory, to read their default branch from; if empty, the repositories are listed from GitHub")
+	var refreshInventory = flag.Bool("refresh-inventory", false, "Fetch the metadata of the repositories from GitHub and write it to the --inventory file, instead of generating the configs")
 	flag.Var(&extraEnvVars, "extra-env", "Extra environment variables (key=value) to add to a job")
+	flag.Var(&diffs, "diff", "Existing Prow jobs or TestGrid config to compare with the generated one, printing a summary of the changes instead of writing the configs; can be repeated")
 	flag.Parse()
+	if flag.Arg(0) == "split" {
+		if len(flag.Args()) != 3 {
+			log.Fatal("Usage: config-generator split <config file> <output directory>")
+		}
+		if err := splitConfig(flag.Arg(1), flag.Arg(2)); err != nil {
+			logFatalf("Failed splitting config: %v", err)
+		}
+		return
+	}
 	if len(flag.Args()) != 1 {
-		log.Fatal("Pass the config file as parameter")
+		log.Fatal("Pass the config file or directory as parameter")
 	}
 
 	prowTestsDockerImage = path.Join(*dockerImagesBase, *prowTestsDockerImageName)
 
-	// We use MapSlice instead of maps to keep key order and create predictable output.
-	configYaml := yaml.MapSlice{}
+	if *validate {
+		files, err := readConfigFiles(flag.Arg(0))
+		if err != nil {
+			logFatalf("Cannot read config: %v", err)
+		}
+		errs := validateConfig(files)
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		if len(errs) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
 
-	// Read input config.
-	configFileName := flag.Arg(0)
-	if upgradeReleaseBranches {
+	// Read input config, either a single file or a directory of files.
+	configPath := flag.Arg(0)
+	githubClient := func() *ghutil.GithubClient {
 		gc, err := ghutil.NewGithubClient(githubTokenPath)
 		if err != nil {
 			logFatalf("Failed creating github client from %q: %v", githubTokenPath, err)
 		}
-		if err := upgradeReleaseBranchesTemplate(configFileName, gc); err != nil {
-			logFatalf("Failed upgrade based on release branch: '%v'", err)
+		return gc
+	}
+	// Listing the repos of the orgs doesn't need a token, so without one they
+	// are listed anonymously, with GitHub's lower rate limit.
+	reposClient := func() inventory.Client {
+		if _, found := os.LookupEnv("GITHUB_TOKEN"); githubTokenPath == "" && !found {
+			return &ghutil.GithubClient{Client: github.NewClient(nil)}
 		}
+		return githubClient()
 	}
-
-	// Fill in the main exception list. We fetch this once for all of our orgs to avoid
-	// rate-limiting issues with Github.
-	client := github.NewClient(nil)
-	for org := range pathAliasOrgs {
-		repos, _, err := client.Repositories.List(context.Background(), org, &github.RepositoryListOptions{
-			ListOptions: github.ListOptions{PerPage: 200},
-		})
+	if upgradeReleaseBranches && len(diffs) == 0 {
+		gc := githubClient()
+		files, err := readConfigFiles(configPath)
 		if err != nil {
-			logFatalf("Cannot fetch default repos for %s org: %v", org, err)
+			logFatalf("Cannot read config: %v", err)
 		}
-		for _, r := range repos {
-			if r.DefaultBranch != nil && *r.DefaultBranch == "main" {
-				mainBranchRepos.Insert(*r.FullName)
+		for _, f := range files {
+			if err := upgradeReleaseBranchesTemplate(f.Name, gc); err != nil {
+				logFatalf("Failed upgrade based on release branch: '%v'", err)
 			}
 		}
 	}
 
-	configFileContent, err := ioutil.ReadFile(configFileName)
+	configFiles, err := readConfigFiles(configPath)
 	if err != nil {
-		logFatalf("Cannot read file %q: %v", configFileName, err)
+		logFatalf("Cannot read config: %v", err)
+	}
+	// In diff mode nothing is written, so the upgraded config files are only
+	// used to generate the configs compared with the existing ones.
+	if upgradeReleaseBranches && len(diffs) > 0 {
+		gc := githubClient()
+		for i, f := range configFiles {
+			if configFiles[i].Content, err = upgradeReleaseBranchesConfig(f.Name, f.Content, gc); err != nil {
+				logFatalf("Failed upgrade based on release branch: '%v'", err)
+			}
+		}
 	}
-	if err = yaml.Unmarshal(configFileContent, &configYaml); err != nil {
-		logFatalf("Cannot parse config %q: %v", configFileName, err)
+	// We use MapSlice instead of maps to keep key order and create predictable output.
+	configYaml, err := parseConfigFiles(configFiles)
+	if err != nil {
+		logFatalf("Cannot parse config: %v", err)
 	}
 
-	prowConfigData := getProwConfigData(configYaml)
-
-	// Generate Prow config.
-	repositories = make([]repositoryData, 0)
-	sectionMap = make(map[string]bool)
-	setOutput(prowJobsConfigOutput)
-	executeTemplate("general header", readTemplate(commonHeaderConfig), prowConfigData)
-	parseSection(configYaml, "presubmits", generatePresubmit, nil)
-	parseSection(configYaml, "periodics", generatePeriodic, generateGoCoveragePeriodic)
-	for _, repo := range repositories { // Keep order for predictable output.
-		if !repo.Processed && repo.EnableGoCoverage {
-			generateGoCoveragePeriodic("periodics", repo.Name, nil)
+	// Look up the repos, either in the inventory or on GitHub.
+	repos := configRepos(configYaml)
+	if *refreshInventory {
+		if *inventoryPath == "" {
+			logFatalf("--refresh-inventory requires --inventory")
 		}
+		inv, err := listRepos(reposClient(), repos)
+		if err != nil {
+			logFatalf("Failed listing the repositories: %v", err)
+		}
+		if err := writeInventory(*inventoryPath, inv); err != nil {
+			logFatalf("Failed refreshing the inventory: %v", err)
+		}
+		return
+	}
+	var inv []inventory.Repo
+	if *inventoryPath != "" {
+		inv, err = readInventory(*inventoryPath)
+	} else {
+		inv, err = listRepos(reposClient(), repos)
+	}
+	if err != nil {
+		logFatalf("Cannot get the repositories: %v", err)
+	}
+	if err := loadInventory(inv, repos); err != nil {
+		logFatalf("Cannot get the repositories: %v", err)
 	}
-	generatePerfClusterUpdatePeriodicJobs()
 
-	for _, repo := range repositories {
-		if repo.EnableGoCoverage {
-			generateGoCoveragePostsubmit("postsubmits", repo.Name, nil)
+	// In diff mode the configs are generated in memory and compared with the
+	// existing ones instead of being written.
+	outputs := map[string]string{
+		prowJobsConfigKind:    prowJobsConfigOutput,
+		testgridConfigKind:    testgridConfigOutput,
+		k8sTestgridConfigKind: k8sTestgridConfigOutput,
+	}
+	generated := make(map[string]*bytes.Buffer)
+	setConfigOutput := func(kind string) {
+		if len(diffs) == 0 {
+			setOutput(outputs[kind])
+			return
 		}
-		if repo.EnablePerformanceTests {
-			generatePerfClusterPostsubmitJob(repo)
+		generated[kind] = &bytes.Buffer{}
+		output = newOutputter(generated[kind])
+	}
+	generateConfigs(configFiles, *generateK8sTestgridConfig, *generateTestgridConfig, *includeConfig, setConfigOutput)
+
+	if len(diffs) > 0 {
+		if err := writeDiffs(os.Stdout, generated); err != nil {
+			logFatalf("Failed comparing configs: %v", err)
 		}
 	}
+}
+
+// generateConfigs generates the Prow jobs config, and the k8s TestGrid and
+// TestGrid configs if enabled, from the given config files. setConfigOutput
+// is called with the kind of each config before it is generated.
+func generateConfigs(configFiles []configFile, k8sTestgrid, testgrid, includeConfig bool, setConfigOutput func(kind string)) {
+	configYaml, err := parseConfigFiles(configFiles)
+	if err != nil {
+		logFatalf("Cannot parse config: %v", err)
+	}
+	prowConfigData := getProwConfigData(configYaml)
+
+	// Generate Prow config.
+	setConfigOutput(prowJobsConfigKind)
+	executeTemplate("general header", readTemplate(commonHeaderConfig), prowConfigData)
+	outputProwJobs(generateProwJobs(configYaml))
 
 	// config object is modified when we generate prow config, so we'll need to reload it here
-	if err = yaml.Unmarshal(configFileContent, &configYaml); err != nil {
-		logFatalf("Cannot parse config %q: %v", configFileName, err)
+	if configYaml, err = parseConfigFiles(configFiles); err != nil {
+		logFatalf("Cannot parse config: %v", err)
 	}
 
-	if *generateK8sTestgridConfig {
-		setOutput(k8sTestgridConfigOutput)
+	if k8sTestgrid {
+		setConfigOutput(k8sTestgridConfigKind)
 		executeTemplate("general header", readTemplate(commonHeaderConfig), newBaseTestgridTemplateData(""))
 
 		periodicJobData := parseJob(configYaml, "periodics")
@@ -848,10 +906,10 @@ func main() {
 	}
 
 	// Generate Testgrid config.
-	if *generateTestgridConfig {
-		setOutput(testgridConfigOutput)
+	if testgrid {
+		setConfigOutput(testgridConfigKind)
 
-		if *includeConfig {
+		if includeConfig {
 			executeTemplate("general header", readTemplate(commonHeaderConfig), newBaseTestgridTemplateData(""))
 			executeTemplate("general config", readTemplate(generalTestgridConfig), newBaseTestgridTemplateData(""))
 		}
@@ -880,6 +938,31 @@ func main() {
 	}
 }
 
+// generateProwJobs generates the Prow jobs of all the sections of the given
+// config.
+func generateProwJobs(configYaml yaml.MapSlice) ProwJobs {
+	repositories = make([]repositoryData, 0)
+	prowJobs = ProwJobs{}
+	parseSection(configYaml, "presubmits", generatePresubmit, nil)
+	parseSection(configYaml, "periodics", generatePeriodic, generateGoCoveragePeriodic)
+	for _, repo := range repositories { // Keep order for predictable output.
+		if !repo.Processed && repo.EnableGoCoverage {
+			generateGoCoveragePeriodic("periodics", repo.Name, nil)
+		}
+	}
+	generatePerfClusterUpdatePeriodicJobs()
+
+	for _, repo := range repositories {
+		if repo.EnableGoCoverage {
+			generateGoCoveragePostsubmit("postsubmits", repo.Name, nil)
+		}
+		if repo.EnablePerformanceTests {
+			generatePerfClusterPostsubmitJob(repo)
+		}
+	}
+	return prowJobs
+}
+
 // parseOrgAndRepoFromMapItem splits the "org/repo" string of a yaml.MapItem
 // into "org" and "repo" return values.
 func parseOrgAndRepoFromMapItem(mapItem yaml.MapItem) (string, string) {
@@ -79,10 +79,14 @@ func TestNewbaseProwJobTemplateData(t *testing.T) {
 
 	pathAliasOrgs.Insert("foo")
 	out = newbaseProwJobTemplateData("foo/subrepo")
-	expected := "path_alias: knative.dev/subrepo"
+	expected := "knative.dev/subrepo"
 	if diff := cmp.Diff(out.PathAlias, expected); diff != "" {
 		t.Fatalf("Unexpected path alias: (-got +want)\n%s", diff)
 	}
+	expectedRefs := []Refs{{Org: "foo", Repo: "subrepo", PathAlias: "knative.dev/subrepo"}}
+	if diff := cmp.Diff(out.ExtraRefs, expectedRefs); diff != "" {
+		t.Fatalf("Unexpected extra refs: (-got +want)\n%s", diff)
+	}
 
 	nonPathAliasRepos.Insert("foo/subrepo")
 	out = newbaseProwJobTemplateData("foo/subrepo")
@@ -115,38 +119,14 @@ func TestCreateCommand(t *testing.T) {
 	preCommand = ""
 }
 
-func TestEnvNameToKey(t *testing.T) {
-	SetupForTesting()
-	if diff := cmp.Diff(envNameToKey("foo"), "- name: foo"); diff != "" {
-		t.Fatalf("Unexpected name to key conversion: (-got +want)\n%s", diff)
-	}
-}
-
-func TestEnvValueToValue(t *testing.T) {
-	SetupForTesting()
-	if diff := cmp.Diff(envValueToValue("bar"), "  value: bar"); diff != "" {
-		t.Fatalf("Unexpected env value conversion: (-got +want)\n%s", diff)
-	}
-}
-
 func TestAddEnvToJob(t *testing.T) {
 	SetupForTesting()
 	job := baseProwJobTemplateData{}
 	job.addEnvToJob("foo", "bar")
-	if diff := cmp.Diff(job.Env[0], "- name: foo"); diff != "" {
-		t.Fatalf("Unexpected env name: (-got +want)\n%s", diff)
-	}
-	if diff := cmp.Diff(job.Env[1], "  value: bar"); diff != "" {
-		t.Fatalf("Unexpected env value: (-got +want)\n%s", diff)
-	}
-
-	job = baseProwJobTemplateData{}
 	job.addEnvToJob("num", "42")
-	if diff := cmp.Diff(job.Env[0], "- name: num"); diff != "" {
-		t.Fatalf("Unexpected env name: (-got +want)\n%s", diff)
-	}
-	if diff := cmp.Diff(job.Env[1], "  value: \"42\""); diff != "" {
-		t.Fatalf("Unexpected env value: (-got +want)\n%s", diff)
+	expected := []EnvVar{{Name: "foo", Value: "bar"}, {Name: "num", Value: "42"}}
+	if diff := cmp.Diff(job.Env, expected); diff != "" {
+		t.Fatalf("Unexpected env: (-got +want)\n%s", diff)
 	}
 }
 
@@ -155,7 +135,7 @@ func TestAddLabelToJob(t *testing.T) {
 	job := baseProwJobTemplateData{}
 	addLabelToJob(&job, "foo", "bar")
 
-	expected := []string{"foo: bar"}
+	expected := map[string]string{"foo": "bar"}
 	if diff := cmp.Diff(job.Labels, expected); diff != "" {
 		t.Fatalf("Unexpected label string: (-got +want)\n%s", diff)
 	}
@@ -165,10 +145,10 @@ func TestAddMonitoringPubsubLabelsToJob(t *testing.T) {
 	SetupForTesting()
 	job := baseProwJobTemplateData{}
 	addMonitoringPubsubLabelsToJob(&job, "foobar")
-	expected := []string{
-		"prow.k8s.io/pubsub.project: knative-tests",
-		"prow.k8s.io/pubsub.topic: knative-monitoring",
-		"prow.k8s.io/pubsub.runID: foobar",
+	expected := map[string]string{
+		"prow.k8s.io/pubsub.project": "knative-tests",
+		"prow.k8s.io/pubsub.topic":   "knative-monitoring",
+		"prow.k8s.io/pubsub.runID":   "foobar",
 	}
 	if diff := cmp.Diff(job.Labels, expected); diff != "" {
 		t.Fatalf("Unexpected pubsub label: (-got +want)\n%s", diff)
@@ -179,47 +159,28 @@ func TestAddVolumeToJob(t *testing.T) {
 	SetupForTesting()
 	mountPath := "somePath"
 	name := "foo"
-	content := []string{"bar", "baz"}
+	source := Volume{EmptyDir: &EmptyDirVolumeSource{}}
 
 	job := baseProwJobTemplateData{}
 	isSecret := false
-	addVolumeToJob(&job, mountPath, name, isSecret, content)
-	expectedVolumeMounts := []string{
-		"- name: foo",
-		"  mountPath: somePath",
-	}
+	addVolumeToJob(&job, mountPath, name, isSecret, source)
+	expectedVolumeMounts := []VolumeMount{{Name: "foo", MountPath: "somePath"}}
 	if diff := cmp.Diff(job.VolumeMounts, expectedVolumeMounts); diff != "" {
 		t.Fatalf("Unexpected volume mount: (-got +want)\n%s", diff)
 	}
-	expectedVolumes := []string{
-		"- name: foo",
-		"  bar",
-		"  baz",
-	}
-	for i := range expectedVolumes {
-		if diff := cmp.Diff(job.Volumes[i], expectedVolumes[i]); diff != "" {
-			t.Fatalf("Unexpected volume: (-got +want)\n%s", diff)
-		}
+	expectedVolumes := []Volume{{Name: "foo", EmptyDir: &EmptyDirVolumeSource{}}}
+	if diff := cmp.Diff(job.Volumes, expectedVolumes); diff != "" {
+		t.Fatalf("Unexpected volume: (-got +want)\n%s", diff)
 	}
 
 	job = baseProwJobTemplateData{}
 	isSecret = true
-	addVolumeToJob(&job, mountPath, name, isSecret, content)
-	expectedVolumeMounts = []string{
-		"- name: foo",
-		"  mountPath: somePath",
-		"  readOnly: true",
-	}
+	addVolumeToJob(&job, mountPath, name, isSecret, Volume{})
+	expectedVolumeMounts = []VolumeMount{{Name: "foo", MountPath: "somePath", ReadOnly: true}}
 	if diff := cmp.Diff(job.VolumeMounts, expectedVolumeMounts); diff != "" {
 		t.Fatalf("Unexpected volume mount: (-got +want)\n%s", diff)
 	}
-	expectedVolumes = []string{
-		"- name: foo",
-		"  secret:",
-		"    secretName: foo",
-		"  bar",
-		"  baz",
-	}
+	expectedVolumes = []Volume{{Name: "foo", Secret: &SecretVolumeSource{SecretName: "foo"}}}
 	if diff := cmp.Diff(job.Volumes, expectedVolumes); diff != "" {
 		t.Fatalf("Unexpected volume: (-got +want)\n%s", diff)
 	}
@@ -250,19 +211,11 @@ func TestConfigureServiceAccountForJob(t *testing.T) {
 
 	job = baseProwJobTemplateData{ServiceAccount: "/etc/foo/service-account.json"}
 	configureServiceAccountForJob(&job)
-	expectedVolumeMounts := []string{
-		"- name: foo",
-		"  mountPath: /etc/foo",
-		"  readOnly: true",
-	}
+	expectedVolumeMounts := []VolumeMount{{Name: "foo", MountPath: "/etc/foo", ReadOnly: true}}
 	if diff := cmp.Diff(job.VolumeMounts, expectedVolumeMounts); diff != "" {
 		t.Fatalf("Unexpected volume mount: (-got +want)\n%s", diff)
 	}
-	expectedVolumes := []string{
-		"- name: foo",
-		"  secret:",
-		"    secretName: foo",
-	}
+	expectedVolumes := []Volume{{Name: "foo", Secret: &SecretVolumeSource{SecretName: "foo"}}}
 	if diff := cmp.Diff(job.Volumes, expectedVolumes); diff != "" {
 		t.Fatalf("Unexpected volume: (-got +want)\n%s", diff)
 	}
@@ -272,13 +225,11 @@ func TestAddExtraEnvVarsToJob(t *testing.T) {
 	SetupForTesting()
 	job := baseProwJobTemplateData{}
 
-	in := []string{"foo=bar"}
+	in := []string{"foo=bar", `quoted="true"`}
 	addExtraEnvVarsToJob(in, &job)
-	if diff := cmp.Diff(job.Env[0], "- name: foo"); diff != "" {
-		t.Fatalf("Unexpected env name: (-got +want)\n%s", diff)
-	}
-	if diff := cmp.Diff(job.Env[1], "  value: bar"); diff != "" {
-		t.Fatalf("Unexpected env value: (-got +want)\n%s", diff)
+	expected := []EnvVar{{Name: "foo", Value: "bar"}, {Name: "quoted", Value: "true"}}
+	if diff := cmp.Diff(job.Env, expected); diff != "" {
+		t.Fatalf("Unexpected env: (-got +want)\n%s", diff)
 	}
 
 	in = []string{"foobar"}
@@ -295,7 +246,7 @@ func TestSetupDockerInDockerForJob(t *testing.T) {
 	if len(job.Volumes) == 0 || len(job.VolumeMounts) == 0 {
 		t.Fatalf("Docker in Docker setup did not create volumes and/or mounts")
 	}
-	if len(job.Env) == 0 || len(job.SecurityContext) == 0 {
+	if len(job.Env) == 0 || job.SecurityContext == nil || !*job.SecurityContext.Privileged {
 		t.Fatalf("Docker in Docker setup did not add env and/or set security context")
 	}
 }
@@ -316,13 +267,9 @@ func TestSetResourcesReqForJob(t *testing.T) {
 		yaml.MapItem{Key: "limits", Value: limits},
 	}
 	setResourcesReqForJob(resources, &job)
-	expectedResources := []string{
-		"  requests:",
-		"    memory: 12Gi",
-		"    disk: 12Ti",
-		"  limits:",
-		"    memory: 16Gi",
-		"    disk: 16Ti",
+	expectedResources := &ResourceRequirements{
+		Requests: map[string]string{"memory": "12Gi", "disk": "12Ti"},
+		Limits:   map[string]string{"memory": "16Gi", "disk": "16Ti"},
 	}
 	if diff := cmp.Diff(job.Resources, expectedResources); diff != "" {
 		t.Fatalf("Unexpected volume mount: (-got +want)\n%s", diff)
@@ -334,25 +281,29 @@ func TestSetReporterConfigReqForJob(t *testing.T) {
 	job := baseProwJobTemplateData{}
 	slack := yaml.MapSlice{
 		yaml.MapItem{Key: "channel", Value: "serving-api"},
-		yaml.MapItem{Key: "report_template", Value: "Report Template"},
-		yaml.MapItem{Key: "foo", Value: []interface{}{"bar", "baz"}},
+		yaml.MapItem{Key: "report_template", Value: `"Report Template"`},
+		yaml.MapItem{Key: "job_states_to_report", Value: []interface{}{"bar", "baz"}},
 	}
 	resources := yaml.MapSlice{
 		yaml.MapItem{Key: "slack", Value: slack},
 	}
 	setReporterConfigReqForJob(resources, &job)
 
-	expectedConfig := []string{
-		"  slack:",
-		"    channel: serving-api",
-		"    report_template: Report Template",
+	expectedConfig := &ReporterConfig{
+		Slack: &SlackReporterConfig{
+			Channel:           "serving-api",
+			ReportTemplate:    "Report Template",
+			JobStatesToReport: []string{"bar", "baz"},
+		},
 	}
 	if diff := cmp.Diff(job.ReporterConfig, expectedConfig); diff != "" {
 		t.Fatalf("Unexpected reporter config: (-got +want)\n%s", diff)
 	}
-	expectedJobStates := []string{"bar", "baz"}
-	if diff := cmp.Diff(job.JobStatesToReport, expectedJobStates); diff != "" {
-		t.Fatalf("Unexpected job states: (-got +want)\n%s", diff)
+
+	slack = append(slack, yaml.MapItem{Key: "foo", Value: "bar"})
+	setReporterConfigReqForJob(yaml.MapSlice{{Key: "slack", Value: slack}}, &job)
+	if logFatalCalls != 1 {
+		t.Fatalf("Unknown entry 'foo' should have caused error")
 	}
 }
 
@@ -373,7 +324,7 @@ func TestParseBasicJobConfigOverrides(t *testing.T) {
 	slack := yaml.MapSlice{
 		yaml.MapItem{Key: "channel", Value: "serving-api"},
 		yaml.MapItem{Key: "report_template", Value: "Report Template"},
-		yaml.MapItem{Key: "foo", Value: []interface{}{"bar", "baz"}},
+		yaml.MapItem{Key: "job_states_to_report", Value: []interface{}{"bar", "baz"}},
 	}
 	reporterConfig := yaml.MapSlice{
 		yaml.MapItem{Key: "slack", Value: slack},
@@ -384,7 +335,7 @@ func TestParseBasicJobConfigOverrides(t *testing.T) {
 		{Name: repoName, EnablePerformanceTests: false},
 	}
 
-	job := baseProwJobTemplateData{RepoBranch: "my_repo_branch", RepoName: repoName}
+	job := baseProwJobTemplateData{RepoBranch: "my_repo_branch", RepoName: repoName, ExtraRefs: []Refs{{Org: "org", Repo: repoName}}}
 	config := yaml.MapSlice{
 		yaml.MapItem{Key: "skip_branches", Value: []interface{}{"skip", "branches"}},
 		yaml.MapItem{Key: "branches", Value: []interface{}{"branch1", "branch2"}},
@@ -403,11 +354,11 @@ func TestParseBasicJobConfigOverrides(t *testing.T) {
 
 	parseBasicJobConfigOverrides(&job, config)
 
-	expected := []string{"  base_ref: my_repo_branch"}
-	if diff := cmp.Diff(job.ExtraRefs, expected); diff != "" {
+	expectedRefs := []Refs{{Org: "org", Repo: repoName, BaseRef: "my_repo_branch"}}
+	if diff := cmp.Diff(job.ExtraRefs, expectedRefs); diff != "" {
 		t.Fatalf("Unexpected base ref: (-got +want)\n%s", diff)
 	}
-	expected = []string{"skip", "branches"}
+	expected := []string{"skip", "branches"}
 	if diff := cmp.Diff(job.SkipBranches, expected); diff != "" {
 		t.Fatalf("Unexpected skip branches: (-got +want)\n%s", diff)
 	}
@@ -428,7 +379,7 @@ func TestParseBasicJobConfigOverrides(t *testing.T) {
 	if !job.NeedsMonitor {
 		t.Fatalf("Expected job.NeedsMonitor to be true")
 	}
-	if len(job.Volumes) == 0 || len(job.VolumeMounts) == 0 || len(job.SecurityContext) == 0 {
+	if len(job.Volumes) == 0 || len(job.VolumeMounts) == 0 || job.SecurityContext == nil {
 		t.Fatalf("Error in Docker in Docker setup")
 	}
 	if !job.AlwaysRun {
@@ -440,37 +391,28 @@ func TestParseBasicJobConfigOverrides(t *testing.T) {
 	if !repositories[0].EnablePerformanceTests {
 		t.Fatalf("Repository performance test should have been enabled")
 	}
-	// Note that the first 2 Env variables are from the Docker in Docker setup
-	if diff := cmp.Diff(job.Env[2], "- name: foo"); diff != "" {
-		t.Fatalf("Unexpected env name: (-got +want)\n%s", diff)
+	// Note that the first Env variable is from the Docker in Docker setup
+	if diff := cmp.Diff(job.Env[1], EnvVar{Name: "foo", Value: "bar"}); diff != "" {
+		t.Fatalf("Unexpected env: (-got +want)\n%s", diff)
 	}
-	if diff := cmp.Diff(job.Env[3], "  value: bar"); diff != "" {
-		t.Fatalf("Unexpected env value: (-got +want)\n%s", diff)
-	}
-	expectedResources := []string{
-		"  requests:",
-		"    memory: 12Gi",
-		"    disk: 12Ti",
-		"  limits:",
-		"    memory: 16Gi",
-		"    disk: 16Ti",
+	expectedResources := &ResourceRequirements{
+		Requests: map[string]string{"memory": "12Gi", "disk": "12Ti"},
+		Limits:   map[string]string{"memory": "16Gi", "disk": "16Ti"},
 	}
 	if diff := cmp.Diff(job.Resources, expectedResources); diff != "" {
 		t.Fatalf("Unexpected volume mount: (-got +want)\n%s", diff)
 	}
 
-	expectedReporterConfig := []string{
-		"  slack:",
-		"    channel: serving-api",
-		"    report_template: Report Template",
+	expectedReporterConfig := &ReporterConfig{
+		Slack: &SlackReporterConfig{
+			Channel:           "serving-api",
+			ReportTemplate:    "Report Template",
+			JobStatesToReport: []string{"bar", "baz"},
+		},
 	}
 	if diff := cmp.Diff(job.ReporterConfig, expectedReporterConfig); diff != "" {
 		t.Fatalf("Unexpected reporter config: (-got +want)\n%s", diff)
 	}
-	expectedJobStates := []string{"bar", "baz"}
-	if diff := cmp.Diff(job.JobStatesToReport, expectedJobStates); diff != "" {
-		t.Fatalf("Unexpected job states: (-got +want)\n%s", diff)
-	}
 
 	timeoutOverride = 999
 	parseBasicJobConfigOverrides(&job, config)
@@ -579,81 +521,6 @@ func TestGitHubRepo(t *testing.T) {
 	}
 }
 
-func TestExecuteJobTemplate(t *testing.T) {
-	SetupForTesting()
-	name := "foo"
-	templ := `
-- foo: [[.Foo]]
-[[indent_section 2 "bar" .Bar]]
-`
-	title := "my-title"
-	repoName := "my-repo-name"
-	jobName := "my-job-name"
-	groupByRepo := false
-	data := struct {
-		Foo string
-		Bar []string
-	}{
-		Foo: "Foo",
-		Bar: []string{"Bar", "Baz"},
-	}
-
-	jobNameFilter = "xyz"
-	executeJobTemplate(name, templ, title, repoName, jobName, groupByRepo, data)
-	if logFatalCalls != 0 {
-		t.Fatalf("Fatal log call recorded")
-	}
-	expected := ""
-	if diff := cmp.Diff(GetOutput(), expected); diff != "" {
-		t.Fatalf("Expected job to be filtered: (-got +want)\n%s", diff)
-	}
-
-	ResetOutput()
-	jobNameFilter = "my-job-name"
-	executeJobTemplate(name, templ, title, repoName, jobName, groupByRepo, data)
-	if logFatalCalls != 0 {
-		t.Fatalf("Fatal log call recorded")
-	}
-	if GetOutput() == "" {
-		t.Fatalf("Job should not have been filtered")
-	}
-
-	ResetOutput()
-	jobNameFilter = ""
-	sectionMap[title] = false
-	executeJobTemplate(name, templ, title, repoName, jobName, groupByRepo, data)
-	if logFatalCalls != 0 {
-		t.Fatalf("Fatal log call recorded")
-	}
-	expected = "my-title:\n- foo: Foo\nbar:\n  \"Bar\"\n  \"Baz\"\n"
-	if diff := cmp.Diff(GetOutput(), expected); diff != "" {
-		t.Fatalf("Bad execute job template output: (-got +want)\n%s", diff)
-	}
-
-	ResetOutput()
-	sectionMap[title] = true
-	executeJobTemplate(name, templ, title, repoName, jobName, groupByRepo, data)
-	if logFatalCalls != 0 {
-		t.Fatalf("Fatal log call recorded")
-	}
-	expected = "- foo: Foo\nbar:\n  \"Bar\"\n  \"Baz\"\n"
-	if diff := cmp.Diff(GetOutput(), expected); diff != "" {
-		t.Fatalf("Bad execute job template output: (-got +want)\n%s", diff)
-	}
-
-	ResetOutput()
-	groupByRepo = true
-	sectionMap[title+repoName] = false
-	executeJobTemplate(name, templ, title, repoName, jobName, groupByRepo, data)
-	if logFatalCalls != 0 {
-		t.Fatalf("Fatal log call recorded")
-	}
-	expected = "  my-repo-name:\n- foo: Foo\nbar:\n  \"Bar\"\n  \"Baz\"\n"
-	if diff := cmp.Diff(GetOutput(), expected); diff != "" {
-		t.Fatalf("Bad execute job template output: (-got +want)\n%s", diff)
-	}
-}
-
 func TestExecuteTemplate(t *testing.T) {
 	SetupForTesting()
 	name := "foo"
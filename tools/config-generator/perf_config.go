@@ -68,14 +68,13 @@ func generatePerfClusterPostsubmitJob(repo repositoryData) {
 func perfClusterPeriodicJob(jobNamePostFix, cronString, command string, args []string, repo repositoryData, sa string) {
 	var data periodicJobTemplateData
 	data.Base = perfClusterBaseProwJob(command, args, repo.Name, sa)
-	data.Base.ExtraRefs = append(data.Base.ExtraRefs, "  base_ref: "+data.Base.RepoBranch)
+	data.Base.ExtraRefs[0].BaseRef = data.Base.RepoBranch
 	data.PeriodicJobName = fmt.Sprintf("ci-%s-%s", data.Base.RepoNameForJob, jobNamePostFix)
 	data.CronString = cronString
 	data.PeriodicCommand = createCommand(data.Base)
-	data.Base.Annotations = []string{"  testgrid-create-test-group: \"false\""}
+	data.Base.Annotations = map[string]string{"testgrid-create-test-group": "false"}
 	addMonitoringPubsubLabelsToJob(&data.Base, data.PeriodicJobName)
-	executeJobTemplate("performance tests periodic", readTemplate(periodicTestJob),
-		"periodics", repo.Name, data.PeriodicJobName, false, data)
+	prowJobs.addPeriodic(periodicTestJob(data))
 }
 
 func perfClusterReconcilePostsubmitJob(jobNamePostFix, command string, args []string, repo repositoryData, sa string) {
@@ -85,15 +84,14 @@ func perfClusterReconcilePostsubmitJob(jobNamePostFix, command string, args []st
 	data.PostsubmitJobName = fmt.Sprintf("post-%s-%s", data.Base.RepoNameForJob, jobNamePostFix)
 	data.PostsubmitCommand = createCommand(data.Base)
 	addMonitoringPubsubLabelsToJob(&data.Base, data.PostsubmitJobName)
-	executeJobTemplate("performance tests postsubmit", readTemplate(perfPostsubmitJob),
-		"postsubmits", repo.Name, data.PostsubmitJobName, true, data)
+	prowJobs.addPostsubmit(repo.Name, perfPostsubmitJob(data))
 }
 
 func perfClusterBaseProwJob(command string, args []string, fullRepoName, sa string) baseProwJobTemplateData {
 	base := newbaseProwJobTemplateData(fullRepoName)
 	base.Command = command
 	base.Args = args
-	addVolumeToJob(&base, "/etc/performance-test", sa, true, nil)
+	addVolumeToJob(&base, "/etc/performance-test", sa, true, Volume{})
 	base.addEnvToJob("GOOGLE_APPLICATION_CREDENTIALS", "/etc/performance-test/service-account.json")
 	base.addEnvToJob("GITHUB_TOKEN", "/etc/performance-test/github-token")
 	base.addEnvToJob("SLACK_READ_TOKEN", "/etc/performance-test/slack-read-token")
@@ -31,7 +31,7 @@ func TestGeneratePerfClusterUpdatePeriodicJobs(t *testing.T) {
 		},
 	}
 	generatePerfClusterUpdatePeriodicJobs()
-	if logFatalCalls != 0 || len(GetOutput()) == 0 {
+	if logFatalCalls != 0 || GetJobCount() == 0 {
 		t.Errorf("Expected job to be written without errors")
 	}
 
@@ -43,7 +43,7 @@ func TestGeneratePerfClusterUpdatePeriodicJobs(t *testing.T) {
 		},
 	}
 	generatePerfClusterUpdatePeriodicJobs()
-	if len(GetOutput()) != 0 {
+	if GetJobCount() != 0 {
 		t.Errorf("Expected nothing to be written")
 	}
 }
@@ -51,7 +51,7 @@ func TestGeneratePerfClusterUpdatePeriodicJobs(t *testing.T) {
 func TestGeneratePerfClusterPostsubmitJob(t *testing.T) {
 	SetupForTesting()
 	generatePerfClusterPostsubmitJob(repositoryData{Name: "my-repo"})
-	if logFatalCalls != 0 || len(GetOutput()) == 0 {
+	if logFatalCalls != 0 || GetJobCount() == 0 {
 		t.Errorf("Expected job to be written without errors")
 	}
 }
@@ -61,7 +61,7 @@ func TestPerfClusterPeriodicJob(t *testing.T) {
 	repoData := repositoryData{Name: "my-repo"}
 	perfClusterPeriodicJob("postfix", "cronString", "command", []string{"arg1", "arg2"}, repoData, "sa")
 
-	if logFatalCalls != 0 || len(GetOutput()) == 0 {
+	if logFatalCalls != 0 || GetJobCount() == 0 {
 		t.Errorf("Expected job to be written without errors")
 	}
 }
@@ -71,7 +71,7 @@ func TestPerfClusterReconcilePostsubmitJob(t *testing.T) {
 	repoData := repositoryData{Name: "my-repo"}
 	perfClusterReconcilePostsubmitJob("postfix", "command", []string{"arg1", "arg2"}, repoData, "sa")
 
-	if logFatalCalls != 0 || len(GetOutput()) == 0 {
+	if logFatalCalls != 0 || GetJobCount() == 0 {
 		t.Errorf("Expected job to be written without errors")
 	}
 }
@@ -93,7 +93,7 @@ func TestPerfClusterBaseProwJob(t *testing.T) {
 	if diff := cmp.Diff(res.Command, command); diff != "" {
 		t.Errorf("Incorrect command: (-got +want)\n%s", diff)
 	}
-	if want, got := 8, len(res.Env); want != got {
-		t.Errorf("Expected 8 environments, got %d", len(res.Env))
+	if want, got := 4, len(res.Env); want != got {
+		t.Errorf("Expected 4 environments, got %d", len(res.Env))
 	}
 }
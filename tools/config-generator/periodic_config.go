@@ -19,8 +19,7 @@ limitations under the License.
 package main
 
 import (
-	"bytes"
-	"encoding/gob"
+	"encoding/json"
 	"fmt"
 	"hash/fnv"
 	"log"
@@ -30,12 +29,6 @@ import (
 )
 
 const (
-	// Template for periodic test/release jobs.
-	periodicTestJob = "prow_periodic_test_job.yaml"
-
-	// Template for periodic custom jobs.
-	periodicCustomJob = "prow_periodic_custom_job.yaml"
-
 	// Cron strings for key jobs
 	goCoveragePeriodicJobCron          = "0 1 * * *"   // Run at 01:00 every day
 	recreatePerfClusterPeriodicJobCron = "30 07 * * *" // Run at 00:30PST every day (07:30 UTC)
@@ -52,19 +45,46 @@ type periodicJobTemplateData struct {
 
 func (p periodicJobTemplateData) Clone() periodicJobTemplateData {
 	var r periodicJobTemplateData
-	var err error
-	buff := new(bytes.Buffer)
-	enc := gob.NewEncoder(buff)
-	dec := gob.NewDecoder(buff)
-	if err = enc.Encode(&p); err != nil {
+	b, err := json.Marshal(&p)
+	if err != nil {
 		panic(err)
 	}
-	if err = dec.Decode(&r); err != nil {
+	if err = json.Unmarshal(b, &r); err != nil {
 		panic(err)
 	}
 	return r
 }
 
+// periodicTestJob returns the periodic job running the command of the job,
+// for test and release jobs.
+func periodicTestJob(data periodicJobTemplateData) Periodic {
+	container := data.Base.container([]string{"runner.sh"}, data.PeriodicCommand)
+	job := Periodic{
+		Cron:     data.CronString,
+		JobBase:  data.Base.jobBase(data.PeriodicJobName, container),
+		Brancher: Brancher{SkipBranches: data.Base.SkipBranches, Branches: data.Base.Branches},
+	}
+	job.ReporterConfig = data.Base.ReporterConfig
+	job.DecorationConfig = data.Base.DecorationConfig
+	job.ExtraRefs = data.Base.ExtraRefs
+	return job
+}
+
+// periodicCustomJob returns the periodic job running the command and
+// arguments of the job as is, for go coverage jobs.
+func periodicCustomJob(data periodicJobTemplateData) Periodic {
+	container := data.Base.container([]string{data.Base.Command}, data.Base.Args)
+	container.SecurityContext = nil
+	job := Periodic{
+		Cron:     data.CronString,
+		JobBase:  data.Base.jobBase(data.PeriodicJobName, container),
+		Brancher: Brancher{SkipBranches: data.Base.SkipBranches, Branches: data.Base.Branches},
+	}
+	job.DecorationConfig = data.Base.DecorationConfig
+	job.ExtraRefs = data.Base.ExtraRefs
+	return job
+}
+
 func getUTCtime(i int) int {
 	r := i + 7
 	if r > 23 {
@@ -128,7 +148,6 @@ func generatePeriodic(title string, repoName string, periodicConfig yaml.MapSlic
 	var data periodicJobTemplateData
 	data.Base = newbaseProwJobTemplateData(repoName)
 	jobNameSuffix := ""
-	jobTemplate := readTemplate(periodicTestJob)
 	jobType := ""
 	isContinuousJob := false
 	org := data.Base.OrgName
@@ -186,7 +205,7 @@ func generatePeriodic(title string, repoName string, periodicConfig yaml.MapSlic
 				"--release-gcs " + data.Base.ReleaseGcs,
 				"--release-gcr gcr.io/knative-releases",
 				"--github-token /etc/hub-token/token"}
-			addVolumeToJob(&data.Base, "/etc/hub-token", "hub-token", true, nil)
+			addVolumeToJob(&data.Base, "/etc/hub-token", "hub-token", true, Volume{})
 			// For dot-release and auto-release jobs, set ORG_NAME env var if the org name is not knative, as it's needed by release.sh
 			if data.Base.OrgName != "knative" {
 				data.Base.addEnvToJob("ORG_NAME", data.Base.OrgName)
@@ -251,10 +270,10 @@ func generatePeriodic(title string, repoName string, periodicConfig yaml.MapSlic
 	}
 	addExtraEnvVarsToJob(extraEnvVars, &data.Base)
 	configureServiceAccountForJob(&data.Base)
-	data.Base.DecorationConfig = []string{fmt.Sprintf("timeout: %dm", data.Base.Timeout)}
+	data.Base.DecorationConfig = &DecorationConfig{Timeout: fmt.Sprintf("%dm", data.Base.Timeout)}
 
-	// This is where the data actually gets written out
-	executeJobTemplate("periodic", jobTemplate, title, repoName, data.PeriodicJobName, false, data)
+	// This is where the job actually gets added
+	prowJobs.addPeriodic(periodicTestJob(data))
 
 	// If job is a continuous run, add a duplicate for pre-release testing of new prow-tests image
 	// It will (mostly) run less often than source job
@@ -266,10 +285,8 @@ func generatePeriodic(title string, repoName string, periodicConfig yaml.MapSlic
 		betaData.Base.Image = strings.ReplaceAll(betaData.Base.Image, ":stable", ":beta")
 
 		// These jobs all get lumped together in a single Testgrid dashboard
-		dashboardAnnotation := fmtDashboardAnnotation("knative-prow-tests")
-		tabAnnotation := betaData.Base.Annotations[1] + "-beta-prow-tests"
-		betaData.Base.Annotations[0] = dashboardAnnotation
-		betaData.Base.Annotations[1] = tabAnnotation
+		betaData.Base.Annotations[testgridDashboardsAnnotation] = "knative-prow-tests"
+		betaData.Base.Annotations[testgridTabNameAnnotation] += "-beta-prow-tests"
 
 		// Run 2 or 3 times a day because prow-tests beta testing has different desired interval than the underlying job
 		hours := []int{getUTCtime(1), getUTCtime(4)}
@@ -286,8 +303,8 @@ func generatePeriodic(title string, repoName string, periodicConfig yaml.MapSlic
 			calculateMinuteOffset(jobType, betaData.PeriodicJobName),
 			strings.Join(hoursStr, ","))
 
-		// Write out our duplicate job
-		executeJobTemplate("periodic", jobTemplate, title, repoName, betaData.PeriodicJobName, false, betaData)
+		// Add our duplicate job
+		prowJobs.addPeriodic(periodicTestJob(betaData))
 
 		// Setup TestGrid here
 		// Each job becomes one of "test_groups"
@@ -329,7 +346,7 @@ func generateGoCoveragePeriodic(title string, repoName string, _ yaml.MapSlice)
 			"--artifacts=$(ARTIFACTS)",
 			fmt.Sprintf("--cov-threshold-percentage=%d", data.Base.GoCoverageThreshold)}
 		data.Base.ServiceAccount = ""
-		data.Base.ExtraRefs = append(data.Base.ExtraRefs, "  base_ref: "+data.Base.RepoBranch)
+		data.Base.ExtraRefs[0].BaseRef = data.Base.RepoBranch
 
 		addExtraEnvVarsToJob(extraEnvVars, &data.Base)
 		addMonitoringPubsubLabelsToJob(&data.Base, data.PeriodicJobName)
@@ -338,7 +355,7 @@ func generateGoCoveragePeriodic(title string, repoName string, _ yaml.MapSlice)
 		tabName := data.Base.RepoNameForJob + "-" + jobNameSuffix
 		testgroupExtras := map[string]string{"short-text-metric": "coverage"}
 		data.Base.Annotations = generateProwJobAnnotations(dashboardName, tabName, testgroupExtras)
-		executeJobTemplate("periodic go coverage", readTemplate(periodicCustomJob), title, repoName, data.PeriodicJobName, false, data)
+		prowJobs.addPeriodic(periodicCustomJob(data))
 
 		betaData := data.Clone()
 
@@ -356,8 +373,8 @@ func generateGoCoveragePeriodic(title string, repoName string, _ yaml.MapSlice)
 			calculateMinuteOffset("go-coverage", betaData.PeriodicJobName),
 			fmt.Sprint(getUTCtime(0)))
 
-		// Write out our duplicate job
-		executeJobTemplate("periodic go coverage", readTemplate(periodicCustomJob), title, repoName, betaData.PeriodicJobName, false, betaData)
+		// Add our duplicate job
+		prowJobs.addPeriodic(periodicCustomJob(betaData))
 
 		// Setup TestGrid here
 		// Each job becomes one of "test_groups"
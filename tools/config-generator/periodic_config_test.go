@@ -26,7 +26,10 @@ import (
 
 func TestClone(t *testing.T) {
 	SetupForTesting()
-	base := baseProwJobTemplateData{OrgName: "org-name"}
+	base := baseProwJobTemplateData{
+		OrgName: "org-name",
+		Volumes: []Volume{{Name: "docker-graph", EmptyDir: &EmptyDirVolumeSource{}}},
+	}
 	data := periodicJobTemplateData{
 		Base:            base,
 		PeriodicJobName: "periodic-job-name",
@@ -138,9 +141,9 @@ func TestGeneratePeriodic(t *testing.T) {
 	for _, item := range items {
 		periodicConfig = yaml.MapSlice{item}
 		generatePeriodic(title, repoName, periodicConfig)
-		outputLen := len(GetOutput())
-		if outputLen == 0 {
-			t.Fatalf("Failure for key %d: No output", outputLen)
+		jobCount := GetJobCount()
+		if jobCount == 0 {
+			t.Fatalf("Failure for key %d: No output", jobCount)
 		}
 		if logFatalCalls != 0 {
 			t.Fatalf("Failure for key %s: LogFatal was called.", item.Key)
@@ -159,7 +162,7 @@ func TestGenerateGoCoveragePeriodic(t *testing.T) {
 		},
 	}
 	generateGoCoveragePeriodic("title", "repo-name", nil)
-	if len(GetOutput()) == 0 {
+	if GetJobCount() == 0 {
 		t.Fatalf("No output")
 	}
 	if logFatalCalls != 0 {
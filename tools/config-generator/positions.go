@@ -0,0 +1,169 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlNode records where a value of a yaml document starts, so problems found
+// after unmarshalling it can be reported with their position in the file.
+type yamlNode struct {
+	line, column int
+	keys         map[string]*yamlNode
+	items        []*yamlNode
+}
+
+// child returns the node of the given map key or sequence index, or n itself
+// if there is no such child (e.g. the value is written in flow style).
+func (n *yamlNode) child(k interface{}) *yamlNode {
+	if i, ok := k.(int); ok {
+		if i >= 0 && i < len(n.items) {
+			return n.items[i]
+		}
+		return n
+	}
+	if c, ok := n.keys[fmt.Sprint(k)]; ok {
+		return c
+	}
+	return n
+}
+
+// indexYamlPositions records the position of every map key and sequence item
+// written in block style in the given yaml document. It only understands the
+// subset of yaml used by the config files, but never fails: anything it cannot
+// follow is simply left out of the index.
+func indexYamlPositions(content []byte) *yamlNode {
+	type frame struct {
+		indent int       // Column (0-based) of the entries of node.
+		node   *yamlNode // Map or sequence the entries are added to.
+		last   *yamlNode // Last entry added to node.
+	}
+	root := &yamlNode{line: 1, column: 1}
+	stack := []*frame{{node: root}}
+	// Lines indented deeper than this continue a scalar value.
+	scalarIndent := -1
+	for i, line := range strings.Split(string(content), "\n") {
+		text := strings.TrimRight(line, " \t\r")
+		trimmed := strings.TrimLeft(text, " ")
+		indent := len(text) - len(trimmed)
+		if scalarIndent >= 0 && indent > scalarIndent {
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "---") {
+			continue
+		}
+		scalarIndent = -1
+
+		for len(stack) > 1 && stack[len(stack)-1].indent > indent {
+			stack = stack[:len(stack)-1]
+		}
+		top := stack[len(stack)-1]
+		isItem := isYamlItem(trimmed)
+		// A sequence written at the indentation of its key ends with the next key.
+		if len(stack) > 1 && top.indent == indent && top.node.items != nil && !isItem {
+			stack = stack[:len(stack)-1]
+			top = stack[len(stack)-1]
+		}
+		if indent > top.indent || (indent == top.indent && isItem && top.node.items == nil && top.last != nil) {
+			if top.last == nil {
+				continue
+			}
+			top = &frame{indent: indent, node: top.last}
+			stack = append(stack, top)
+		}
+
+		// A sequence item can start a map (or another sequence) on its own line.
+		for isItem {
+			n := &yamlNode{line: i + 1, column: indent + 1}
+			top.node.items = append(top.node.items, n)
+			top.last = n
+			rest := strings.TrimLeft(trimmed[1:], " ")
+			if rest == "" || strings.HasPrefix(rest, "#") {
+				break
+			}
+			indent += len(trimmed) - len(rest)
+			trimmed = rest
+			top = &frame{indent: indent, node: n}
+			stack = append(stack, top)
+			isItem = isYamlItem(trimmed)
+		}
+		if isItem {
+			continue
+		}
+		key, value, ok := splitYamlKey(trimmed)
+		if !ok {
+			// A scalar, possibly spanning the following lines.
+			scalarIndent = indent
+			continue
+		}
+		n := &yamlNode{line: i + 1, column: indent + 1}
+		if top.node.keys == nil {
+			top.node.keys = make(map[string]*yamlNode)
+		}
+		top.node.keys[key] = n
+		top.last = n
+		if value != "" && !strings.HasPrefix(value, "#") {
+			scalarIndent = indent
+		}
+	}
+	return root
+}
+
+// isYamlItem returns whether the given line content is a sequence item.
+func isYamlItem(s string) bool {
+	return s == "-" || strings.HasPrefix(s, "- ")
+}
+
+// splitYamlKey splits the given line content into a map key and its inline
+// value. It returns false if the content is not a map entry.
+func splitYamlKey(s string) (string, string, bool) {
+	var key, rest string
+	switch {
+	case strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'"):
+		end := strings.Index(s[1:], s[:1])
+		if end < 0 {
+			return "", "", false
+		}
+		end += 2
+		key, rest = s[:end], s[end:]
+		if s[0] == '"' {
+			if k, err := strconv.Unquote(key); err == nil {
+				key = k
+			}
+		} else {
+			key = key[1 : len(key)-1]
+		}
+		if rest != ":" && !strings.HasPrefix(rest, ": ") {
+			return "", "", false
+		}
+	case strings.HasPrefix(s, "{") || strings.HasPrefix(s, "["):
+		return "", "", false
+	default:
+		end := strings.Index(s, ": ")
+		if end < 0 {
+			if !strings.HasSuffix(s, ":") {
+				return "", "", false
+			}
+			end = len(s) - 1
+		}
+		key, rest = strings.TrimRight(s[:end], " "), s[end:]
+	}
+	return key, strings.TrimSpace(rest[1:]), true
+}
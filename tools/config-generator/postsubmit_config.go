@@ -23,15 +23,6 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
-const (
-	// goCoveragePostsubmitJob is the template for the go postsubmit coverage job.
-	goCoveragePostsubmitJob = "prow_postsubmit_gocoverage_job.yaml"
-
-	// perfPostsubmitJob is the template for the performance operations
-	// postsubmit job.
-	perfPostsubmitJob = "prow_postsubmit_perf_job.yaml"
-)
-
 // postsubmitJobTemplateData contains data about a postsubmit Prow job.
 type postsubmitJobTemplateData struct {
 	Base              baseProwJobTemplateData
@@ -39,6 +30,40 @@ type postsubmitJobTemplateData struct {
 	PostsubmitCommand []string
 }
 
+// postsubmitJob returns the postsubmit job running the given container, which
+// is not shown in testgrid.
+func postsubmitJob(data postsubmitJobTemplateData, container Container) Postsubmit {
+	job := Postsubmit{
+		JobBase:  data.Base.jobBase(data.PostsubmitJobName, container),
+		Brancher: Brancher{Branches: data.Base.Branches},
+	}
+	job.Annotations = map[string]string{"testgrid-create-test-group": "false"}
+	job.PathAlias = data.Base.PathAlias
+	return job
+}
+
+// goCoveragePostsubmitJob returns the go coverage postsubmit job.
+func goCoveragePostsubmitJob(data postsubmitJobTemplateData) Postsubmit {
+	container := Container{
+		Command:   []string{"runner.sh"},
+		Args:      []string{"coverage", "--artifacts=$(ARTIFACTS)", "--cov-threshold-percentage=0"},
+		Env:       data.Base.Env,
+		Resources: data.Base.Resources,
+	}
+	job := postsubmitJob(data, container)
+	job.Spec.Volumes = nil
+	return job
+}
+
+// perfPostsubmitJob returns the performance operations postsubmit job.
+func perfPostsubmitJob(data postsubmitJobTemplateData) Postsubmit {
+	container := data.Base.container([]string{"runner.sh"}, data.PostsubmitCommand)
+	container.SecurityContext = nil
+	job := postsubmitJob(data, container)
+	job.MaxConcurrency = 1
+	return job
+}
+
 // generateGoCoveragePostsubmit generates the go coverage postsubmit job config for the given repo.
 func generateGoCoveragePostsubmit(title, repoName string, _ yaml.MapSlice) {
 	var data postsubmitJobTemplateData
@@ -47,13 +72,12 @@ func generateGoCoveragePostsubmit(title, repoName string, _ yaml.MapSlice) {
 	data.PostsubmitJobName = fmt.Sprintf("post-%s-go-coverage", data.Base.RepoNameForJob)
 	addExtraEnvVarsToJob(extraEnvVars, &data.Base)
 	configureServiceAccountForJob(&data.Base)
-	jobName := data.PostsubmitJobName
-	executeJobTemplate("postsubmit go coverage", readTemplate(goCoveragePostsubmitJob), title, repoName, jobName, true, data)
+	prowJobs.addPostsubmit(repoName, goCoveragePostsubmitJob(data))
 	// Generate config for post-knative-serving-go-coverage-dev right after post-knative-serving-go-coverage,
 	// this job is mainly for debugging purpose.
 	if data.PostsubmitJobName == "post-knative-serving-go-coverage" {
 		data.PostsubmitJobName += "-dev"
 		data.Base.Image = strings.ReplaceAll(data.Base.Image, ":stable", ":coverage-dev")
-		executeJobTemplate("postsubmit go coverage", readTemplate(goCoveragePostsubmitJob), title, repoName, data.PostsubmitJobName, false, data)
+		prowJobs.addPostsubmit(repoName, goCoveragePostsubmitJob(data))
 	}
 }
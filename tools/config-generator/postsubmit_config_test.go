@@ -23,7 +23,7 @@ import (
 func TestGenerateGoCoveragePostsubmit(t *testing.T) {
 	SetupForTesting()
 	generateGoCoveragePostsubmit("title", "knative-serving", nil)
-	if len(GetOutput()) == 0 {
+	if GetJobCount() == 0 {
 		t.Errorf("No output")
 	}
 	if logFatalCalls != 0 {
@@ -17,19 +17,12 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
 	"strings"
 
 	"gopkg.in/yaml.v2"
 )
 
-const (
-	// presubmitJob is the template for presubmit jobs.
-	presubmitJob = "prow_presubmit_job.yaml"
-
-	// presubmitGoCoverageJob is the template for go coverage presubmit jobs.
-	presubmitGoCoverageJob = "prow_presubmit_gocoverage_job.yaml"
-)
-
 // presubmitJobTemplateData contains data about a presubmit Prow job.
 type presubmitJobTemplateData struct {
 	Base                 baseProwJobTemplateData
@@ -40,6 +33,47 @@ type presubmitJobTemplateData struct {
 	RunIfChanged         string
 }
 
+// presubmitJob returns the presubmit job running the command of the job.
+func presubmitJob(data presubmitJobTemplateData) Presubmit {
+	container := data.Base.container([]string{"runner.sh"}, data.PresubmitCommand)
+	job := Presubmit{
+		JobBase:      data.Base.jobBase(data.PresubmitPullJobName, container),
+		AlwaysRun:    data.Base.AlwaysRun,
+		RunIfChanged: data.RunIfChanged,
+		Optional:     data.Base.Optional,
+		Trigger:      presubmitTrigger(data.PresubmitPullJobName, true),
+		RerunCommand: "/test " + data.PresubmitPullJobName,
+		Brancher:     Brancher{SkipBranches: data.Base.SkipBranches, Branches: data.Base.Branches},
+		Context:      data.PresubmitPullJobName,
+	}
+	job.PathAlias = data.Base.PathAlias
+	return job
+}
+
+// presubmitGoCoverageJob returns the go coverage presubmit job. If all is
+// true, the job is triggered by "/test all" too.
+func presubmitGoCoverageJob(data presubmitJobTemplateData, all bool) Presubmit {
+	container := data.Base.container([]string{"runner.sh"}, []string{
+		"coverage",
+		"--postsubmit-job-name=" + data.PresubmitPostJobName,
+		"--artifacts=$(ARTIFACTS)",
+		fmt.Sprintf("--cov-threshold-percentage=%d", data.Base.GoCoverageThreshold),
+		"--github-token=/etc/covbot-token/token",
+	})
+	container.SecurityContext = nil
+	job := Presubmit{
+		JobBase:      data.Base.jobBase(data.PresubmitPullJobName, container),
+		AlwaysRun:    data.Base.AlwaysRun,
+		Optional:     true,
+		Trigger:      presubmitTrigger(data.PresubmitPullJobName, all),
+		RerunCommand: "/test " + data.PresubmitPullJobName,
+		Brancher:     Brancher{SkipBranches: data.Base.SkipBranches, Branches: data.Base.Branches},
+		Context:      data.PresubmitPullJobName,
+	}
+	job.PathAlias = data.Base.PathAlias
+	return job
+}
+
 // generatePresubmit generates all presubmit job configs for the given repo and configuration.
 // While this function is designed to only make one "logical" presubmit, it does generate multiple separate jobs when different branches need different settings
 //  i.e. it creates all jobs pull-knative-serving-build-tests per single invocation
@@ -50,7 +84,7 @@ func generatePresubmit(title string, repoName string, presubmitConfig yaml.MapSl
 	data.Base = newbaseProwJobTemplateData(repoName)
 	data.Base.Command = presubmitScript
 	data.Base.GoCoverageThreshold = 50
-	jobTemplate := readTemplate(presubmitJob)
+	newJob := presubmitJob
 	repoData := repositoryData{Name: repoName, EnableGoCoverage: false, GoCoverageThreshold: data.Base.GoCoverageThreshold}
 	generateJob := true
 	for i, item := range presubmitConfig {
@@ -65,16 +99,18 @@ func generatePresubmit(title string, repoName string, presubmitConfig yaml.MapSl
 			if len(data.Base.Args) == 0 {
 				data.Base.Args = []string{"--" + jobName}
 			}
-			addVolumeToJob(&data.Base, "/etc/repoview-token", "repoview-token", true, nil)
+			addVolumeToJob(&data.Base, "/etc/repoview-token", "repoview-token", true, Volume{})
 		case "go-coverage":
 			if !getBool(item.Value) {
 				return
 			}
-			jobTemplate = readTemplate(presubmitGoCoverageJob)
+			newJob = func(data presubmitJobTemplateData) Presubmit {
+				return presubmitGoCoverageJob(data, true)
+			}
 			data.PresubmitJobName = data.Base.RepoNameForJob + "-go-coverage"
 			data.Base.ServiceAccount = ""
 			repoData.EnableGoCoverage = true
-			addVolumeToJob(&data.Base, "/etc/covbot-token", "covbot-token", true, nil)
+			addVolumeToJob(&data.Base, "/etc/covbot-token", "covbot-token", true, Volume{})
 		case "custom-test":
 			data.PresubmitJobName = data.Base.RepoNameForJob + "-" + getString(item.Value)
 		case "go-coverage-threshold":
@@ -83,7 +119,7 @@ func generatePresubmit(title string, repoName string, presubmitConfig yaml.MapSl
 		case "repo-settings":
 			generateJob = false
 		case "run-if-changed":
-			data.RunIfChanged = "run_if_changed: \"" + getString(item.Value) + "\""
+			data.RunIfChanged = getString(item.Value)
 		default:
 			continue
 		}
@@ -107,10 +143,9 @@ func generatePresubmit(title string, repoName string, presubmitConfig yaml.MapSl
 	}
 	addExtraEnvVarsToJob(extraEnvVars, &data.Base)
 	configureServiceAccountForJob(&data.Base)
-	jobName := data.PresubmitPullJobName
 
-	// This is where the data actually gets written out
-	executeJobTemplate("presubmit", jobTemplate, title, repoName, jobName, true, data)
+	// This is where the job actually gets added
+	prowJobs.addPresubmit(repoName, newJob(data))
 
 	// Generate config for pull-knative-serving-go-coverage-dev right after pull-knative-serving-go-coverage,
 	// this job is mainly for debugging purpose.
@@ -118,7 +153,6 @@ func generatePresubmit(title string, repoName string, presubmitConfig yaml.MapSl
 		data.PresubmitPullJobName += "-dev"
 		data.Base.AlwaysRun = false
 		data.Base.Image = strings.ReplaceAll(data.Base.Image, ":stable", ":coverage-dev")
-		template := strings.Replace(readTemplate(presubmitGoCoverageJob), "(all|", "(", 1)
-		executeJobTemplate("presubmit", template, title, repoName, data.PresubmitPullJobName, true, data)
+		prowJobs.addPresubmit(repoName, presubmitGoCoverageJob(data, false))
 	}
 }
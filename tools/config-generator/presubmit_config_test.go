@@ -39,8 +39,8 @@ func TestGeneratePresubmit(t *testing.T) {
 	for _, item := range items {
 		presubmitConfig = yaml.MapSlice{item}
 		generatePresubmit(title, repoName, presubmitConfig)
-		outputLen := len(GetOutput())
-		if outputLen == 0 {
+		jobCount := GetJobCount()
+		if jobCount == 0 {
 			t.Errorf("Failure for key %s: No output", item.Key)
 		}
 		if logFatalCalls != 0 {
@@ -0,0 +1,264 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Typed Prow jobs. The types mirror the subset of the Prow job schema, ex:
+// JobBase and the Kubernetes PodSpec, used by the generated jobs, so jobs are
+// marshaled to yaml instead of being rendered by templates.
+
+package main
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ProwJobs is the top-level Prow job config. Jobs are keyed by "org/repo".
+type ProwJobs struct {
+	Presubmits  map[string][]Presubmit  `yaml:"presubmits,omitempty"`
+	Periodics   []Periodic              `yaml:"periodics,omitempty"`
+	Postsubmits map[string][]Postsubmit `yaml:"postsubmits,omitempty"`
+}
+
+// JobBase holds the fields common to all kinds of Prow jobs.
+type JobBase struct {
+	Name           string            `yaml:"name"`
+	Labels         map[string]string `yaml:"labels,omitempty"`
+	MaxConcurrency int               `yaml:"max_concurrency,omitempty"`
+	Agent          string            `yaml:"agent"`
+	Cluster        string            `yaml:"cluster,omitempty"`
+	Annotations    map[string]string `yaml:"annotations,omitempty"`
+	ReporterConfig *ReporterConfig   `yaml:"reporter_config,omitempty"`
+	UtilityConfig  `yaml:",inline"`
+	Spec           *PodSpec `yaml:"spec,omitempty"`
+}
+
+// UtilityConfig holds the decoration settings of a job.
+type UtilityConfig struct {
+	Decorate         bool              `yaml:"decorate,omitempty"`
+	PathAlias        string            `yaml:"path_alias,omitempty"`
+	ExtraRefs        []Refs            `yaml:"extra_refs,omitempty"`
+	DecorationConfig *DecorationConfig `yaml:"decoration_config,omitempty"`
+}
+
+// Refs is a repo cloned by a job.
+type Refs struct {
+	Org       string `yaml:"org"`
+	Repo      string `yaml:"repo"`
+	PathAlias string `yaml:"path_alias,omitempty"`
+	BaseRef   string `yaml:"base_ref,omitempty"`
+}
+
+// DecorationConfig overrides the default decoration of a job.
+type DecorationConfig struct {
+	Timeout string `yaml:"timeout,omitempty"`
+}
+
+// ReporterConfig configures where the results of a job are reported.
+type ReporterConfig struct {
+	Slack *SlackReporterConfig `yaml:"slack,omitempty"`
+}
+
+// SlackReporterConfig reports the results of a job to a slack channel.
+type SlackReporterConfig struct {
+	Host              string   `yaml:"host,omitempty"`
+	Channel           string   `yaml:"channel,omitempty"`
+	JobStatesToReport []string `yaml:"job_states_to_report,omitempty"`
+	ReportTemplate    string   `yaml:"report_template,omitempty"`
+}
+
+// Brancher selects the branches a job runs against.
+type Brancher struct {
+	SkipBranches []string `yaml:"skip_branches,omitempty"`
+	Branches     []string `yaml:"branches,omitempty"`
+}
+
+// Presubmit is a job run against pull requests.
+type Presubmit struct {
+	JobBase      `yaml:",inline"`
+	AlwaysRun    bool   `yaml:"always_run"`
+	RunIfChanged string `yaml:"run_if_changed,omitempty"`
+	Optional     bool   `yaml:"optional"`
+	Trigger      string `yaml:"trigger"`
+	RerunCommand string `yaml:"rerun_command"`
+	Brancher     `yaml:",inline"`
+	Context      string `yaml:"context"`
+}
+
+// Postsubmit is a job run after changes are merged.
+type Postsubmit struct {
+	JobBase  `yaml:",inline"`
+	Brancher `yaml:",inline"`
+}
+
+// Periodic is a job run on a schedule.
+type Periodic struct {
+	Cron    string `yaml:"cron"`
+	JobBase `yaml:",inline"`
+	// Brancher is not part of the Prow schema of periodic jobs, but is kept
+	// for the configs setting it.
+	Brancher `yaml:",inline"`
+}
+
+// PodSpec is the pod running a job.
+type PodSpec struct {
+	Containers []Container `yaml:"containers"`
+	Volumes    []Volume    `yaml:"volumes,omitempty"`
+}
+
+// Container is a container of the pod running a job.
+type Container struct {
+	Image           string                `yaml:"image"`
+	ImagePullPolicy string                `yaml:"imagePullPolicy,omitempty"`
+	Command         []string              `yaml:"command,omitempty"`
+	Args            []string              `yaml:"args,omitempty"`
+	SecurityContext *SecurityContext      `yaml:"securityContext,omitempty"`
+	VolumeMounts    []VolumeMount         `yaml:"volumeMounts,omitempty"`
+	Env             []EnvVar              `yaml:"env,omitempty"`
+	Resources       *ResourceRequirements `yaml:"resources,omitempty"`
+}
+
+// SecurityContext is the security context of a container.
+type SecurityContext struct {
+	Privileged *bool `yaml:"privileged,omitempty"`
+}
+
+// VolumeMount mounts a volume in a container.
+type VolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+// EnvVar is an environment variable of a container.
+type EnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// ResourceRequirements are the resources of a container, ex: "memory": "12Gi".
+type ResourceRequirements struct {
+	Requests map[string]string `yaml:"requests,omitempty"`
+	Limits   map[string]string `yaml:"limits,omitempty"`
+}
+
+// Volume is a volume of the pod running a job. Only one source is set.
+type Volume struct {
+	Name     string                `yaml:"name"`
+	Secret   *SecretVolumeSource   `yaml:"secret,omitempty"`
+	EmptyDir *EmptyDirVolumeSource `yaml:"emptyDir,omitempty"`
+	HostPath *HostPathVolumeSource `yaml:"hostPath,omitempty"`
+}
+
+// SecretVolumeSource is a volume holding a secret.
+type SecretVolumeSource struct {
+	SecretName string `yaml:"secretName"`
+}
+
+// EmptyDirVolumeSource is an empty volume sharing the lifetime of the pod.
+type EmptyDirVolumeSource struct {
+	Medium string `yaml:"medium,omitempty"`
+}
+
+// HostPathVolumeSource is a volume mapping a path of the host.
+type HostPathVolumeSource struct {
+	Path string `yaml:"path"`
+	Type string `yaml:"type,omitempty"`
+}
+
+// addPresubmit adds a presubmit job of repoName, respecting any filtering.
+func (j *ProwJobs) addPresubmit(repoName string, job Presubmit) {
+	if jobNameFilter != "" && jobNameFilter != job.Name {
+		return
+	}
+	if j.Presubmits == nil {
+		j.Presubmits = make(map[string][]Presubmit)
+	}
+	j.Presubmits[repoName] = append(j.Presubmits[repoName], job)
+}
+
+// addPostsubmit adds a postsubmit job of repoName, respecting any filtering.
+func (j *ProwJobs) addPostsubmit(repoName string, job Postsubmit) {
+	if jobNameFilter != "" && jobNameFilter != job.Name {
+		return
+	}
+	if j.Postsubmits == nil {
+		j.Postsubmits = make(map[string][]Postsubmit)
+	}
+	j.Postsubmits[repoName] = append(j.Postsubmits[repoName], job)
+}
+
+// addPeriodic adds a periodic job, respecting any filtering.
+func (j *ProwJobs) addPeriodic(job Periodic) {
+	if jobNameFilter != "" && jobNameFilter != job.Name {
+		return
+	}
+	j.Periodics = append(j.Periodics, job)
+}
+
+// outputProwJobs outputs the given jobs as yaml. Maps are marshaled with
+// sorted keys, so the output is deterministic.
+func outputProwJobs(jobs ProwJobs) {
+	b, err := yaml.Marshal(jobs)
+	if err != nil {
+		logFatalf("Cannot marshal the Prow jobs: %v", err)
+		return
+	}
+	output.outputConfig(string(b))
+}
+
+// jobBase returns the fields common to all kinds of Prow jobs, for the given
+// job name and container.
+func (data baseProwJobTemplateData) jobBase(name string, container Container) JobBase {
+	container.Image = data.Image
+	container.ImagePullPolicy = "Always"
+	return JobBase{
+		Name:        name,
+		Labels:      data.Labels,
+		Agent:       "kubernetes",
+		Cluster:     data.Cluster,
+		Annotations: data.Annotations,
+		UtilityConfig: UtilityConfig{
+			Decorate: true,
+		},
+		Spec: &PodSpec{
+			Containers: []Container{container},
+			Volumes:    data.Volumes,
+		},
+	}
+}
+
+// container returns a container running the given command and arguments, with
+// the volume mounts, environment and resources of the job.
+func (data baseProwJobTemplateData) container(command, args []string) Container {
+	return Container{
+		Command:         command,
+		Args:            args,
+		SecurityContext: data.SecurityContext,
+		VolumeMounts:    data.VolumeMounts,
+		Env:             data.Env,
+		Resources:       data.Resources,
+	}
+}
+
+// presubmitTrigger returns the trigger of a presubmit job. If all is true, the
+// job is triggered by "/test all" too.
+func presubmitTrigger(jobName string, all bool) string {
+	if all {
+		return fmt.Sprintf(`(?m)^/test (all|%s),?(\s+|$)`, jobName)
+	}
+	return fmt.Sprintf(`(?m)^/test (%s),?(\s+|$)`, jobName)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v2"
+)
+
+// setupFlagDefaults sets the values changed through command-line flags to
+// their defaults.
+func setupFlagDefaults() {
+	GCSBucket, LogsDir, presubmitLogsDir = "knative-prow", "logs", "pr-logs"
+	testAccount = "/etc/test-account/service-account.json"
+	nightlyAccount = "/etc/nightly-account/service-account.json"
+	releaseAccount = "/etc/release-account/service-account.json"
+	prowTestsDockerImage = "gcr.io/knative-tests/test-infra/prow-tests:stable"
+	presubmitScript, releaseScript = "./test/presubmit-tests.sh", "./hack/release.sh"
+	repositoryOverride, jobNameFilter, preCommand = "", "", ""
+	extraEnvVars = nil
+	timeoutOverride = 0
+}
+
+// readYaml reads the yaml file at path as generic values, so files differing
+// only in formatting, key order or comments are equal.
+func readYaml(t *testing.T, path string) interface{} {
+	t.Helper()
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v interface{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		t.Fatalf("Cannot parse %s: %v", path, err)
+	}
+	return v
+}
+
+// TestGenerateProwJobs checks the jobs generated from testdata/config.yaml,
+// with the repos metadata of testdata/inventory.yaml, against
+// testdata/prow_jobs.yaml, which was generated by the templates used before
+// the jobs were typed.
+func TestGenerateProwJobs(t *testing.T) {
+	SetupForTesting()
+	logFatalf = t.Fatalf
+	setupFlagDefaults()
+
+	var config yaml.MapSlice
+	b, err := ioutil.ReadFile("testdata/config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal(b, &config); err != nil {
+		t.Fatal(err)
+	}
+	inv, err := readInventory("testdata/inventory.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loadInventory(inv, configRepos(config)); err != nil {
+		t.Fatal(err)
+	}
+	outputProwJobs(generateProwJobs(config))
+	first := GetOutput()
+
+	var got interface{}
+	if err := yaml.Unmarshal([]byte(first), &got); err != nil {
+		t.Fatalf("Cannot parse the generated jobs: %v\n%s", err, first)
+	}
+	if diff := cmp.Diff(readYaml(t, "testdata/prow_jobs.yaml"), got); diff != "" {
+		t.Errorf("generateProwJobs() diff(-want,+got):\n%s", diff)
+	}
+
+	// The config is modified by the generation, so parse it again.
+	config = nil
+	if err := yaml.Unmarshal(b, &config); err != nil {
+		t.Fatal(err)
+	}
+	ResetOutput()
+	outputProwJobs(generateProwJobs(config))
+	if !bytes.Equal([]byte(first), outputBuffer.Bytes()) {
+		t.Error("generateProwJobs() is not deterministic")
+	}
+}
+
+func TestPresubmitTrigger(t *testing.T) {
+	tests := map[string]struct {
+		all  bool
+		want string
+	}{
+		"with all": {all: true, want: `(?m)^/test (all|pull-job),?(\s+|$)`},
+		"alone":    {all: false, want: `(?m)^/test (pull-job),?(\s+|$)`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := presubmitTrigger("pull-job", tt.all); got != tt.want {
+				t.Errorf("presubmitTrigger() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddProwJobs(t *testing.T) {
+	SetupForTesting()
+	jobNameFilter = "pull-b"
+	defer func() { jobNameFilter = "" }()
+
+	var jobs ProwJobs
+	jobs.addPresubmit("org/repo", Presubmit{JobBase: JobBase{Name: "pull-a"}})
+	jobs.addPresubmit("org/repo", Presubmit{JobBase: JobBase{Name: "pull-b"}})
+	jobs.addPostsubmit("org/repo", Postsubmit{JobBase: JobBase{Name: "post-a"}})
+	jobs.addPeriodic(Periodic{JobBase: JobBase{Name: "ci-a"}})
+
+	want := ProwJobs{
+		Presubmits: map[string][]Presubmit{"org/repo": {{JobBase: JobBase{Name: "pull-b"}}}},
+	}
+	if diff := cmp.Diff(want, jobs); diff != "" {
+		t.Errorf("ProwJobs diff(-want,+got):\n%s", diff)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"knative.dev/test-infra/pkg/inventory"
+)
+
+// inventoryHeader is written at the top of the inventory file.
+const inventoryHeader = "# This file is generated by running config-generator with --refresh-inventory.\n\n"
+
+// repoDefaultBranch returns the default branch of the given repository, as
+// loaded by loadInventory, or master if it was not loaded.
+func repoDefaultBranch(repo string) string {
+	if r, ok := reposInventory[repo]; ok {
+		return r.DefaultBranch
+	}
+	return "master"
+}
+
+// repoPathAlias returns the knative.dev path alias the given repository is
+// cloned to if its org uses it, or "".
+func repoPathAlias(repo string) string {
+	org := strings.Split(repo, "/")[0]
+	if !pathAliasOrgs.Has(org) || nonPathAliasRepos.Has(repo) {
+		return ""
+	}
+	return "knative.dev/" + strings.TrimPrefix(repo, org+"/")
+}
+
+// configRepos returns the sorted names of the repositories in the given config.
+func configRepos(config yaml.MapSlice) []string {
+	repos := sets.NewString()
+	for _, section := range config {
+		for _, repo := range getMapSlice(section.Value) {
+			repos.Insert(getString(repo.Key))
+		}
+	}
+	return repos.List()
+}
+
+// listRepos lists the given repositories on GitHub, with one call per org.
+func listRepos(client inventory.Client, repos []string) ([]inventory.Repo, error) {
+	orgs := sets.NewString()
+	for _, repo := range repos {
+		parts := strings.Split(repo, "/")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("repository %q is expected to be \"org/repo\"", repo)
+		}
+		orgs.Insert(parts[0])
+	}
+	listed, err := inventory.List(client, orgs.List(), inventory.Options{})
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]inventory.Repo, len(listed))
+	for _, r := range listed {
+		byName[r.String()] = r
+	}
+	res := make([]inventory.Repo, 0, len(repos))
+	for _, repo := range repos {
+		r, ok := byName[repo]
+		if !ok {
+			return nil, fmt.Errorf("repository %q is not listed in its GitHub org", repo)
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// loadInventory loads the given repositories of the config from the given
+// inventory, or returns an error if one is not in it.
+func loadInventory(inv []inventory.Repo, repos []string) error {
+	byName := make(map[string]inventory.Repo, len(inv))
+	for _, r := range inv {
+		byName[r.String()] = r
+	}
+	loaded := make(map[string]inventory.Repo, len(repos))
+	for _, repo := range repos {
+		r, ok := byName[repo]
+		if !ok {
+			return fmt.Errorf("repository %q is not in the inventory, refresh it with --refresh-inventory", repo)
+		}
+		loaded[repo] = r
+	}
+	reposInventory = loaded
+	return nil
+}
+
+// readInventory reads the inventory from the given file.
+func readInventory(fileName string) ([]inventory.Repo, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read inventory %q: %w", fileName, err)
+	}
+	defer f.Close()
+	repos, err := inventory.Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse inventory %q: %w", fileName, err)
+	}
+	return repos, nil
+}
+
+// writeInventory writes the given repositories to the given file.
+func writeInventory(fileName string, repos []inventory.Repo) error {
+	b := bytes.NewBufferString(inventoryHeader)
+	if err := inventory.Write(b, repos); err != nil {
+		return fmt.Errorf("failed marshal the inventory: %w", err)
+	}
+	if err := ioutil.WriteFile(fileName, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("cannot write inventory %q: %w", fileName, err)
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+	"gopkg.in/yaml.v2"
+
+	"knative.dev/test-infra/pkg/ghutil"
+	"knative.dev/test-infra/pkg/inventory"
+)
+
+// fakeReposClient lists repos with the given default branches, keyed by
+// "org/repo", and counts the list calls per org.
+type fakeReposClient struct {
+	defaultBranches map[string]string
+	lists           map[string]int
+}
+
+func newFakeReposClient(defaultBranches map[string]string) *fakeReposClient {
+	return &fakeReposClient{defaultBranches: defaultBranches, lists: make(map[string]int)}
+}
+
+func (c *fakeReposClient) ListRepositories(org string) ([]*github.Repository, error) {
+	c.lists[org]++
+	var repos []*github.Repository
+	for repo, branch := range c.defaultBranches {
+		if strings.HasPrefix(repo, org+"/") {
+			repos = append(repos, &github.Repository{
+				Name:          github.String(strings.TrimPrefix(repo, org+"/")),
+				DefaultBranch: github.String(branch),
+			})
+		}
+	}
+	if len(repos) == 0 {
+		return nil, fmt.Errorf("unknown org %s", org)
+	}
+	return repos, nil
+}
+
+func (c *fakeReposClient) GetFileContent(_, _, _, _ string) ([]byte, error) {
+	return nil, ghutil.ErrNotFound
+}
+
+func TestListRepos(t *testing.T) {
+	client := newFakeReposClient(map[string]string{
+		"knative/serving":           "main",
+		"knative/eventing":          "master",
+		"knative/pkg":               "main",
+		"knative-sandbox/net-istio": "master",
+	})
+	tests := map[string]struct {
+		repos     []string
+		want      []inventory.Repo
+		wantLists map[string]int
+		wantErr   bool
+	}{
+		"one list per org": {
+			repos: []string{"knative-sandbox/net-istio", "knative/eventing", "knative/serving"},
+			want: []inventory.Repo{
+				{Org: "knative-sandbox", Name: "net-istio", DefaultBranch: "master"},
+				{Org: "knative", Name: "eventing", DefaultBranch: "master"},
+				{Org: "knative", Name: "serving", DefaultBranch: "main"},
+			},
+			wantLists: map[string]int{"knative": 1, "knative-sandbox": 1},
+		},
+		"missing repo": {
+			repos:     []string{"knative/missing"},
+			wantLists: map[string]int{"knative": 1},
+			wantErr:   true,
+		},
+		"unknown org": {
+			repos:     []string{"google/knative-gcp"},
+			wantLists: map[string]int{"google": 1},
+			wantErr:   true,
+		},
+		"invalid repo": {
+			repos:     []string{"knative"},
+			wantLists: map[string]int{},
+			wantErr:   true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			client.lists = make(map[string]int)
+			got, err := listRepos(client, tt.repos)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("listRepos() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("listRepos() diff(-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantLists, client.lists); diff != "" {
+				t.Errorf("listRepos() lists per org diff(-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadInventory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-generator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	tests := map[string]struct {
+		path    string
+		repos   []string
+		want    map[string]inventory.Repo
+		wantErr bool
+	}{
+		"config repos": {
+			path:  "testdata/inventory.yaml",
+			repos: []string{"google/knative-gcp", "knative/serving"},
+			want: map[string]inventory.Repo{
+				"google/knative-gcp": {Org: "google", Name: "knative-gcp", DefaultBranch: "main"},
+				"knative/serving":    {Org: "knative", Name: "serving", DefaultBranch: "master"},
+			},
+		},
+		"repo not in the inventory": {
+			path:    "testdata/inventory.yaml",
+			repos:   []string{"knative/missing"},
+			wantErr: true,
+		},
+		"missing file": {
+			path:    "testdata/missing.yaml",
+			wantErr: true,
+		},
+		"unknown field": {
+			path:    write("unknown.yaml", "- org: knative\n  name: serving\n  defaultBranch: main\n  branch: main\n"),
+			wantErr: true,
+		},
+		"no default branch": {
+			path:    write("nobranch.yaml", "- org: knative\n  name: serving\n"),
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			SetupForTesting()
+			inv, err := readInventory(tt.path)
+			if err == nil {
+				err = loadInventory(inv, tt.repos)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readInventory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.want, reposInventory); diff != "" {
+				t.Errorf("loadInventory() diff(-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestWriteInventory checks that refreshing the inventory of testdata/config.yaml
+// from GitHub writes testdata/inventory.yaml.
+func TestWriteInventory(t *testing.T) {
+	SetupForTesting()
+	logFatalf = t.Fatalf
+	var config yaml.MapSlice
+	b, err := ioutil.ReadFile("testdata/config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal(b, &config); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "config-generator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	client := newFakeReposClient(map[string]string{
+		"google/knative-gcp":          "main",
+		"knative-sandbox/net-kourier": "master",
+		"knative/docs":                "master",
+		"knative/eventing":            "master",
+		"knative/operator":            "master",
+		"knative/serving":             "master",
+		"knative/pkg":                 "main",
+	})
+	inv, err := listRepos(client, configRepos(config))
+	if err != nil {
+		t.Fatalf("listRepos() error = %v", err)
+	}
+	fileName := filepath.Join(dir, "inventory.yaml")
+	if err := writeInventory(fileName, inv); err != nil {
+		t.Fatalf("writeInventory() error = %v", err)
+	}
+	got, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile("testdata/inventory.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		t.Errorf("writeInventory() diff(-want,+got):\n%s", diff)
+	}
+}
+
+func TestRepoBranch(t *testing.T) {
+	SetupForTesting()
+	setupFlagDefaults()
+	inv := []inventory.Repo{
+		{Org: "knative", Name: "serving", DefaultBranch: "main"},
+		{Org: "knative", Name: "eventing", DefaultBranch: "master"},
+		{Org: "google", Name: "knative-gcp", DefaultBranch: "main"},
+	}
+	if err := loadInventory(inv, []string{"knative/serving", "knative/eventing", "google/knative-gcp"}); err != nil {
+		t.Fatalf("loadInventory() error = %v", err)
+	}
+
+	tests := map[string]struct {
+		repo       string
+		wantBranch string
+		wantAlias  string
+	}{
+		"main branch": {
+			repo:       "knative/serving",
+			wantBranch: "main",
+			wantAlias:  "knative.dev/serving",
+		},
+		"master branch": {
+			repo:       "knative/eventing",
+			wantBranch: "master",
+			wantAlias:  "knative.dev/eventing",
+		},
+		"main branch without path alias": {
+			repo:       "google/knative-gcp",
+			wantBranch: "main",
+		},
+		"not loaded": {
+			repo:       "knative/pkg",
+			wantBranch: "master",
+			wantAlias:  "knative.dev/pkg",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := newbaseProwJobTemplateData(tt.repo)
+			if data.RepoBranch != tt.wantBranch {
+				t.Errorf("RepoBranch = %q, want %q", data.RepoBranch, tt.wantBranch)
+			}
+			if data.PathAlias != tt.wantAlias || data.ExtraRefs[0].PathAlias != tt.wantAlias {
+				t.Errorf("PathAlias = %q, ExtraRefs[0].PathAlias = %q, want %q", data.PathAlias, data.ExtraRefs[0].PathAlias, tt.wantAlias)
+			}
+			if got, want := gitHubRepo(data), "github.com/"+tt.repo+"="+tt.wantBranch; got != want {
+				t.Errorf("gitHubRepo() = %q, want %q", got, want)
+			}
+		})
+	}
+
+	// A failed load keeps the metadata loaded before.
+	if err := loadInventory(inv, []string{"knative/missing"}); err == nil {
+		t.Error("loadInventory() of a missing repository succeeded")
+	}
+	if got := newbaseProwJobTemplateData("knative/serving").RepoBranch; got != "main" {
+		t.Errorf("RepoBranch after a failed load = %q, want %q", got, "main")
+	}
+}
@@ -40,6 +40,10 @@ const (
 
 	// dashboardGroupTemplate is the template for the dashboard tab config
 	dashboardGroupTemplate = "testgrid_dashboardgroup.yaml"
+
+	// Annotations of Prow jobs selecting their testgrid dashboard and tab.
+	testgridDashboardsAnnotation = "testgrid-dashboards"
+	testgridTabNameAnnotation    = "testgrid-tab-name"
 )
 
 var (
@@ -196,41 +200,33 @@ func getTestgroupExtras(projName, jobName string) map[string]string {
 	return extras
 }
 
-func generateProwJobAnnotations(dashboardName, tabName string, tgExtras map[string]string) []string {
-	annotations := []string{fmtDashboardAnnotation(dashboardName), fmtTabAnnotation(tabName)}
+// generateProwJobAnnotations returns the testgrid annotations of a Prow job.
+func generateProwJobAnnotations(dashboardName, tabName string, tgExtras map[string]string) map[string]string {
+	annotations := map[string]string{
+		testgridDashboardsAnnotation: dashboardName,
+		testgridTabNameAnnotation:    tabName,
+	}
 
 	v, ok := tgExtras["alert_stale_results_hours"]
 	if ok {
-		res := fmt.Sprintf("  testgrid-alert-stale-results-hours: \"%s\"", v)
-		annotations = append(annotations, res)
+		annotations["testgrid-alert-stale-results-hours"] = v
 	}
 	v, ok = tgExtras["short_text_metric"]
 	if ok {
-		res := "  testgrid-in-cell-metric: " + v
-		annotations = append(annotations, res)
+		annotations["testgrid-in-cell-metric"] = v
 	}
 	v, ok = tgExtras["alert_options"]
 	if ok {
 		email := quotedEmailPattern.FindStringSubmatch(v)[1] //index 1 is first capture group
-		res := fmt.Sprintf("  testgrid-alert-email: \"%s\"", email)
-		annotations = append(annotations, res)
+		annotations["testgrid-alert-email"] = email
 	}
 	v, ok = tgExtras["num_failures_to_alert"]
 	if ok {
-		res := fmt.Sprintf("  testgrid-num-failures-to-alert: \"%s\"", v)
-		annotations = append(annotations, res)
+		annotations["testgrid-num-failures-to-alert"] = v
 	}
 	return annotations
 }
 
-func fmtDashboardAnnotation(dashboardName string) string {
-	return fmt.Sprintf("  testgrid-dashboards: " + dashboardName)
-}
-
-func fmtTabAnnotation(tabName string) string {
-	return fmt.Sprintf("  testgrid-tab-name: " + tabName)
-}
-
 // generateTestGroup generates the test group configuration
 func generateTestGroup(projName string, repoName string, jobNames []string) {
 	projRepoStr := buildProjRepoStr(projName, repoName)
@@ -217,13 +217,13 @@ func TestGenerateProwJobAnnotations(t *testing.T) {
 		"num_failures_to_alert":     "3",
 		"short_text_metric":         "coverage",
 	}
-	expected := []string{
-		"  testgrid-dashboards: repo-name",
-		"  testgrid-tab-name: job-name",
-		"  testgrid-alert-stale-results-hours: \"48\"",
-		"  testgrid-in-cell-metric: coverage",
-		"  testgrid-alert-email: \"foo-bar@google.com\"",
-		"  testgrid-num-failures-to-alert: \"3\"",
+	expected := map[string]string{
+		"testgrid-dashboards":                "repo-name",
+		"testgrid-tab-name":                  "job-name",
+		"testgrid-alert-stale-results-hours": "48",
+		"testgrid-in-cell-metric":            "coverage",
+		"testgrid-alert-email":               "foo-bar@google.com",
+		"testgrid-num-failures-to-alert":     "3",
 	}
 	annotations := generateProwJobAnnotations("repo-name", "job-name", tgExtras)
 	if diff := cmp.Diff(annotations, expected); diff != "" {
@@ -231,18 +231,6 @@ func TestGenerateProwJobAnnotations(t *testing.T) {
 	}
 }
 
-func TestFmtDashboardAnnotation(t *testing.T) {
-	if diff := cmp.Diff(fmtDashboardAnnotation("dashboardName"), "  testgrid-dashboards: dashboardName"); diff != "" {
-		t.Errorf("(-got +want): \n%s", diff)
-	}
-}
-
-func TestFmtTabAnnotation(t *testing.T) {
-	if diff := cmp.Diff(fmtTabAnnotation("tabName"), "  testgrid-tab-name: tabName"); diff != "" {
-		t.Errorf("(-got +want): \n%s", diff)
-	}
-}
-
 func TestTestGridMetaDataGenerateTestGroup(t *testing.T) {
 	SetupForTesting()
 	projName := "proj-name"
@@ -15,6 +15,8 @@ package main
 
 import (
 	"bytes"
+
+	"knative.dev/test-infra/pkg/inventory"
 )
 
 var outputBuffer bytes.Buffer
@@ -39,5 +41,20 @@ func SetupForTesting() {
 	ResetOutput() // Redirect output prior to each test.
 	logFatalf = logFatalfMock
 	logFatalCalls = 0
-	sectionMap = make(map[string]bool)
+	prowJobs = ProwJobs{}
+	metaData = NewTestGridMetaData()
+	reposInventory = make(map[string]inventory.Repo)
+}
+
+// GetJobCount returns the number of Prow jobs generated since the last
+// SetupForTesting call.
+func GetJobCount() int {
+	n := len(prowJobs.Periodics)
+	for _, jobs := range prowJobs.Presubmits {
+		n += len(jobs)
+	}
+	for _, jobs := range prowJobs.Postsubmits {
+		n += len(jobs)
+	}
+	return n
 }
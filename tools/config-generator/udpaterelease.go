@@ -34,7 +34,6 @@ const (
 )
 
 func upgradeReleaseBranchesTemplate(configfileName string, gc ghutil.GithubOperations) error {
-	config := yaml.MapSlice{}
 	info, err := os.Lstat(configfileName)
 	if err != nil {
 		return fmt.Errorf("failed stats file %q: %w", configfileName, err)
@@ -43,14 +42,26 @@ func upgradeReleaseBranchesTemplate(configfileName string, gc ghutil.GithubOpera
 	if err != nil {
 		return fmt.Errorf("cannot read file %q: %w", configfileName, err)
 	}
-	if err = yaml.Unmarshal(content, &config); err != nil {
-		return fmt.Errorf("cannot parse config %q: %w", configfileName, err)
+	updated, err := upgradeReleaseBranchesConfig(configfileName, content, gc)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(configfileName, updated, info.Mode())
+}
+
+// upgradeReleaseBranchesConfig returns the content of the config file with
+// the jobs of release branches upgraded, without writing it.
+func upgradeReleaseBranchesConfig(configfileName string, content []byte, gc ghutil.GithubOperations) ([]byte, error) {
+	config := yaml.MapSlice{}
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("cannot parse config %q: %w", configfileName, err)
 	}
+	var err error
 	for i, repos := range config {
 		if repos.Key != "presubmits" {
 			config[i].Value, err = getReposMap(gc, repos.Value)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 	}
@@ -58,9 +69,9 @@ func upgradeReleaseBranchesTemplate(configfileName string, gc ghutil.GithubOpera
 	updated, err := yaml.Marshal(&config)
 	// This shouldn't happen, just catch it in case
 	if err != nil {
-		return fmt.Errorf("failed marshal modified content: %w", err)
+		return nil, fmt.Errorf("failed marshal modified content: %w", err)
 	}
-	return ioutil.WriteFile(configfileName, updated, info.Mode())
+	return updated, nil
 }
 
 func getReposMap(gc ghutil.GithubOperations, val interface{}) (interface{}, error) {
@@ -116,6 +116,20 @@ func quote(s string) string {
 	return "\"" + s + "\""
 }
 
+// unquote returns the value of the given string as a yaml scalar if it's
+// quoted, ex: "\"true\"" is "true". Such values were written to the configs
+// as is before Prow jobs were marshaled.
+func unquote(s string) string {
+	if len(s) < 2 || (s[0] != '"' && s[0] != '\'') || s[len(s)-1] != s[0] {
+		return s
+	}
+	var value string
+	if err := yaml.Unmarshal([]byte(s), &value); err != nil {
+		return s
+	}
+	return value
+}
+
 // indentBase is a helper function which returns the given array indented.
 func indentBase(indentation int, prefix string, indentFirstLine bool, array []string) string {
 	s := ""
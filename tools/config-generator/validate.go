@@ -0,0 +1,505 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// valueKind is the type expected for the value of a config entry.
+type valueKind int
+
+const (
+	anyValue valueKind = iota
+	boolValue
+	intValue
+	stringValue
+	stringArrayValue
+	mapValue
+)
+
+var (
+	// basicJobEntries are the entries handled by parseBasicJobConfigOverrides.
+	basicJobEntries = map[string]valueKind{
+		"skip_branches":   stringArrayValue,
+		"branches":        stringArrayValue,
+		"args":            stringArrayValue,
+		"timeout":         intValue,
+		"command":         stringValue,
+		"needs-monitor":   boolValue,
+		"needs-dind":      boolValue,
+		"always-run":      boolValue,
+		"performance":     boolValue,
+		"env-vars":        stringArrayValue,
+		"optional":        boolValue,
+		"resources":       mapValue,
+		"reporter_config": mapValue,
+	}
+
+	// presubmitEntries are the entries handled by generatePresubmit.
+	presubmitEntries = map[string]valueKind{
+		"build-tests":           boolValue,
+		"unit-tests":            boolValue,
+		"integration-tests":     boolValue,
+		"go-coverage":           boolValue,
+		"custom-test":           stringValue,
+		"go-coverage-threshold": intValue,
+		"repo-settings":         anyValue,
+		"run-if-changed":        stringValue,
+	}
+
+	// periodicEntries are the entries handled by generatePeriodic.
+	periodicEntries = map[string]valueKind{
+		"continuous":   boolValue,
+		"nightly":      boolValue,
+		"branch-ci":    boolValue,
+		"dot-release":  boolValue,
+		"auto-release": boolValue,
+		"custom-job":   stringValue,
+		"cron":         stringValue,
+		"release":      stringValue,
+	}
+
+	// slackReporterEntries are the entries of the slack reporter config.
+	slackReporterEntries = map[string]valueKind{
+		"host":                 stringValue,
+		"channel":              stringValue,
+		"job_states_to_report": stringArrayValue,
+		"report_template":      stringValue,
+	}
+
+	yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+)
+
+// configError is a problem found in a config file, at the given position.
+type configError struct {
+	File         string
+	Line, Column int
+	Message      string
+}
+
+func (e configError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+// configValidator collects the problems found in the config files.
+type configValidator struct {
+	file string
+	errs []configError
+	// Where the jobs were first defined, as "file:line".
+	jobs map[string]string
+	// knownRepos are the repositories with presubmits or periodics on their
+	// default branch, in any file.
+	knownRepos map[string]bool
+	// releaseRepos are the repositories with periodics on release branches,
+	// where they were first found. They are checked once all the files are
+	// read.
+	releaseRepos []releaseRepo
+}
+
+// releaseRepo is a repository with periodics on release branches.
+type releaseRepo struct {
+	repo string
+	file string
+	node *yamlNode
+}
+
+func (v *configValidator) errorf(n *yamlNode, format string, args ...interface{}) {
+	v.errs = append(v.errs, configError{File: v.file, Line: n.line, Column: n.column, Message: fmt.Sprintf(format, args...)})
+}
+
+// validateConfig checks the given config files and returns every problem
+// found, ordered by file and position. Nothing is generated.
+func validateConfig(files []configFile) []configError {
+	// Jobs are generated to find duplicate names and the problems only
+	// detected while generating them, so keep the generator state intact.
+	savedLogFatalf, savedRepositories, savedProwJobs, savedMetaData := logFatalf, repositories, prowJobs, metaData
+	defer func() {
+		logFatalf, repositories, prowJobs, metaData = savedLogFatalf, savedRepositories, savedProwJobs, savedMetaData
+	}()
+	repositories = make([]repositoryData, 0)
+	prowJobs = ProwJobs{}
+
+	v := &configValidator{jobs: make(map[string]string), knownRepos: make(map[string]bool)}
+	for _, f := range files {
+		v.file = f.Name
+		v.validateFile(f.Content)
+	}
+	v.validateReleaseJobs(files)
+	return v.errs
+}
+
+// validateReleaseJobs checks that the repositories with periodics on release
+// branches have a config of their own: presubmits, or periodics on their
+// default branch. Otherwise the repository is most likely misspelled. The
+// problems found are added in the order of the files and positions.
+func (v *configValidator) validateReleaseJobs(files []configFile) {
+	start := len(v.errs)
+	for _, r := range v.releaseRepos {
+		if !v.knownRepos[r.repo] {
+			v.file = r.file
+			v.errorf(r.node, "unknown repository %q: it only has periodics on release branches, and no presubmits or periodics on its default branch", r.repo)
+		}
+	}
+	if len(v.errs) == start {
+		return
+	}
+	order := make(map[string]int, len(files))
+	for i, f := range files {
+		order[f.Name] = i
+	}
+	sort.SliceStable(v.errs, func(i, j int) bool {
+		a, b := v.errs[i], v.errs[j]
+		if a.File != b.File {
+			return order[a.File] < order[b.File]
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// validateFile checks the sections of a config file, adding the problems
+// found ordered by position.
+func (v *configValidator) validateFile(content []byte) {
+	root := indexYamlPositions(content)
+	var config yaml.MapSlice
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		line := 1
+		if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+		}
+		v.errorf(&yamlNode{line: line, column: 1}, "%v", err)
+		return
+	}
+	start := len(v.errs)
+	for _, section := range config {
+		n := root.child(section.Key)
+		switch section.Key {
+		case "presubmits":
+			v.validateSection(n, "presubmits", section.Value, presubmitEntries, generatePresubmit)
+		case "periodics":
+			v.validateSection(n, "periodics", section.Value, periodicEntries, generatePeriodic)
+		default:
+			v.errorf(n, "unknown section %q", section.Key)
+		}
+	}
+	errs := v.errs[start:]
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+		return errs[i].Column < errs[j].Column
+	})
+}
+
+// validateSection checks the jobs of all repositories of a section.
+func (v *configValidator) validateSection(n *yamlNode, title string, value interface{}, entries map[string]valueKind, generate sectionGenerator) {
+	repos, ok := value.(yaml.MapSlice)
+	if !ok {
+		v.errorf(n, "%s is expected to be a map of repositories", title)
+		return
+	}
+	for _, repo := range repos {
+		rn := n.child(repo.Key)
+		repoName, ok := repo.Key.(string)
+		if !ok || len(strings.Split(repoName, "/")) != 2 {
+			v.errorf(rn, "repository %v is expected to be \"org/repo\"", repo.Key)
+			continue
+		}
+		jobs, ok := repo.Value.([]interface{})
+		if !ok {
+			v.errorf(rn, "%s of %q are expected to be a list of jobs", title, repoName)
+			continue
+		}
+		for i, job := range jobs {
+			jn := rn.child(i)
+			jobConfig, ok := job.(yaml.MapSlice)
+			if !ok {
+				v.errorf(jn, "job is expected to be a map")
+				continue
+			}
+			v.recordRepo(rn, title, repoName, jobConfig)
+			if v.validateJob(jn, jobConfig, entries) {
+				v.generateJob(jn, title, repoName, jobConfig, generate)
+			}
+		}
+	}
+}
+
+// recordRepo records whether the job is on a release branch of the
+// repository, for validateReleaseJobs.
+func (v *configValidator) recordRepo(n *yamlNode, title, repoName string, config yaml.MapSlice) {
+	if title == "presubmits" || !hasKey(config, "release") {
+		v.knownRepos[repoName] = true
+		return
+	}
+	for _, r := range v.releaseRepos {
+		if r.repo == repoName {
+			return
+		}
+	}
+	v.releaseRepos = append(v.releaseRepos, releaseRepo{repo: repoName, file: v.file, node: n})
+}
+
+// validateJob checks the entries of a job, returning whether they are valid.
+func (v *configValidator) validateJob(n *yamlNode, config yaml.MapSlice, entries map[string]valueKind) bool {
+	errs := len(v.errs)
+	for _, item := range config {
+		en := n.child(item.Key)
+		key := fmt.Sprint(item.Key)
+		kind, ok := entries[key]
+		if !ok {
+			kind, ok = basicJobEntries[key]
+		}
+		if !ok {
+			v.errorf(en, "unknown entry %q for job", key)
+			continue
+		}
+		if !v.checkKind(en, key, item.Value, kind) {
+			continue
+		}
+		switch key {
+		case "cron":
+			if err := validateCron(getString(item.Value)); err != nil {
+				v.errorf(en, "invalid cron %q: %v", getString(item.Value), err)
+			}
+		case "run-if-changed":
+			v.checkRegexp(en, key, getString(item.Value))
+		case "branches", "skip_branches":
+			for i, branch := range getStringArray(item.Value) {
+				v.checkRegexp(en.child(i), key, branch)
+			}
+		case "env-vars":
+			for i, env := range getStringArray(item.Value) {
+				if !strings.Contains(env, "=") {
+					v.errorf(en.child(i), "environment variable %q is expected to be \"key=value\"", env)
+				}
+			}
+		case "resources":
+			for _, res := range getMapSlice(item.Value) {
+				rn := en.child(res.Key)
+				switch res.Key {
+				case "requests", "limits":
+					if v.checkKind(rn, fmt.Sprint(res.Key), res.Value, mapValue) {
+						for _, r := range getMapSlice(res.Value) {
+							v.checkKind(rn.child(r.Key), fmt.Sprint(r.Key), r.Value, stringValue)
+						}
+					}
+				default:
+					v.errorf(rn, "unknown entry %q for resources", res.Key)
+				}
+			}
+		case "reporter_config":
+			for _, reporter := range getMapSlice(item.Value) {
+				rn := en.child(reporter.Key)
+				if reporter.Key != "slack" {
+					v.errorf(rn, "unknown reporter %q", reporter.Key)
+					continue
+				}
+				if !v.checkKind(rn, "slack", reporter.Value, mapValue) {
+					continue
+				}
+				for _, r := range getMapSlice(reporter.Value) {
+					kind, ok := slackReporterEntries[fmt.Sprint(r.Key)]
+					if !ok {
+						v.errorf(rn.child(r.Key), "unknown entry %q for slack reporter", r.Key)
+						continue
+					}
+					v.checkKind(rn.child(r.Key), fmt.Sprint(r.Key), r.Value, kind)
+				}
+			}
+		}
+	}
+	return len(v.errs) == errs
+}
+
+// checkKind checks that the value of the given entry has the expected type.
+// Like getString, a string array of size 1 is also a valid string.
+func (v *configValidator) checkKind(n *yamlNode, key string, value interface{}, kind valueKind) bool {
+	var ok bool
+	var expected string
+	switch kind {
+	case anyValue:
+		return true
+	case boolValue:
+		_, ok = value.(bool)
+		expected = "a boolean"
+	case intValue:
+		_, ok = value.(int)
+		expected = "an integer"
+	case stringValue:
+		ok = isString(value)
+		if a, isArray := value.([]interface{}); isArray && len(a) == 1 {
+			ok = isString(a[0])
+		}
+		expected = "a string"
+	case stringArrayValue:
+		a, isArray := value.([]interface{})
+		ok = isArray
+		for _, s := range a {
+			ok = ok && isString(s)
+		}
+		expected = "a list of strings"
+	case mapValue:
+		_, ok = value.(yaml.MapSlice)
+		expected = "a map"
+	}
+	if !ok {
+		v.errorf(n, "%q is expected to be %s, got %v", key, expected, value)
+	}
+	return ok
+}
+
+// checkRegexp checks that the value of the given entry is a valid regular expression.
+func (v *configValidator) checkRegexp(n *yamlNode, key, value string) {
+	if _, err := regexp.Compile(value); err != nil {
+		v.errorf(n, "%q is not a valid regular expression: %v", key, err)
+	}
+}
+
+// hasKey returns whether the job config has the given entry.
+func hasKey(config yaml.MapSlice, key string) bool {
+	for _, item := range config {
+		if item.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func isString(value interface{}) bool {
+	_, ok := value.(string)
+	return ok
+}
+
+// generateJob generates the given job, reporting the problems found while
+// generating it and the jobs whose name was already used.
+func (v *configValidator) generateJob(n *yamlNode, title, repoName string, config yaml.MapSlice, generate sectionGenerator) {
+	logFatalf = func(format string, args ...interface{}) {
+		v.errorf(n, format, args...)
+	}
+	presubmits, periodics := len(prowJobs.Presubmits[repoName]), len(prowJobs.Periodics)
+	generate(title, repoName, config)
+
+	var names []string
+	for _, job := range prowJobs.Presubmits[repoName][presubmits:] {
+		// Presubmit names only need to be unique within their repository.
+		names = append(names, repoName+"/"+job.Name)
+	}
+	for _, job := range prowJobs.Periodics[periodics:] {
+		names = append(names, job.Name)
+	}
+	for _, name := range names {
+		if first, ok := v.jobs[name]; ok {
+			// Jobs generated along with the duplicate would only repeat the error.
+			v.errorf(n, "duplicate job name %q, first defined at %s", path.Base(name), first)
+			return
+		}
+		v.jobs[name] = fmt.Sprintf("%s:%d", v.file, n.line)
+	}
+}
+
+// validateCron checks that the given string is a cron schedule understood by Prow:
+// five fields, or one of the predefined "@" schedules.
+func validateCron(s string) error {
+	if strings.HasPrefix(s, "@every ") {
+		_, err := time.ParseDuration(strings.TrimPrefix(s, "@every "))
+		return err
+	}
+	switch s {
+	case "@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly":
+		return nil
+	}
+	fields := strings.Fields(s)
+	if len(fields) != len(cronFields) {
+		return fmt.Errorf("expected %d fields, got %d", len(cronFields), len(fields))
+	}
+	for i, field := range fields {
+		if err := cronFields[i].validate(field); err != nil {
+			return fmt.Errorf("%s: %v", cronFields[i].name, err)
+		}
+	}
+	return nil
+}
+
+// cronField is a field of a cron schedule.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // Names of the values, starting from min.
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 6, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// validate checks a comma separated list of values, ranges and steps.
+func (f cronField) validate(field string) error {
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, ""
+		if i := strings.Index(part, "/"); i >= 0 {
+			rng, step = part[:i], part[i+1:]
+			if n, err := strconv.Atoi(step); err != nil || n <= 0 {
+				return fmt.Errorf("invalid step %q", step)
+			}
+		}
+		if rng == "*" || rng == "?" {
+			continue
+		}
+		bounds := strings.SplitN(rng, "-", 2)
+		for _, b := range bounds {
+			if _, err := f.value(b); err != nil {
+				return err
+			}
+		}
+		if len(bounds) == 2 {
+			lo, _ := f.value(bounds[0])
+			hi, _ := f.value(bounds[1])
+			if lo > hi {
+				return fmt.Errorf("invalid range %q", rng)
+			}
+		}
+	}
+	return nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("value %q is not between %d and %d", s, f.min, f.max)
+	}
+	return n, nil
+}
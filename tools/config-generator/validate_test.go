@@ -0,0 +1,194 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidateConfig(t *testing.T) {
+	SetupForTesting()
+	setupFlagDefaults()
+
+	tests := map[string]struct {
+		file string
+		want []configError
+	}{
+		"valid": {
+			file: "testdata/config.yaml",
+		},
+		"valid directory": {
+			file: "testdata/jobs",
+		},
+		"invalid": {
+			file: "testdata/invalid_config.yaml",
+			want: []configError{
+				{"testdata/invalid_config.yaml", 5, 5, `unknown entry "dind" for job`},
+				{"testdata/invalid_config.yaml", 6, 5, `"integration-tests" is expected to be a boolean, got yes`},
+				{"testdata/invalid_config.yaml", 8, 5, "\"run-if-changed\" is not a valid regular expression: error parsing regexp: missing closing ): `^(pkg/(.*\\.go$`"},
+				{"testdata/invalid_config.yaml", 10, 5, "\"branches\" is not a valid regular expression: error parsing regexp: missing closing ]: `[0-9`"},
+				{"testdata/invalid_config.yaml", 15, 9, `"cpu" is expected to be a string, got 8`},
+				{"testdata/invalid_config.yaml", 16, 7, `unknown entry "limitz" for resources`},
+				{"testdata/invalid_config.yaml", 19, 5, `environment variable "NO_VALUE" is expected to be "key=value"`},
+				{"testdata/invalid_config.yaml", 20, 3, `duplicate job name "pull-knative-serving-build-tests", first defined at testdata/invalid_config.yaml:3`},
+				{"testdata/invalid_config.yaml", 26, 5, `invalid cron "0 */4 * * 8": day of week: value "8" is not between 0 and 6`},
+				{"testdata/invalid_config.yaml", 28, 5, `"timeout" is expected to be an integer, got 1h`},
+				{"testdata/invalid_config.yaml", 32, 9, `unknown entry "states" for slack reporter`},
+				{"testdata/invalid_config.yaml", 33, 3, `Job "ci-knative-serving-upgrade" is missing command`},
+				{"testdata/invalid_config.yaml", 34, 3, `duplicate job name "ci-knative-serving-continuous", first defined at testdata/invalid_config.yaml:24`},
+				{"testdata/invalid_config.yaml", 35, 3, `repository serving is expected to be "org/repo"`},
+				{"testdata/invalid_config.yaml", 37, 3, `unknown repository "knative/servng": it only has periodics on release branches, and no presubmits or periodics on its default branch`},
+				{"testdata/invalid_config.yaml", 41, 1, `unknown section "postsubmits"`},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			files, err := readConfigFiles(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := validateConfig(files)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("validateConfig() diff(-want,+got):\n%s", diff)
+			}
+		})
+	}
+	if logFatalCalls != 0 || GetJobCount() != 0 {
+		t.Error("validateConfig() changed the generator state")
+	}
+}
+
+func TestValidateConfigSyntaxError(t *testing.T) {
+	SetupForTesting()
+	got := validateConfig([]configFile{{Name: "config.yaml", Content: []byte("presubmits:\n  knative/serving:\n  - build-tests: true\n    args: [\n")}})
+	if len(got) != 1 || got[0].Line != 4 {
+		t.Errorf("validateConfig() = %v, want a single error at line 4", got)
+	}
+}
+
+func TestValidateConfigAcrossFiles(t *testing.T) {
+	SetupForTesting()
+	setupFlagDefaults()
+	files := []configFile{
+		{Name: "knative/serving.yaml", Content: []byte("presubmits:\n  knative/serving:\n  - build-tests: true\n")},
+		{Name: "knative/serving-extra.yaml", Content: []byte("# More jobs.\npresubmits:\n  knative/serving:\n  - unit-tests: true\n  - build-tests: true\n")},
+	}
+	want := []configError{
+		{"knative/serving-extra.yaml", 5, 3, `duplicate job name "pull-knative-serving-build-tests", first defined at knative/serving.yaml:3`},
+	}
+	if diff := cmp.Diff(want, validateConfig(files)); diff != "" {
+		t.Errorf("validateConfig() diff(-want,+got):\n%s", diff)
+	}
+}
+
+func TestValidateConfigReleaseRepos(t *testing.T) {
+	SetupForTesting()
+	setupFlagDefaults()
+	files := []configFile{
+		// The release jobs of serving come before its presubmits.
+		{Name: "knative/release.yaml", Content: []byte("periodics:\n  knative/serving:\n  - branch-ci: true\n    release: \"0.19\"\n  knative/servng:\n  - dot-release: true\n    release: \"0.19\"\n")},
+		{Name: "knative/serving.yaml", Content: []byte("presubmits:\n  knative/serving:\n  - build-tests: true\n  - build-tests: true\n")},
+		// Periodics on the default branch are enough.
+		{Name: "knative/eventing.yaml", Content: []byte("periodics:\n  knative/eventing:\n  - branch-ci: true\n    release: \"0.19\"\n  - continuous: true\n")},
+	}
+	want := []configError{
+		{"knative/release.yaml", 5, 3, `unknown repository "knative/servng": it only has periodics on release branches, and no presubmits or periodics on its default branch`},
+		{"knative/serving.yaml", 4, 3, `duplicate job name "pull-knative-serving-build-tests", first defined at knative/serving.yaml:3`},
+	}
+	if diff := cmp.Diff(want, validateConfig(files)); diff != "" {
+		t.Errorf("validateConfig() diff(-want,+got):\n%s", diff)
+	}
+}
+
+func TestValidateCron(t *testing.T) {
+	tests := map[string]struct {
+		cron    string
+		wantErr bool
+	}{
+		"hourly":           {cron: "15 * * * *"},
+		"steps and ranges": {cron: "0 */4 1-15 * 1-5"},
+		"lists":            {cron: "4 8,11,22 * * *"},
+		"names":            {cron: "0 9 * JAN-JUN mon,wed"},
+		"descriptor":       {cron: "@daily"},
+		"every":            {cron: "@every 2h"},
+		"too few fields":   {cron: "0 9 * *", wantErr: true},
+		"out of range":     {cron: "60 * * * *", wantErr: true},
+		"bad step":         {cron: "*/0 * * * *", wantErr: true},
+		"reversed range":   {cron: "0 9-1 * * *", wantErr: true},
+		"bad name":         {cron: "0 9 * * funday", wantErr: true},
+		"bad every":        {cron: "@every often", wantErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := validateCron(tt.cron); (err != nil) != tt.wantErr {
+				t.Errorf("validateCron(%q) error = %v, wantErr %v", tt.cron, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIndexYamlPositions(t *testing.T) {
+	content := `# comment
+presubmits:
+  "knative/serving":
+  - build-tests: true
+    args:
+    - --foo
+    - --bar
+    reporter_config:
+      slack:
+        report_template: |
+          not: a key
+  - unit-tests: true
+periodics:
+  knative/eventing:
+    - continuous: true
+      env-vars: ["A=B"]
+`
+	root := indexYamlPositions([]byte(content))
+	tests := map[string]struct {
+		path []interface{}
+		line int
+		col  int
+	}{
+		"top level key":            {path: []interface{}{"periodics"}, line: 13, col: 1},
+		"quoted key":               {path: []interface{}{"presubmits", "knative/serving"}, line: 3, col: 3},
+		"item at key indentation":  {path: []interface{}{"presubmits", "knative/serving", 1}, line: 12, col: 3},
+		"key in item":              {path: []interface{}{"presubmits", "knative/serving", 0, "build-tests"}, line: 4, col: 5},
+		"scalar item":              {path: []interface{}{"presubmits", "knative/serving", 0, "args", 1}, line: 7, col: 5},
+		"nested map":               {path: []interface{}{"presubmits", "knative/serving", 0, "reporter_config", "slack", "report_template"}, line: 10, col: 9},
+		"block scalar is skipped":  {path: []interface{}{"presubmits", "knative/serving", 0, "reporter_config", "slack", "report_template", "not"}, line: 10, col: 9},
+		"indented item":            {path: []interface{}{"periodics", "knative/eventing", 0, "continuous"}, line: 15, col: 7},
+		"flow sequence":            {path: []interface{}{"periodics", "knative/eventing", 0, "env-vars", 0}, line: 16, col: 7},
+		"missing key falls back":   {path: []interface{}{"presubmits", "knative/docs"}, line: 2, col: 1},
+		"missing index falls back": {path: []interface{}{"presubmits", "knative/serving", 5}, line: 3, col: 3},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			n := root
+			for _, k := range tt.path {
+				n = n.child(k)
+			}
+			if n.line != tt.line || n.column != tt.col {
+				t.Errorf("position = %d:%d, want %d:%d", n.line, n.column, tt.line, tt.col)
+			}
+		})
+	}
+}
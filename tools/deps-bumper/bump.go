@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// bump.go floats the dependencies of a repo and rewrites its go.mod
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"knative.dev/test-infra/pkg/cmd"
+	"knative.dev/test-infra/pkg/git"
+	"knative.dev/test-infra/pkg/gomod"
+)
+
+// bumper bumps the dependencies of the configured repos.
+type bumper struct {
+	config  *Config
+	gcw     *GHClientWrapper
+	workDir string
+	// userID, userName and email are the github ID hosting the forks, and
+	// the author of the commits.
+	userID   string
+	userName string
+	email    string
+	dryrun   bool
+}
+
+// bump bumps the dependencies of a single repo, and creates or updates its
+// PR if they changed.
+func (b *bumper) bump(rc RepoConfig) error {
+	dir := filepath.Join(b.workDir, rc.Org, rc.Repo)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	// The history is needed to push the commit to the fork, which does not
+	// accept pushes from a shallow clone.
+	cloneCmd := fmt.Sprintf("git clone --single-branch --branch %s https://github.com/%s/%s.git %s", rc.Base, rc.Org, rc.Repo, dir)
+	if out, err := cmd.RunCommand(cloneCmd); err != nil {
+		return fmt.Errorf("failed running %q:\nOutput: %q\nError: %w", cloneCmd, out, err)
+	}
+
+	changes, err := b.updateGoMod(filepath.Join(dir, rc.GoMod))
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		log.Printf("%s/%s: dependencies are up to date", rc.Org, rc.Repo)
+		return nil
+	}
+
+	if rc.PostUpdate != "" {
+		log.Printf("%s/%s: running %q", rc.Org, rc.Repo, rc.PostUpdate)
+		if out, err := cmd.RunCommand(rc.PostUpdate, cmd.WithDir(dir)); err != nil {
+			return fmt.Errorf("failed running %q:\nOutput: %q\nError: %w", rc.PostUpdate, out, err)
+		}
+	}
+
+	// MakeCommit runs git in the current directory.
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(dir); err != nil {
+		return err
+	}
+	defer os.Chdir(wd)
+
+	gi := git.Info{
+		Org:      rc.Org,
+		Repo:     rc.Repo,
+		Head:     rc.Head,
+		Base:     rc.Base,
+		UserID:   b.userID,
+		UserName: b.userName,
+		Email:    b.email,
+	}
+	return createOrUpdatePR(b.gcw, gi, b.config, changes, b.dryrun)
+}
+
+// updateGoMod floats the dependencies of the go mod file for the release, the
+// same way gomod.Float does, rewrites it, and returns how the dependencies
+// changed.
+func (b *bumper) updateGoMod(path string) ([]gomod.Change, error) {
+	before, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	after, err := gomod.Update(path, b.config.Release, b.config.Domain, git.Ruleset(b.config.Ruleset))
+	if err != nil {
+		return nil, err
+	}
+	changes, err := gomod.Diff(path, before, path, after, b.config.Domain)
+	if err != nil || len(changes) == 0 {
+		return changes, err
+	}
+	return changes, ioutil.WriteFile(path, after, 0644)
+}
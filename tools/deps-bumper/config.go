@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// config.go loads the repos to bump the dependencies of
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"knative.dev/test-infra/pkg/git"
+)
+
+const (
+	defaultDomain  = "knative.dev"
+	defaultRuleset = git.AnyRule
+	defaultBase    = "master"
+	defaultHead    = "bump-knative-deps"
+	defaultGoMod   = "go.mod"
+)
+
+// Config holds the release to bump dependencies for, and the repos to bump.
+type Config struct {
+	// Release is the release to float dependencies to, ex: "0.19".
+	Release string `yaml:"release"`
+	// Domain filters the dependencies to bump. Defaults to "knative.dev".
+	Domain string `yaml:"domain,omitempty"`
+	// Ruleset selects the refs dependencies are floated to, see
+	// git.Rulesets. Defaults to "Any".
+	Ruleset string `yaml:"ruleset,omitempty"`
+	// Repos are the repos to bump the dependencies of.
+	Repos []RepoConfig `yaml:"repos"`
+}
+
+// RepoConfig is the configuration of a single repo.
+type RepoConfig struct {
+	Org  string `yaml:"org"`
+	Repo string `yaml:"repo"`
+	// Base is the branch the PR targets. Defaults to "master".
+	Base string `yaml:"base,omitempty"`
+	// Head is the branch of the fork the changes are pushed to. Defaults to
+	// "bump-knative-deps".
+	Head string `yaml:"head,omitempty"`
+	// GoMod is the path of the go.mod file in the repo. Defaults to "go.mod".
+	GoMod string `yaml:"goMod,omitempty"`
+	// PostUpdate is the command run in the repo after go.mod is rewritten,
+	// ex: "./hack/update-deps.sh".
+	PostUpdate string `yaml:"postUpdate,omitempty"`
+}
+
+// loadConfig reads the config file at path, and applies the defaults.
+func loadConfig(path string) (*Config, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(contents, config); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	config.applyDefaults()
+	return config, nil
+}
+
+func (c *Config) applyDefaults() {
+	if c.Domain == "" {
+		c.Domain = defaultDomain
+	}
+	if c.Ruleset == "" {
+		c.Ruleset = defaultRuleset.String()
+	}
+	for i := range c.Repos {
+		r := &c.Repos[i]
+		if r.Base == "" {
+			r.Base = defaultBase
+		}
+		if r.Head == "" {
+			r.Head = defaultHead
+		}
+		if r.GoMod == "" {
+			r.GoMod = defaultGoMod
+		}
+	}
+}
+
+// validate returns an error if the config is missing a required field.
+func (c *Config) validate() error {
+	if c.Release == "" {
+		return errors.New("release is required")
+	}
+	if git.Ruleset(c.Ruleset) == git.InvalidRule {
+		return fmt.Errorf("invalid ruleset %q", c.Ruleset)
+	}
+	if len(c.Repos) == 0 {
+		return errors.New("no repos configured")
+	}
+	for i, r := range c.Repos {
+		if r.Org == "" || r.Repo == "" {
+			return fmt.Errorf("repos[%d]: org and repo are required", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadConfig(t *testing.T) {
+	got, err := loadConfig("testdata/config.yaml")
+	if err != nil {
+		t.Fatal("loadConfig() =", err)
+	}
+	want := &Config{
+		Release: "0.19",
+		Domain:  "knative.dev",
+		Ruleset: "Any",
+		Repos: []RepoConfig{{
+			Org:        "knative",
+			Repo:       "serving",
+			Base:       "master",
+			Head:       "bump-knative-deps",
+			GoMod:      "go.mod",
+			PostUpdate: "./hack/update-deps.sh --upgrade",
+		}, {
+			Org:   "knative-sandbox",
+			Repo:  "net-istio",
+			Base:  "main",
+			Head:  "bump-deps",
+			GoMod: "hack/go.mod",
+		}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error("loadConfig() diff(-want,+got):\n", diff)
+	}
+	if err := got.validate(); err != nil {
+		t.Error("validate() =", err)
+	}
+
+	if _, err := loadConfig("testdata/nope.yaml"); err == nil {
+		t.Error("loadConfig() of a missing file succeeded")
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := func() *Config {
+		c := &Config{Release: "0.19", Repos: []RepoConfig{{Org: "knative", Repo: "serving"}}}
+		c.applyDefaults()
+		return c
+	}
+	tests := map[string]struct {
+		mutate  func(c *Config)
+		wantErr bool
+	}{
+		"valid": {
+			mutate: func(c *Config) {},
+		},
+		"no release": {
+			mutate:  func(c *Config) { c.Release = "" },
+			wantErr: true,
+		},
+		"invalid ruleset": {
+			mutate:  func(c *Config) { c.Ruleset = "Nope" },
+			wantErr: true,
+		},
+		"no repos": {
+			mutate:  func(c *Config) { c.Repos = nil },
+			wantErr: true,
+		},
+		"no repo name": {
+			mutate:  func(c *Config) { c.Repos[0].Repo = "" },
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := valid()
+			tt.mutate(c)
+			if err := c.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// deps-bumper floats the knative.dev dependencies of repos for a release,
+// and creates PRs updating them
+
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"knative.dev/test-infra/pkg/ghutil"
+)
+
+func main() {
+	configPath := flag.String("config", "", "Path of the config file listing the repos to bump")
+	release := flag.String("release", "", "Release to float dependencies to, overrides the release of the config file")
+	workDir := flag.String("work-dir", os.TempDir(), "Directory to clone the repos into")
+	githubAccount := flag.String("github-account", "", "Token file for Github authentication")
+	gitUserID := flag.String("git-userid", "", "The github ID of user for hosting fork, i.e. Github ID of bot")
+	gitUserName := flag.String("git-username", "", "The username to use on the git commit. Requires --git-email")
+	gitEmail := flag.String("git-email", "", "The email to use on the git commit. Requires --git-username")
+	dryrun := flag.Bool("dry-run", false, "dry run switch")
+	flag.Parse()
+
+	if *dryrun {
+		log.Println("Running in [dry run mode]")
+	}
+
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("cannot load the config: %v", err)
+	}
+	if *release != "" {
+		config.Release = *release
+	}
+	if err := config.validate(); err != nil {
+		log.Fatalf("invalid config %s: %v", *configPath, err)
+	}
+
+	gc, err := ghutil.NewGithubClient(*githubAccount)
+	if err != nil {
+		log.Fatalf("cannot authenticate to github: %v", err)
+	}
+
+	b := &bumper{
+		config:   config,
+		gcw:      &GHClientWrapper{gc},
+		workDir:  *workDir,
+		userID:   *gitUserID,
+		userName: *gitUserName,
+		email:    *gitEmail,
+		dryrun:   *dryrun,
+	}
+
+	failed := 0
+	for _, rc := range config.Repos {
+		log.Printf("Bumping the dependencies of %s/%s", rc.Org, rc.Repo)
+		if err := b.bump(rc); err != nil {
+			log.Printf("failed bumping the dependencies of %s/%s: %v", rc.Org, rc.Repo, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		log.Fatalf("failed bumping the dependencies of %d repos", failed)
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// pullrequest.go creates git commits and Pull Requests
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+
+	"knative.dev/test-infra/pkg/ghutil"
+	"knative.dev/test-infra/pkg/git"
+	"knative.dev/test-infra/pkg/gomod"
+	"knative.dev/test-infra/pkg/helpers"
+)
+
+// GHClientWrapper handles methods for github pull requests
+type GHClientWrapper struct {
+	ghutil.GithubOperations
+}
+
+// matchTitle returns the title prefix of the PRs created by the tool, which
+// stays the same across releases.
+func matchTitle(config *Config) string {
+	return fmt.Sprintf("[Auto] Bump %s dependencies", config.Domain)
+}
+
+func generatePRTitle(config *Config) string {
+	return fmt.Sprintf("%s for release %s", matchTitle(config), config.Release)
+}
+
+// generatePRBody returns the PR body, with a table of how each dependency
+// changed.
+func generatePRBody(config *Config, changes []gomod.Change) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Bump %s dependencies to the best refs for release %s, based on the %s ruleset.\n\n",
+		config.Domain, config.Release, config.Ruleset)
+	b.WriteString("| Module | Old | New |\n")
+	b.WriteString("| --- | --- | --- |\n")
+	for _, c := range changes {
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", c.Module, refCell(c.OldVersion, c.OldKind), refCell(c.NewVersion, c.NewKind))
+	}
+	return b.String()
+}
+
+func refCell(version string, kind gomod.VersionKind) string {
+	if version == "" {
+		return "-"
+	}
+	return fmt.Sprintf("`%s` (%s)", version, kind)
+}
+
+// Get existing open PR not merged yet
+func getExistingPR(gcw *GHClientWrapper, gi git.Info, matchTitle string) (*github.PullRequest, error) {
+	var res *github.PullRequest
+	PRs, err := gcw.ListPullRequests(gi.Org, gi.Repo, gi.GetHeadRef(), gi.Base)
+	if err == nil {
+		for _, PR := range PRs {
+			if string(ghutil.PullRequestOpenState) == PR.GetState() && strings.HasPrefix(PR.GetTitle(), matchTitle) {
+				res = PR
+				break
+			}
+		}
+	}
+	return res, err
+}
+
+func createOrUpdatePR(gcw *GHClientWrapper, gi git.Info, config *Config, changes []gomod.Change, dryrun bool) error {
+	title := generatePRTitle(config)
+	body := generatePRBody(config, changes)
+	hasUpdates, err := git.MakeCommit(gi, title, dryrun)
+	if err != nil {
+		return fmt.Errorf("failed git commit: %w", err)
+	}
+	if !hasUpdates {
+		log.Print("There is nothing committed, skip PR")
+		return nil
+	}
+	existPR, err := getExistingPR(gcw, gi, matchTitle(config))
+	if err != nil {
+		return fmt.Errorf("failed querying existing pullrequests: %w", err)
+	}
+	if existPR != nil {
+		log.Printf("Found open PR %d", existPR.GetNumber())
+		return helpers.Run(
+			fmt.Sprintf("Updating PR %d, title: %q, body: %q", existPR.GetNumber(), title, body),
+			func() error {
+				if _, err := gcw.EditPullRequest(gi.Org, gi.Repo, existPR.GetNumber(), title, body); err != nil {
+					return fmt.Errorf("failed updating pullrequest: %w", err)
+				}
+				return nil
+			},
+			dryrun,
+		)
+	}
+	return helpers.Run(
+		fmt.Sprintf("Creating PR, title: %q, body: %q", title, body),
+		func() error {
+			if _, err := gcw.CreatePullRequest(gi.Org, gi.Repo, gi.GetHeadRef(), gi.Base, title, body); err != nil {
+				return fmt.Errorf("failed creating pullrequest: %w", err)
+			}
+			return nil
+		},
+		dryrun,
+	)
+}
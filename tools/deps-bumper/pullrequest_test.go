@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+
+	"knative.dev/test-infra/pkg/cmd"
+	"knative.dev/test-infra/pkg/ghutil/fakeghutil"
+	"knative.dev/test-infra/pkg/git"
+	"knative.dev/test-infra/pkg/gomod"
+)
+
+var (
+	testConfig  = &Config{Release: "0.19", Domain: "knative.dev", Ruleset: "Any"}
+	testChanges = []gomod.Change{{
+		Module:     "knative.dev/networking",
+		Change:     gomod.Added,
+		NewVersion: "v0.19.0",
+		NewKind:    gomod.TagKind,
+	}, {
+		Module:     "knative.dev/pkg",
+		Change:     gomod.Upgraded,
+		OldVersion: "v0.0.0-20201103163404-c8a0f8ae3f11",
+		OldKind:    gomod.PseudoVersionKind,
+		NewVersion: "v0.19.1-0.20201110123000-0123456789ab",
+		NewKind:    gomod.ReleaseBranchKind,
+	}}
+)
+
+func TestGeneratePRBody(t *testing.T) {
+	want := "Bump knative.dev dependencies to the best refs for release 0.19, based on the Any ruleset.\n\n" +
+		"| Module | Old | New |\n" +
+		"| --- | --- | --- |\n" +
+		"| knative.dev/networking | - | `v0.19.0` (tag) |\n" +
+		"| knative.dev/pkg | `v0.0.0-20201103163404-c8a0f8ae3f11` (pseudo-version) | `v0.19.1-0.20201110123000-0123456789ab` (release branch) |\n"
+	if diff := cmp.Diff(want, generatePRBody(testConfig, testChanges)); diff != "" {
+		t.Error("generatePRBody() diff(-want,+got):\n", diff)
+	}
+}
+
+func TestCreateOrUpdatePR(t *testing.T) {
+	// Fake a work tree with changes to commit.
+	var commands []string
+	defer func(orig func(string, ...cmd.Option) (string, error)) { cmd.RunCommand = orig }(cmd.RunCommand)
+	cmd.RunCommand = func(cmdLine string, options ...cmd.Option) (string, error) {
+		commands = append(commands, cmdLine)
+		if strings.HasPrefix(cmdLine, "git status") {
+			return " M go.mod\n", nil
+		}
+		return "", nil
+	}
+
+	fgc := fakeghutil.NewFakeGithubClient()
+	fgc.PullRequests["serving"] = map[int]*github.PullRequest{}
+	gcw := &GHClientWrapper{fgc}
+	gi := git.Info{Org: "knative", Repo: "serving", Head: "bump-knative-deps", Base: "master", UserID: "bot"}
+
+	// The first run creates the PR.
+	if err := createOrUpdatePR(gcw, gi, testConfig, testChanges[:1], false); err != nil {
+		t.Fatal("createOrUpdatePR() =", err)
+	}
+	if len(fgc.PullRequests["serving"]) != 1 {
+		t.Fatalf("got %d PRs, want 1", len(fgc.PullRequests["serving"]))
+	}
+	wantCommands := []string{
+		"git status --porcelain",
+		"git add -A",
+		`git commit -m "[Auto] Bump knative.dev dependencies for release 0.19"`,
+		"git push -f git@github.com:bot/serving.git HEAD:bump-knative-deps",
+	}
+	if diff := cmp.Diff(wantCommands, commands); diff != "" {
+		t.Error("createOrUpdatePR() commands diff(-want,+got):\n", diff)
+	}
+
+	// The next run, for another release, updates it.
+	next := *testConfig
+	next.Release = "0.20"
+	if err := createOrUpdatePR(gcw, gi, &next, testChanges, false); err != nil {
+		t.Fatal("createOrUpdatePR() =", err)
+	}
+	if len(fgc.PullRequests["serving"]) != 1 {
+		t.Fatalf("got %d PRs, want 1", len(fgc.PullRequests["serving"]))
+	}
+	for _, pr := range fgc.PullRequests["serving"] {
+		if got, want := pr.GetTitle(), "[Auto] Bump knative.dev dependencies for release 0.20"; got != want {
+			t.Errorf("PR title = %q, want %q", got, want)
+		}
+		if got, want := pr.GetBody(), generatePRBody(&next, testChanges); got != want {
+			t.Errorf("PR body = %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2020 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// license-checker verifies that the licenses of the vendored go modules of a
+// repo are allowed, and that they are copied to its VENDOR-LICENSE tree
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"knative.dev/test-infra/pkg/licenses"
+)
+
+func main() {
+	repoDir := flag.String("repo", ".", "Path of the repo holding go.mod and vendor/")
+	licenseDir := flag.String("license-dir", licenses.DefaultLicenseDir, "Path of the license tree relative to --repo")
+	allow := flag.String("allow", strings.Join(licenses.DefaultAllowed, ","), "Comma separated SPDX identifiers of the allowed licenses")
+	update := flag.Bool("update", false, "Regenerate the license tree before checking it")
+	flag.Parse()
+
+	opts := licenses.Options{
+		RepoDir:    *repoDir,
+		LicenseDir: *licenseDir,
+		Allowed:    strings.Split(*allow, ","),
+	}
+
+	check := licenses.Check
+	if *update {
+		check = licenses.Update
+	}
+	report, err := check(opts)
+	if err != nil {
+		log.Fatalf("cannot check the licenses of %s: %v", *repoDir, err)
+	}
+
+	for _, issue := range report.Issues {
+		fmt.Printf("%s: %s: %s\n", issue.Kind, issue.Path, issue.Detail)
+	}
+	if len(report.Issues) > 0 {
+		log.Printf("found %d license issues in %d libraries", len(report.Issues), len(report.Libraries))
+		os.Exit(1)
+	}
+	log.Printf("the licenses of %d libraries are allowed and up to date", len(report.Libraries))
+}
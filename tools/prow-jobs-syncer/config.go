@@ -35,6 +35,7 @@ const (
 	pluginPath         = "config/prod/prow/core/plugins.yaml"
 	testgridConfigPath = "config/prod/prow/testgrid/testgrid.yaml"
 	templateConfigPath = "config/prod/prow/config_knative.yaml"
+	inventoryPath      = "config/prod/prow/inventory.yaml"
 
 	configGenPath = "tools/config-generator"
 
@@ -47,18 +47,59 @@ func main() {
 
 	gopath := os.Getenv("GOPATH")
 
-	configgenArgs := []string{
-		"--prow-jobs-config-output",
+	configgenFullPath := path.Join(gopath, repoPath, configGenPath)
+
+	// Refresh the default branches of the repos from GitHub, so that the
+	// configs generated below pick them up.
+	inventoryArgs := []string{
+		"--refresh-inventory",
+		"--inventory",
+		path.Join(gopath, repoPath, inventoryPath),
+		"--github-token-path",
+		*githubAccount,
+		path.Join(gopath, repoPath, templateConfigPath),
+	}
+	if _, err := cmd.RunCommand(fmt.Sprintf("go run %s %s",
+		configgenFullPath, strings.Join(inventoryArgs, " "))); err != nil {
+		log.Fatalf("failed refreshing the repos inventory: %v", err)
+	}
+
+	// Compare the configs generated from the template config with upgraded
+	// release branches with the existing ones, to summarize the changes in the
+	// PR body. Nothing is written in diff mode.
+	diffArgs := []string{
+		"--diff",
 		path.Join(gopath, repoPath, jobConfigPath),
-		"--testgrid-config-output",
+		"--diff",
 		path.Join(gopath, repoPath, testgridConfigPath),
 		"--upgrade-release-branches",
 		"--github-token-path",
 		*githubAccount,
+		"--inventory",
+		path.Join(gopath, repoPath, inventoryPath),
 		path.Join(gopath, repoPath, templateConfigPath),
 	}
+	summary, err := cmd.RunCommand(fmt.Sprintf("go run %s %s",
+		configgenFullPath, strings.Join(diffArgs, " ")))
+	if err != nil {
+		log.Fatalf("failed comparing the generated configs: %v", err)
+	}
+	log.Print(summary)
 
-	configgenFullPath := path.Join(gopath, repoPath, configGenPath)
+	// Then upgrade the release branches in the template config, and generate
+	// the configs from it.
+	configgenArgs := []string{
+		"--upgrade-release-branches",
+		"--github-token-path",
+		*githubAccount,
+		"--prow-jobs-config-output",
+		path.Join(gopath, repoPath, jobConfigPath),
+		"--testgrid-config-output",
+		path.Join(gopath, repoPath, testgridConfigPath),
+		"--inventory",
+		path.Join(gopath, repoPath, inventoryPath),
+		path.Join(gopath, repoPath, templateConfigPath),
+	}
 
 	log.Print(cmd.RunCommand(fmt.Sprintf("go run %s %s",
 		configgenFullPath, strings.Join(configgenArgs, " "))))
@@ -79,7 +120,7 @@ func main() {
 	}
 
 	gcw := &GHClientWrapper{gc}
-	if err = createOrUpdatePR(gcw, targetGI, *dryrun); err != nil {
+	if err = createOrUpdatePR(gcw, targetGI, summary, *dryrun); err != nil {
 		log.Fatalf("failed creating pullrequest: '%v'", err)
 	}
 }
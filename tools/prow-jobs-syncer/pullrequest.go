@@ -32,8 +32,13 @@ import (
 	"knative.dev/test-infra/pkg/git"
 )
 
-func generatePRBody() string {
+// generatePRBody returns the PR body, with the given summary of the changes
+// of the generated configs.
+func generatePRBody(summary string) string {
 	body := "PR created for syncing release branches changes\n"
+	if summary != "" {
+		body += "\n```\n" + summary + "```\n\n"
+	}
 	oncaller, err := getOncaller()
 	assignment := "Nobody is currently oncall."
 	if err == nil {
@@ -82,11 +87,11 @@ func getExistingPR(gcw *GHClientWrapper, gi git.Info, matchTitle string) (*githu
 	return res, err
 }
 
-func createOrUpdatePR(gcw *GHClientWrapper, gi git.Info, dryrun bool) error {
+func createOrUpdatePR(gcw *GHClientWrapper, gi git.Info, summary string, dryrun bool) error {
 	const matchTitle = "[Auto] Update prow jobs for release branches"
 	commitMsg := matchTitle
 	title := commitMsg
-	body := generatePRBody()
+	body := generatePRBody(summary)
 	hasUpdates, err := git.MakeCommit(gi, commitMsg, dryrun)
 	if err != nil {
 		return fmt.Errorf("failed git commit: %w", err)